@@ -0,0 +1,108 @@
+package bbnclient
+
+import (
+	"context"
+	"fmt"
+
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+)
+
+// QueryFinalityProvider returns the state of the finality provider with the
+// given BIP340 public key, including whether it was slashed or jailed
+func (c *Client) QueryFinalityProvider(ctx context.Context, fpBtcPkHex string) (*bstypes.FinalityProviderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bstypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.FinalityProvider(ctx, &bstypes.QueryFinalityProviderRequest{FpBtcPkHex: fpBtcPkHex})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finality provider %s: %w", fpBtcPkHex, err)
+	}
+	if res.FinalityProvider == nil {
+		return nil, fmt.Errorf("finality provider %s not found", fpBtcPkHex)
+	}
+
+	return res.FinalityProvider, nil
+}
+
+// QueryFinalityProviders returns the finality providers securing Babylon
+func (c *Client) QueryFinalityProviders(ctx context.Context) ([]*bstypes.FinalityProviderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bstypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.FinalityProviders(ctx, &bstypes.QueryFinalityProvidersRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finality providers: %w", err)
+	}
+
+	return res.FinalityProviders, nil
+}
+
+// QueryConsumerFinalityProviders returns the finality providers registered
+// for the consumer consumerID
+func (c *Client) QueryConsumerFinalityProviders(ctx context.Context, consumerID string) ([]*bsctypes.FinalityProviderResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bsctypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.FinalityProviders(ctx, &bsctypes.QueryFinalityProvidersRequest{ConsumerId: consumerID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finality providers of consumer %s: %w", consumerID, err)
+	}
+
+	return res.FinalityProviders, nil
+}
+
+// QueryBTCDelegations returns the BTC delegations with the given status
+func (c *Client) QueryBTCDelegations(ctx context.Context, status bstypes.BTCDelegationStatus) ([]*bstypes.BTCDelegationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bstypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.BTCDelegations(ctx, &bstypes.QueryBTCDelegationsRequest{Status: status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s BTC delegations: %w", status, err)
+	}
+
+	return res.BtcDelegations, nil
+}
+
+// QueryBTCDelegation returns the BTC delegation whose staking tx has the
+// given hash
+func (c *Client) QueryBTCDelegation(ctx context.Context, stakingTxHashHex string) (*bstypes.BTCDelegationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bstypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.BTCDelegation(ctx, &bstypes.QueryBTCDelegationRequest{StakingTxHashHex: stakingTxHashHex})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query BTC delegation %s: %w", stakingTxHashHex, err)
+	}
+	if res.BtcDelegation == nil {
+		return nil, fmt.Errorf("BTC delegation %s not found", stakingTxHashHex)
+	}
+
+	return res.BtcDelegation, nil
+}
+
+// QueryConsumerRegistration returns the registration of the consumer
+// consumerID, including the address of its finality contract
+func (c *Client) QueryConsumerRegistration(ctx context.Context, consumerID string) (*bsctypes.ConsumerRegisterResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := bsctypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.ConsumersRegistry(ctx, &bsctypes.QueryConsumersRegistryRequest{ConsumerIds: []string{consumerID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consumer %s: %w", consumerID, err)
+	}
+	for _, consumer := range res.ConsumerRegisters {
+		if consumer.ConsumerId == consumerID {
+			return consumer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("consumer %s not registered", consumerID)
+}
@@ -0,0 +1,234 @@
+package bbnclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+	rpchttp "github.com/cometbft/cometbft/rpc/client/http"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectestutil "github.com/cosmos/cosmos-sdk/codec/testutil"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client talks to a Babylon node directly over CometBFT RPC and, optionally,
+// gRPC. It signs transactions with a key from a local Cosmos SDK keyring.
+type Client struct {
+	cfg       Config
+	rpc       *rpchttp.HTTP
+	grpcConn  *grpc.ClientConn
+	clientCtx client.Context
+	txConfig  client.TxConfig
+
+	// signer is the account of Key, nil for a read-only client, and payers
+	// the accounts taking turns paying for contract executions
+	signer *account
+	payers *payerPool
+
+	// wsMu guards starting and stopping the websocket client, its single
+	// Tx event subscription and txWaiters, the channels of the waiters of
+	// each tx hash the events are dispatched to
+	wsMu         sync.Mutex
+	txSubscribed bool
+	txWaiters    map[string][]chan *sdk.TxResponse
+}
+
+// New creates a client from the given configuration and resolves the
+// address of the signing key, if one is configured
+func New(cfg Config) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid client config: %w", err)
+	}
+
+	ir := codectestutil.CodecOptions{
+		AccAddressPrefix: cfg.AccountPrefix,
+		ValAddressPrefix: cfg.AccountPrefix + "valoper",
+	}.NewInterfaceRegistry()
+	std.RegisterInterfaces(ir)
+	authtypes.RegisterInterfaces(ir)
+	banktypes.RegisterInterfaces(ir)
+	wasmtypes.RegisterInterfaces(ir)
+	bstypes.RegisterInterfaces(ir)
+	bsctypes.RegisterInterfaces(ir)
+	cdc := codec.NewProtoCodec(ir)
+	txConfig := authtx.NewTxConfig(cdc, authtx.DefaultSignModes)
+
+	kr, err := keyring.New(sdk.KeyringServiceName(), cfg.KeyringBackend, cfg.KeyringDir, os.Stdin, cdc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keyring at %s: %w", cfg.KeyringDir, err)
+	}
+	var fromAddr sdk.AccAddress
+	var signer *account
+	var payers []*account
+	if cfg.Key != "" {
+		signer, err = newAccount(kr, cfg.Key)
+		if err != nil {
+			return nil, err
+		}
+		fromAddr = signer.addr
+		payers = append(payers, signer)
+	}
+	for _, name := range cfg.FeePayers {
+		payer, err := newAccount(kr, name)
+		if err != nil {
+			return nil, err
+		}
+		payers = append(payers, payer)
+	}
+
+	rpcClient, err := rpchttp.NewWithTimeout(cfg.RPCAddr, "/websocket", uint(cfg.Timeout.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rpc client for %s: %w", cfg.RPCAddr, err)
+	}
+
+	clientCtx := client.Context{}.
+		WithChainID(cfg.ChainID).
+		WithCodec(cdc).
+		WithInterfaceRegistry(ir).
+		WithTxConfig(txConfig).
+		WithKeyring(kr).
+		WithClient(rpcClient).
+		WithNodeURI(cfg.RPCAddr).
+		WithAccountRetriever(authtypes.AccountRetriever{}).
+		WithBroadcastMode(flags.BroadcastSync).
+		WithFromName(cfg.Key).
+		WithFromAddress(fromAddr)
+
+	c := &Client{
+		cfg:      cfg,
+		rpc:      rpcClient,
+		txConfig: txConfig,
+		signer:   signer,
+		payers:   newPayerPool(payers),
+	}
+
+	if cfg.GRPCAddr != "" {
+		conn, err := dialGRPC(cfg.GRPCAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to grpc %s: %w", cfg.GRPCAddr, err)
+		}
+		c.grpcConn = conn
+		clientCtx = clientCtx.WithGRPCClient(conn)
+	}
+	c.clientCtx = clientCtx
+
+	return c, nil
+}
+
+// dialGRPC connects to a gRPC endpoint. An https:// scheme enables TLS,
+// anything else is treated as a plaintext connection.
+func dialGRPC(addr string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	switch {
+	case strings.HasPrefix(addr, "https://"):
+		addr = strings.TrimPrefix(addr, "https://")
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	case strings.HasPrefix(addr, "http://"):
+		addr = strings.TrimPrefix(addr, "http://")
+	}
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+}
+
+// Close stops the websocket client and releases the gRPC connection, if any
+func (c *Client) Close() error {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.rpc.IsRunning() {
+		if err := c.rpc.Stop(); err != nil {
+			return err
+		}
+	}
+	if c.grpcConn != nil {
+		return c.grpcConn.Close()
+	}
+	return nil
+}
+
+// Address returns the bech32 address of the signing key
+func (c *Client) Address() string {
+	return c.clientCtx.GetFromAddress().String()
+}
+
+// SendMsgs builds, signs and broadcasts a transaction carrying msgs, signed
+// by Key. It returns once the node accepted the transaction into its
+// mempool; a non-zero CheckTx code is reported as an error.
+func (c *Client) SendMsgs(ctx context.Context, msgs ...sdk.Msg) (*sdk.TxResponse, error) {
+	if c.signer == nil {
+		return nil, fmt.Errorf("no signing key configured")
+	}
+	return c.sendFrom(ctx, c.signer, msgs)
+}
+
+// signAndBroadcast builds a transaction carrying msgs with the account
+// number and sequence of acc, signs it with the key of acc and broadcasts it
+func (c *Client) signAndBroadcast(ctx context.Context, acc *account, msgs []sdk.Msg) (*sdk.TxResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txf := tx.Factory{}.
+		WithChainID(c.cfg.ChainID).
+		WithKeybase(c.clientCtx.Keyring).
+		WithTxConfig(c.txConfig).
+		WithAccountRetriever(c.clientCtx.AccountRetriever).
+		WithAccountNumber(acc.number).
+		WithSequence(acc.sequence).
+		WithGas(c.cfg.Gas).
+		WithGasAdjustment(c.cfg.GasAdjustment).
+		WithSignMode(signing.SignMode_SIGN_MODE_DIRECT)
+	if c.cfg.Fees != "" {
+		txf = txf.WithFees(c.cfg.Fees)
+	} else {
+		txf = txf.WithGasPrices(c.cfg.GasPrices)
+	}
+
+	clientCtx := c.clientCtx.WithFromName(acc.name).WithFromAddress(acc.addr)
+	if c.cfg.Gas == 0 {
+		_, adjusted, err := tx.CalculateGas(clientCtx, txf, msgs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate tx: %w", err)
+		}
+		txf = txf.WithGas(adjusted)
+	}
+
+	txBuilder, err := txf.BuildUnsignedTx(msgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tx: %w", err)
+	}
+	if err := tx.Sign(ctx, txf, acc.name, txBuilder, true); err != nil {
+		return nil, fmt.Errorf("failed to sign tx: %w", err)
+	}
+	txBytes, err := c.txConfig.TxEncoder()(txBuilder.GetTx())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tx: %w", err)
+	}
+
+	resBroadcast, err := c.rpc.BroadcastTxSync(ctx, txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast tx: %w", err)
+	}
+	res := sdk.NewResponseFormatBroadcastTx(resBroadcast)
+	if res.Code != 0 {
+		return res, fmt.Errorf("tx %s rejected with code %d (codespace %s): %s",
+			res.TxHash, res.Code, res.Codespace, res.RawLog)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package bbnclient
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds the connection and signing parameters of a Babylon client.
+// Field names follow the [babylon] section of fpd.conf.
+type Config struct {
+	// ChainID is the chain id of the Babylon chain to connect to
+	ChainID string
+	// RPCAddr is the address of the CometBFT RPC endpoint, e.g. http://localhost:26657
+	RPCAddr string
+	// GRPCAddr is the optional address of the gRPC endpoint, e.g. localhost:9090.
+	// Queries go through ABCI over RPCAddr when it is empty.
+	GRPCAddr string
+	// AccountPrefix is the bech32 prefix of account addresses
+	AccountPrefix string
+	// KeyringDir is the directory holding the keyring (e.g. <dir>/keyring-test)
+	KeyringDir string
+	// KeyringBackend is the type of keyring to use (test, file, os)
+	KeyringBackend string
+	// Key is the name of the key that signs and pays for transactions.
+	// The client is read-only when it is empty.
+	Key string
+	// FeePayers are the names of funded keys of the keyring that take turns
+	// with Key sending contract executions, so that many can be in flight
+	// at once without waiting on the sequence of a single account
+	FeePayers []string
+	// MaxSequenceRetries is the number of times a transaction rejected for
+	// its account sequence is signed again with the sequence the node
+	// expects
+	MaxSequenceRetries int
+	// Gas is the gas limit of each transaction; zero means simulate
+	Gas uint64
+	// GasAdjustment is the factor applied to simulated gas
+	GasAdjustment float64
+	// GasPrices are the gas prices used when Fees is empty
+	GasPrices string
+	// Fees are the fixed fees attached to each transaction
+	Fees string
+	// Timeout bounds each query and broadcast
+	Timeout time.Duration
+	// TxWaitTimeout bounds the wait for a broadcast tx to be included
+	TxWaitTimeout time.Duration
+	// TxPollInterval is the interval between tx queries while waiting,
+	// which with UseWebsocket catch the events the websocket dropped
+	TxPollInterval time.Duration
+	// UseWebsocket waits for inclusion through a websocket Tx event
+	// subscription, shared by every concurrent wait, instead of polling
+	UseWebsocket bool
+}
+
+// DefaultConfig returns a configuration matching the local
+// rollup-btc-staking-demo deployment.
+func DefaultConfig() Config {
+	return Config{
+		ChainID:            "chain-test",
+		RPCAddr:            "http://localhost:26657",
+		GRPCAddr:           "",
+		AccountPrefix:      "bbn",
+		KeyringDir:         ".testnets/node0/babylond",
+		KeyringBackend:     "test",
+		Key:                "test-spending-key",
+		FeePayers:          nil,
+		MaxSequenceRetries: 5,
+		Gas:                500000,
+		GasAdjustment:      1.5,
+		GasPrices:          "0.01ubbn",
+		Fees:               "100000ubbn",
+		Timeout:            20 * time.Second,
+		TxWaitTimeout:      1 * time.Minute,
+		TxPollInterval:     1 * time.Second,
+		UseWebsocket:       false,
+	}
+}
+
+// Validate checks that the configuration is usable
+func (cfg *Config) Validate() error {
+	if cfg.ChainID == "" {
+		return fmt.Errorf("chain id must be set")
+	}
+	if cfg.RPCAddr == "" {
+		return fmt.Errorf("rpc address must be set")
+	}
+	if cfg.Gas == 0 && cfg.GasAdjustment <= 0 {
+		return fmt.Errorf("gas adjustment must be positive when gas is simulated")
+	}
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if cfg.TxWaitTimeout <= 0 {
+		return fmt.Errorf("tx wait timeout must be positive")
+	}
+	if !cfg.UseWebsocket && cfg.TxPollInterval <= 0 {
+		return fmt.Errorf("tx poll interval must be positive")
+	}
+	if cfg.MaxSequenceRetries < 0 {
+		return fmt.Errorf("max sequence retries must not be negative")
+	}
+	seen := map[string]bool{cfg.Key: true}
+	for _, name := range cfg.FeePayers {
+		if seen[name] {
+			return fmt.Errorf("fee payer %q is listed twice or is the signing key", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
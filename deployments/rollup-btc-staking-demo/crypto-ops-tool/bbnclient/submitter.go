@@ -0,0 +1,202 @@
+package bbnclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+// account is a key of the keyring that signs and pays for transactions. Its
+// sequence is tracked locally, so that the next transaction of the key is
+// broadcast as soon as the previous one is in the mempool rather than once
+// it is included.
+type account struct {
+	name string
+	addr sdk.AccAddress
+
+	// mu is held while a transaction of the account is signed and
+	// broadcast, and guards the fields below
+	mu sync.Mutex
+	// synced is cleared when the sequence must be read from the chain again
+	synced   bool
+	number   uint64
+	sequence uint64
+}
+
+// newAccount resolves the address of the key name
+func newAccount(kr keyring.Keyring, name string) (*account, error) {
+	record, err := kr.Key(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key %q: %w", name, err)
+	}
+	addr, err := record.GetAddress()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address of key %q: %w", name, err)
+	}
+	return &account{name: name, addr: addr}, nil
+}
+
+// sendFrom signs msgs with acc at its local sequence and broadcasts them,
+// then moves the sequence on, see account.send
+func (c *Client) sendFrom(ctx context.Context, acc *account, msgs []sdk.Msg) (*sdk.TxResponse, error) {
+	query := func() (uint64, uint64, error) {
+		return c.clientCtx.AccountRetriever.GetAccountNumberSequence(c.clientCtx, acc.addr)
+	}
+	broadcast := func() (*sdk.TxResponse, error) {
+		return c.signAndBroadcast(ctx, acc, msgs)
+	}
+	return acc.send(c.cfg.MaxSequenceRetries, query, broadcast)
+}
+
+// send broadcasts a transaction of the account at its local sequence, read
+// with query when it is not synced, and moves the sequence on. A
+// transaction rejected for its sequence, as after a transaction of the key
+// sent by another process or evicted from the mempool, is signed again
+// with the sequence the node expects, up to maxRetries times.
+func (acc *account) send(maxRetries int, query func() (number, sequence uint64, err error), broadcast func() (*sdk.TxResponse, error)) (*sdk.TxResponse, error) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if !acc.synced {
+			number, sequence, err := query()
+			if err != nil {
+				return nil, fmt.Errorf("failed to query account %s of key %q: %w", acc.addr, acc.name, err)
+			}
+			acc.number, acc.sequence, acc.synced = number, sequence, true
+		}
+
+		res, err := broadcast()
+		if err == nil {
+			acc.sequence++
+			return res, nil
+		}
+		if !isSequenceMismatch(err) {
+			return res, err
+		}
+		if expected, ok := expectedSequence(err); ok {
+			acc.sequence = expected
+		} else {
+			acc.synced = false
+		}
+		if attempt >= maxRetries {
+			return res, fmt.Errorf("sequence of key %q still mismatched after %d retries: %w", acc.name, attempt, err)
+		}
+	}
+}
+
+// sequenceMismatchPattern matches the sequence the node expects in the log
+// of a transaction the ante handler rejected for its sequence
+var sequenceMismatchPattern = regexp.MustCompile(`account sequence mismatch, expected (\d+)`)
+
+// isSequenceMismatch reports whether the simulation or CheckTx error
+// rejected a transaction for its sequence
+func isSequenceMismatch(err error) bool {
+	return strings.Contains(err.Error(), sdkerrors.ErrWrongSequence.Error())
+}
+
+// expectedSequence returns the sequence the node expected of a transaction
+// rejected for its sequence
+func expectedSequence(err error) (uint64, bool) {
+	match := sequenceMismatchPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+	sequence, convErr := strconv.ParseUint(match[1], 10, 64)
+	if convErr != nil {
+		return 0, false
+	}
+	return sequence, true
+}
+
+// payerPool hands out the accounts paying for contract executions in turn,
+// each to one transaction at a time
+type payerPool struct {
+	idle chan *account
+}
+
+func newPayerPool(accounts []*account) *payerPool {
+	p := &payerPool{idle: make(chan *account, len(accounts))}
+	for _, acc := range accounts {
+		p.idle <- acc
+	}
+	return p
+}
+
+// acquire waits for an idle account
+func (p *payerPool) acquire(ctx context.Context) (*account, error) {
+	if cap(p.idle) == 0 {
+		return nil, fmt.Errorf("no signing key or fee payer configured")
+	}
+	select {
+	case acc := <-p.idle:
+		return acc, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release hands the account back to the pool
+func (p *payerPool) release(acc *account) {
+	p.idle <- acc
+}
+
+// sendFromPool broadcasts the messages msgs builds for the sender, from the
+// next idle account of Key and FeePayers. The account goes back to the pool
+// once the node accepted the transaction into its mempool.
+func (c *Client) sendFromPool(ctx context.Context, msgs func(sender string) ([]sdk.Msg, error)) (*sdk.TxResponse, error) {
+	acc, err := c.payers.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.payers.release(acc)
+
+	built, err := msgs(acc.addr.String())
+	if err != nil {
+		return nil, err
+	}
+	return c.sendFrom(ctx, acc, built)
+}
+
+// FundFeePayers creates the keys names in the keyring unless they exist and
+// sends amount from Key to each of them in a single transaction, so that
+// they can serve as FeePayers. The mnemonics of new keys are not kept. It
+// returns the address of every key.
+func (c *Client) FundFeePayers(ctx context.Context, names []string, amount sdk.Coins) ([]string, *sdk.TxResponse, error) {
+	if c.signer == nil {
+		return nil, nil, fmt.Errorf("no signing key configured")
+	}
+	kr := c.clientCtx.Keyring
+	addrs := make([]string, len(names))
+	msgs := make([]sdk.Msg, len(names))
+	for i, name := range names {
+		record, err := kr.Key(name)
+		if err != nil {
+			record, _, err = kr.NewMnemonic(name, keyring.English, sdk.FullFundraiserPath, keyring.DefaultBIP39Passphrase, hd.Secp256k1)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create key %q: %w", name, err)
+			}
+		}
+		addr, err := record.GetAddress()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get address of key %q: %w", name, err)
+		}
+		addrs[i] = addr.String()
+		msgs[i] = banktypes.NewMsgSend(c.signer.addr, addr, amount)
+	}
+
+	res, err := c.BroadcastAndWait(ctx, msgs...)
+	if err != nil {
+		return nil, res, fmt.Errorf("failed to fund fee payers: %w", err)
+	}
+	return addrs, res, nil
+}
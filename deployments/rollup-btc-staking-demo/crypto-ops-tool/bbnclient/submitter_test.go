@@ -0,0 +1,205 @@
+package bbnclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Errors of transactions rejected for their sequence by the ante handler of
+// the SDK, as returned through simulation and CheckTx
+var sequenceErrors = map[string]struct {
+	err      error
+	expected uint64
+}{
+	"simulation": {
+		err: fmt.Errorf("failed to simulate tx: %w", errors.New("rpc error: code = Unknown desc = account sequence mismatch, expected 12, got 11: "+
+			"incorrect account sequence [cosmos/cosmos-sdk@v0.50.13/x/auth/ante/sigverify.go:290] with gas used: '35369': unknown request")),
+		expected: 12,
+	},
+	"check tx": {
+		err:      errors.New("tx 5F1A rejected with code 32 (codespace sdk): account sequence mismatch, expected 7, got 9: incorrect account sequence"),
+		expected: 7,
+	},
+}
+
+func TestExpectedSequence(t *testing.T) {
+	for name, tc := range sequenceErrors {
+		if !isSequenceMismatch(tc.err) {
+			t.Errorf("%s: not a sequence mismatch", name)
+		}
+		got, ok := expectedSequence(tc.err)
+		if !ok || got != tc.expected {
+			t.Errorf("%s: expected sequence = %d, %v, want %d", name, got, ok, tc.expected)
+		}
+	}
+
+	for _, msg := range []string{
+		"insufficient fees; got: 10ubbn required: 200ubbn: insufficient fee",
+		"out of gas in location: WriteFlat; gasWanted: 200000, gasUsed: 200550: out of gas",
+	} {
+		if isSequenceMismatch(errors.New(msg)) {
+			t.Errorf("%q is a sequence mismatch", msg)
+		}
+	}
+
+	// A mismatch without the expected sequence is still one
+	err := errors.New("incorrect account sequence")
+	if !isSequenceMismatch(err) {
+		t.Error("bare ErrWrongSequence is not a sequence mismatch")
+	}
+	if _, ok := expectedSequence(err); ok {
+		t.Error("expected sequence parsed from an error without one")
+	}
+}
+
+// fakeChain answers the account queries and broadcasts of an account
+type fakeChain struct {
+	sequence   uint64
+	queries    int
+	broadcasts []uint64
+}
+
+func (f *fakeChain) query() (uint64, uint64, error) {
+	f.queries++
+	return 1, f.sequence, nil
+}
+
+// broadcast accepts a transaction at the chain's sequence only
+func (f *fakeChain) broadcast(acc *account, errText func(expected, got uint64) string) func() (*sdk.TxResponse, error) {
+	return func() (*sdk.TxResponse, error) {
+		f.broadcasts = append(f.broadcasts, acc.sequence)
+		if acc.sequence != f.sequence {
+			return &sdk.TxResponse{Code: 32}, errors.New(errText(f.sequence, acc.sequence))
+		}
+		f.sequence++
+		return &sdk.TxResponse{}, nil
+	}
+}
+
+func mismatch(expected, got uint64) string {
+	return fmt.Sprintf("account sequence mismatch, expected %d, got %d: incorrect account sequence", expected, got)
+}
+
+func bareMismatch(uint64, uint64) string {
+	return "incorrect account sequence"
+}
+
+func TestAccountSendBumpsSequence(t *testing.T) {
+	chain := &fakeChain{sequence: 5}
+	acc := &account{name: "fp"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := acc.send(3, chain.query, chain.broadcast(acc, mismatch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The sequence is queried once and then tracked locally
+	if chain.queries != 1 {
+		t.Errorf("%d account queries, want 1", chain.queries)
+	}
+	if acc.sequence != 8 || fmt.Sprint(chain.broadcasts) != "[5 6 7]" {
+		t.Errorf("local sequence %d after broadcasts at %v", acc.sequence, chain.broadcasts)
+	}
+}
+
+func TestAccountSendTakesExpectedSequence(t *testing.T) {
+	chain := &fakeChain{sequence: 5}
+	acc := &account{name: "fp", synced: true, sequence: 5}
+
+	// Another process sent two transactions of the key
+	chain.sequence = 7
+	if _, err := acc.send(3, chain.query, chain.broadcast(acc, mismatch)); err != nil {
+		t.Fatal(err)
+	}
+	if chain.queries != 0 {
+		t.Errorf("%d account queries, want the sequence of the error", chain.queries)
+	}
+	if acc.sequence != 8 || fmt.Sprint(chain.broadcasts) != "[5 7]" {
+		t.Errorf("local sequence %d after broadcasts at %v", acc.sequence, chain.broadcasts)
+	}
+}
+
+func TestAccountSendResyncsWithoutExpectedSequence(t *testing.T) {
+	chain := &fakeChain{sequence: 7}
+	acc := &account{name: "fp", synced: true, sequence: 5}
+
+	if _, err := acc.send(3, chain.query, chain.broadcast(acc, bareMismatch)); err != nil {
+		t.Fatal(err)
+	}
+	if chain.queries != 1 {
+		t.Errorf("%d account queries, want the sequence to be read again once", chain.queries)
+	}
+	if acc.sequence != 8 {
+		t.Errorf("local sequence %d, want 8", acc.sequence)
+	}
+}
+
+func TestAccountSendGivesUp(t *testing.T) {
+	chain := &fakeChain{sequence: 5}
+	acc := &account{name: "fp", synced: true, sequence: 1}
+
+	// The chain moves on at every attempt
+	broadcast := func() (*sdk.TxResponse, error) {
+		chain.broadcasts = append(chain.broadcasts, acc.sequence)
+		chain.sequence++
+		return nil, errors.New(mismatch(chain.sequence, acc.sequence))
+	}
+	_, err := acc.send(2, chain.query, broadcast)
+	if err == nil || len(chain.broadcasts) != 3 {
+		t.Fatalf("err = %v after %d broadcasts, want a failure after 2 retries", err, len(chain.broadcasts))
+	}
+
+	// Other errors are not retried and keep the sequence
+	acc = &account{name: "fp", synced: true, sequence: 5}
+	calls := 0
+	_, err = acc.send(2, chain.query, func() (*sdk.TxResponse, error) {
+		calls++
+		return nil, errors.New("insufficient fee")
+	})
+	if err == nil || calls != 1 || acc.sequence != 5 {
+		t.Fatalf("err = %v after %d broadcasts, sequence %d", err, calls, acc.sequence)
+	}
+}
+
+func TestPayerPoolRoundRobin(t *testing.T) {
+	a, b, c := &account{name: "a"}, &account{name: "b"}, &account{name: "c"}
+	pool := newPayerPool([]*account{a, b, c})
+	ctx := context.Background()
+
+	var order []string
+	for i := 0; i < 6; i++ {
+		acc, err := pool.acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, acc.name)
+		pool.release(acc)
+	}
+	if fmt.Sprint(order) != "[a b c a b c]" {
+		t.Fatalf("accounts taken in order %v", order)
+	}
+
+	// Each account serves one transaction at a time
+	held := make([]*account, 3)
+	for i := range held {
+		held[i], _ = pool.acquire(ctx)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.acquire(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want to wait for an idle account", err)
+	}
+	pool.release(held[1])
+	if acc, err := pool.acquire(ctx); err != nil || acc != b {
+		t.Fatalf("acquired %v, %v, want the released account", acc, err)
+	}
+
+	if _, err := newPayerPool(nil).acquire(ctx); err == nil {
+		t.Fatal("empty pool handed out an account")
+	}
+}
@@ -0,0 +1,285 @@
+package bbnclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// txSubscriber is the subscriber name of the websocket Tx subscription
+	txSubscriber = "crypto-ops"
+	// txEventsCapacity buffers the Tx events of the subscription, which the
+	// websocket client drops when the buffer is full
+	txEventsCapacity = 1024
+)
+
+// BroadcastAndWait broadcasts a transaction carrying msgs and waits until
+// it is included in a block. A non-zero DeliverTx code is reported as an
+// error together with the response. Concurrent calls are safe: each tx
+// takes the next sequence of Key as soon as the previous one is in the
+// mempool, and they are included together.
+func (c *Client) BroadcastAndWait(ctx context.Context, msgs ...sdk.Msg) (*sdk.TxResponse, error) {
+	res, err := c.SendMsgs(ctx, msgs...)
+	if err != nil {
+		return res, err
+	}
+	return c.WaitForTx(ctx, res.TxHash)
+}
+
+// WaitForTx waits until the transaction with the given hex hash is included
+// in a block or TxWaitTimeout elapses. Inclusion is detected through a
+// websocket Tx event subscription when UseWebsocket is set and by polling
+// the tx query endpoint otherwise.
+func (c *Client) WaitForTx(ctx context.Context, txHash string) (*sdk.TxResponse, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hash %q: %w", txHash, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.TxWaitTimeout)
+	defer cancel()
+
+	var res *sdk.TxResponse
+	if c.cfg.UseWebsocket {
+		res, err = c.waitForTxEvent(ctx, hash)
+	} else {
+		res, err = c.pollForTx(ctx, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tx %s was not included within %v: %w", txHash, c.cfg.TxWaitTimeout, err)
+	}
+	if res.Code != 0 {
+		return res, fmt.Errorf("tx %s failed at height %d with code %d (codespace %s): %s",
+			res.TxHash, res.Height, res.Code, res.Codespace, res.RawLog)
+	}
+
+	return res, nil
+}
+
+func (c *Client) pollForTx(ctx context.Context, hash []byte) (*sdk.TxResponse, error) {
+	ticker := time.NewTicker(c.cfg.TxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		resTx, err := c.rpc.Tx(ctx, hash, false)
+		if err == nil {
+			return newTxResponse(resTx.Hash.String(), resTx.Height, resTx.TxResult), nil
+		}
+		// the node reports unknown transactions as an error
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForTxEvent waits for the Tx event of hash. Every waiter shares a
+// single subscription to all Tx events, since the node limits the
+// subscriptions of a client (max_subscriptions_per_client, 5 by default).
+func (c *Client) waitForTxEvent(ctx context.Context, hash []byte) (*sdk.TxResponse, error) {
+	key := fmt.Sprintf("%X", hash)
+	included, err := c.addTxWaiter(key)
+	if err != nil {
+		return nil, err
+	}
+	defer c.removeTxWaiter(key, included)
+
+	// the tx may have been included before the waiter was added
+	if resTx, err := c.rpc.Tx(ctx, hash, false); err == nil {
+		return newTxResponse(resTx.Hash.String(), resTx.Height, resTx.TxResult), nil
+	}
+
+	// Events dropped by the websocket client are caught by polling, when
+	// a poll interval is set
+	var poll <-chan time.Time
+	if c.cfg.TxPollInterval > 0 {
+		ticker := time.NewTicker(c.cfg.TxPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-included:
+			return res, nil
+		case <-poll:
+			if resTx, err := c.rpc.Tx(ctx, hash, false); err == nil {
+				return newTxResponse(resTx.Hash.String(), resTx.Height, resTx.TxResult), nil
+			}
+		}
+	}
+}
+
+// addTxWaiter subscribes to Tx events unless a concurrent waiter already
+// did, and returns the channel receiving the inclusion of the tx hash key
+func (c *Client) addTxWaiter(key string) (chan *sdk.TxResponse, error) {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+
+	if !c.txSubscribed {
+		if !c.rpc.IsRunning() {
+			if err := c.rpc.Start(); err != nil {
+				return nil, fmt.Errorf("failed to start websocket client: %w", err)
+			}
+		}
+		query := fmt.Sprintf("%s='%s'", cmttypes.EventTypeKey, cmttypes.EventTx)
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		events, err := c.rpc.Subscribe(ctx, txSubscriber, query, txEventsCapacity)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", query, err)
+		}
+		c.txSubscribed = true
+		c.txWaiters = make(map[string][]chan *sdk.TxResponse)
+		go c.dispatchTxEvents(events)
+	}
+
+	included := make(chan *sdk.TxResponse, 1)
+	c.txWaiters[key] = append(c.txWaiters[key], included)
+	return included, nil
+}
+
+// removeTxWaiter removes the channel of a waiter of the tx hash key
+func (c *Client) removeTxWaiter(key string, included chan *sdk.TxResponse) {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+
+	waiters := c.txWaiters[key]
+	for i, ch := range waiters {
+		if ch == included {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(c.txWaiters, key)
+	} else {
+		c.txWaiters[key] = waiters
+	}
+}
+
+// dispatchTxEvents sends each Tx event to the waiters of its hash until the
+// websocket client stops
+func (c *Client) dispatchTxEvents(events <-chan coretypes.ResultEvent) {
+	for {
+		select {
+		case <-c.rpc.Quit():
+			return
+		case event := <-events:
+			data, ok := event.Data.(cmttypes.EventDataTx)
+			if !ok {
+				continue
+			}
+			c.notifyTxWaiters(data.TxResult)
+		}
+	}
+}
+
+// notifyTxWaiters sends the result of an included tx to its waiters
+func (c *Client) notifyTxWaiters(result abci.TxResult) {
+	key := fmt.Sprintf("%X", cmttypes.Tx(result.Tx).Hash())
+	res := newTxResponse(key, result.Height, result.Result)
+
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	for _, included := range c.txWaiters[key] {
+		// Each channel has room for the only result of its tx
+		select {
+		case included <- res:
+		default:
+		}
+	}
+}
+
+func newTxResponse(txHash string, height int64, result abci.ExecTxResult) *sdk.TxResponse {
+	return &sdk.TxResponse{
+		TxHash:    txHash,
+		Height:    height,
+		Codespace: result.Codespace,
+		Code:      result.Code,
+		RawLog:    result.Log,
+		GasWanted: result.GasWanted,
+		GasUsed:   result.GasUsed,
+		Events:    result.Events,
+	}
+}
+
+// TxResult is the structured outcome of an included transaction
+type TxResult struct {
+	TxHash    string `json:"txhash"`
+	Height    int64  `json:"height"`
+	Code      uint32 `json:"code"`
+	Codespace string `json:"codespace,omitempty"`
+	RawLog    string `json:"raw_log"`
+	GasWanted int64  `json:"gas_wanted"`
+	GasUsed   int64  `json:"gas_used"`
+}
+
+// NewTxResult extracts the structured outcome from a tx response
+func NewTxResult(res *sdk.TxResponse) *TxResult {
+	return &TxResult{
+		TxHash:    res.TxHash,
+		Height:    res.Height,
+		Code:      res.Code,
+		Codespace: res.Codespace,
+		RawLog:    res.RawLog,
+		GasWanted: res.GasWanted,
+		GasUsed:   res.GasUsed,
+	}
+}
+
+// msgIndexPattern matches the index of the failing message that the SDK
+// baseapp puts into the log of a failed multi-message transaction
+var msgIndexPattern = regexp.MustCompile(`message index: (\d+)`)
+
+// FailedMsgIndex returns the index of the message that made a transaction
+// fail, as reported by the simulation, CheckTx or DeliverTx error. It
+// returns false when the failure cannot be attributed to a single message,
+// e.g. when the transaction ran out of gas.
+func FailedMsgIndex(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+	match := msgIndexPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+	index, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// EventAttribute returns the value of the first attribute key of an event
+// of type eventType emitted by an included transaction
+func EventAttribute(res *sdk.TxResponse, eventType, key string) (string, bool) {
+	for _, event := range res.Events {
+		if event.Type != eventType {
+			continue
+		}
+		for _, attr := range event.Attributes {
+			if attr.Key == key {
+				return attr.Value, true
+			}
+		}
+	}
+	return "", false
+}
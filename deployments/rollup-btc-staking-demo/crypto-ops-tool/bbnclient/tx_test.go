@@ -0,0 +1,53 @@
+package bbnclient
+
+import (
+	"fmt"
+	"testing"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestNotifyTxWaiters(t *testing.T) {
+	c := &Client{txWaiters: make(map[string][]chan *sdk.TxResponse)}
+	tx := cmttypes.Tx("tx")
+	key := fmt.Sprintf("%X", tx.Hash())
+
+	// Two waiters of the same tx, and one of another tx
+	first := make(chan *sdk.TxResponse, 1)
+	second := make(chan *sdk.TxResponse, 1)
+	other := make(chan *sdk.TxResponse, 1)
+	c.txWaiters[key] = []chan *sdk.TxResponse{first, second}
+	c.txWaiters["OTHER"] = []chan *sdk.TxResponse{other}
+
+	c.notifyTxWaiters(abci.TxResult{Height: 5, Tx: tx, Result: abci.ExecTxResult{Code: 3, Log: "out of gas"}})
+	for i, ch := range []chan *sdk.TxResponse{first, second} {
+		select {
+		case res := <-ch:
+			if res.TxHash != key || res.Height != 5 || res.Code != 3 || res.RawLog != "out of gas" {
+				t.Errorf("waiter %d got %+v", i, res)
+			}
+		default:
+			t.Errorf("waiter %d was not notified", i)
+		}
+	}
+	select {
+	case res := <-other:
+		t.Errorf("waiter of another tx got %+v", res)
+	default:
+	}
+
+	// A duplicate event does not block on a full channel
+	c.notifyTxWaiters(abci.TxResult{Height: 5, Tx: tx})
+	c.notifyTxWaiters(abci.TxResult{Height: 5, Tx: tx})
+
+	c.removeTxWaiter(key, first)
+	if len(c.txWaiters[key]) != 1 || c.txWaiters[key][0] != second {
+		t.Fatalf("waiters after removal = %v", c.txWaiters[key])
+	}
+	c.removeTxWaiter(key, second)
+	if _, ok := c.txWaiters[key]; ok {
+		t.Fatal("hash without waiters kept")
+	}
+}
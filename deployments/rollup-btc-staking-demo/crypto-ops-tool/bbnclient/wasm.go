@@ -0,0 +1,158 @@
+package bbnclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// NewMsgExecuteContract wraps a JSON execute message into a
+// MsgExecuteContract sent by the client's signing key
+func (c *Client) NewMsgExecuteContract(contractAddr string, msg []byte) (*wasmtypes.MsgExecuteContract, error) {
+	return newMsgExecuteContract(c.Address(), contractAddr, msg)
+}
+
+func newMsgExecuteContract(sender, contractAddr string, msg []byte) (*wasmtypes.MsgExecuteContract, error) {
+	if !json.Valid(msg) {
+		return nil, fmt.Errorf("execute message is not valid JSON: %s", msg)
+	}
+	return &wasmtypes.MsgExecuteContract{
+		Sender:   sender,
+		Contract: contractAddr,
+		Msg:      wasmtypes.RawContractMessage(msg),
+		Funds:    sdk.NewCoins(),
+	}, nil
+}
+
+// ExecuteContract signs and broadcasts a single MsgExecuteContract carrying
+// the given JSON execute message and waits for its inclusion. It is sent by
+// the next idle account of Key and FeePayers, so that concurrent executions
+// are spread over them.
+func (c *Client) ExecuteContract(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error) {
+	return c.ExecuteContractBatch(ctx, contractAddr, [][]byte{msg})
+}
+
+// ExecuteContractFromKey signs and broadcasts a single MsgExecuteContract
+// sent by Key, as messages restricted to the contract admin must be, and
+// waits for its inclusion
+func (c *Client) ExecuteContractFromKey(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error) {
+	execMsg, err := c.NewMsgExecuteContract(contractAddr, msg)
+	if err != nil {
+		return nil, err
+	}
+	return c.BroadcastAndWait(ctx, execMsg)
+}
+
+// ExecuteContractBatch signs and broadcasts a single transaction carrying
+// one MsgExecuteContract per JSON execute message and waits for its
+// inclusion. The messages are executed atomically: when one fails, the
+// whole transaction is reverted and FailedMsgIndex reports which one. Like
+// ExecuteContract, it is sent by the next idle account.
+func (c *Client) ExecuteContractBatch(ctx context.Context, contractAddr string, msgs [][]byte) (*sdk.TxResponse, error) {
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("no execute messages to send")
+	}
+	res, err := c.sendFromPool(ctx, func(sender string) ([]sdk.Msg, error) {
+		execMsgs := make([]sdk.Msg, len(msgs))
+		for i, msg := range msgs {
+			execMsg, err := newMsgExecuteContract(sender, contractAddr, msg)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			execMsgs[i] = execMsg
+		}
+		return execMsgs, nil
+	})
+	if err != nil {
+		return res, err
+	}
+	return c.WaitForTx(ctx, res.TxHash)
+}
+
+// QuerySmart runs a smart query against a contract and returns the raw JSON
+// response data
+func (c *Client) QuerySmart(ctx context.Context, contractAddr string, query []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	if !json.Valid(query) {
+		return nil, fmt.Errorf("query message is not valid JSON: %s", query)
+	}
+
+	queryClient := wasmtypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.SmartContractState(ctx, &wasmtypes.QuerySmartContractStateRequest{
+		Address:   contractAddr,
+		QueryData: wasmtypes.RawContractMessage(query),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contract %s: %w", contractAddr, err)
+	}
+
+	return res.Data, nil
+}
+
+// StoreCode uploads a contract's WASM byte code and returns the code id
+// assigned to it
+func (c *Client) StoreCode(ctx context.Context, wasmByteCode []byte) (uint64, *sdk.TxResponse, error) {
+	res, err := c.BroadcastAndWait(ctx, &wasmtypes.MsgStoreCode{
+		Sender:       c.Address(),
+		WASMByteCode: wasmByteCode,
+	})
+	if err != nil {
+		return 0, res, err
+	}
+
+	codeIDStr, ok := EventAttribute(res, wasmtypes.EventTypeStoreCode, wasmtypes.AttributeKeyCodeID)
+	if !ok {
+		return 0, res, fmt.Errorf("tx %s has no %s event", res.TxHash, wasmtypes.EventTypeStoreCode)
+	}
+	codeID, err := strconv.ParseUint(codeIDStr, 10, 64)
+	if err != nil {
+		return 0, res, fmt.Errorf("invalid code id %q in tx %s: %w", codeIDStr, res.TxHash, err)
+	}
+	return codeID, res, nil
+}
+
+// InstantiateContract instantiates the code codeID with the given JSON
+// instantiate message and returns the address of the new contract
+func (c *Client) InstantiateContract(ctx context.Context, codeID uint64, admin, label string, msg []byte) (string, *sdk.TxResponse, error) {
+	if !json.Valid(msg) {
+		return "", nil, fmt.Errorf("instantiate message is not valid JSON: %s", msg)
+	}
+	res, err := c.BroadcastAndWait(ctx, &wasmtypes.MsgInstantiateContract{
+		Sender: c.Address(),
+		Admin:  admin,
+		CodeID: codeID,
+		Label:  label,
+		Msg:    wasmtypes.RawContractMessage(msg),
+		Funds:  sdk.NewCoins(),
+	})
+	if err != nil {
+		return "", res, err
+	}
+
+	contractAddr, ok := EventAttribute(res, wasmtypes.EventTypeInstantiate, wasmtypes.AttributeKeyContractAddr)
+	if !ok {
+		return "", res, fmt.Errorf("tx %s has no %s event", res.TxHash, wasmtypes.EventTypeInstantiate)
+	}
+	return contractAddr, res, nil
+}
+
+// QueryContractsByCode returns the addresses of the contracts instantiated
+// from the code codeID
+func (c *Client) QueryContractsByCode(ctx context.Context, codeID uint64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	queryClient := wasmtypes.NewQueryClient(c.clientCtx)
+	res, err := queryClient.ContractsByCode(ctx, &wasmtypes.QueryContractsByCodeRequest{CodeId: codeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contracts of code %d: %w", codeID, err)
+	}
+
+	return res.Contracts, nil
+}
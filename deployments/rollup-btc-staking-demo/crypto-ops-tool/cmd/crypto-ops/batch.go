@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/l2client"
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// defaultBatchSize matches BatchSubmissionSize of consumer-fpd.conf
+const defaultBatchSize = 1000
+
+// BatchBlock is a block listed in the --blocks file of
+// submit-finality-sig-batch
+type BatchBlock struct {
+	Height       uint64 `json:"height"`
+	BlockHashHex string `json:"block_hash_hex"`
+}
+
+// BatchSignatureResult is the outcome of one finality signature of a batch
+type BatchSignatureResult struct {
+	Height       uint64 `json:"height"`
+	BlockHashHex string `json:"block_hash_hex,omitempty"`
+	// Status is "submitted" or "failed"
+	Status   string `json:"status"`
+	TxHash   string `json:"txhash,omitempty"`
+	TxHeight int64  `json:"tx_height,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchSubmission is the output of submit-finality-sig-batch
+type BatchSubmission struct {
+	Submitted    int                     `json:"submitted"`
+	Failed       int                     `json:"failed"`
+	Transactions []*bbnclient.TxResult   `json:"transactions"`
+	Results      []*BatchSignatureResult `json:"results"`
+}
+
+// batchBlock is a block to sign; a nil hash selects a mock block
+type batchBlock struct {
+	height uint64
+	hash   []byte
+}
+
+// readBatchBlocks reads a JSON array of BatchBlock from r
+func readBatchBlocks(r io.Reader) ([]batchBlock, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocks: %v", err)
+	}
+	var listed []BatchBlock
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse blocks: %v", err)
+	}
+
+	blocks := make([]batchBlock, len(listed))
+	seen := make(map[uint64]bool, len(listed))
+	for i, b := range listed {
+		if seen[b.Height] {
+			return nil, fmt.Errorf("height %d is listed more than once", b.Height)
+		}
+		seen[b.Height] = true
+		hash, err := parseBlockHash(b.BlockHashHex)
+		if err != nil {
+			return nil, fmt.Errorf("height %d: %v", b.Height, err)
+		}
+		blocks[i] = batchBlock{height: b.Height, hash: hash}
+	}
+	return blocks, nil
+}
+
+// rangeBatchBlocks returns the blocks from fromHeight to toHeight, fetched
+// from the L2 when l2RPCAddr is set and mock blocks otherwise
+func rangeBatchBlocks(bf *blockFlags, fromHeight, toHeight uint64) ([]batchBlock, error) {
+	if fromHeight == 0 || toHeight < fromHeight {
+		return nil, fmt.Errorf("invalid height range [%d, %d]", fromHeight, toHeight)
+	}
+	if bf.BlockHashHex != "" {
+		return nil, fmt.Errorf("--block-hash cannot be used with a height range, list the blocks with --blocks instead")
+	}
+
+	blocks := make([]batchBlock, 0, toHeight-fromHeight+1)
+	if bf.L2RPCAddr == "" {
+		for height := fromHeight; height <= toHeight; height++ {
+			blocks = append(blocks, batchBlock{height: height})
+		}
+		return blocks, nil
+	}
+
+	l2Client, err := l2client.New(bf.L2RPCAddr, bf.L2Timeout)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "  → Fetching L2 blocks %d to %d from %s...\n", fromHeight, toHeight, bf.L2RPCAddr)
+	for height := fromHeight; height <= toHeight; height++ {
+		block, err := l2Client.BlockByNumber(context.Background(), height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch L2 block %d: %v", height, err)
+		}
+		blocks = append(blocks, batchBlock{height: height, hash: block.Hash})
+	}
+	return blocks, nil
+}
+
+// signFinalityBatch signs every block and records the signatures in the
+// store. It returns the messages to submit and a result per block; blocks
+// that cannot be signed are reported as failed and get no message.
+func signFinalityBatch(st *store.Store, allowEquivocation bool, r *mathrand.Rand, randCommits []*RandCommit, s signer.Signer, blocks []batchBlock) ([]*opfinality.SubmitFinalitySignature, []*BatchSignatureResult) {
+	bip340PK := s.PublicKey()
+
+	fmt.Fprintf(os.Stderr, "  → Signing %d blocks...\n", len(blocks))
+	var msgs []*opfinality.SubmitFinalitySignature
+	results := make([]*BatchSignatureResult, 0, len(blocks))
+	for _, block := range blocks {
+		result := &BatchSignatureResult{Height: block.height}
+		results = append(results, result)
+
+		msg, err := func() (*opfinality.SubmitFinalitySignature, error) {
+			blockHash := block.hash
+			if blockHash == nil {
+				var err error
+				blockHash, err = mockBlockHash(st, r, bip340PK, block.height, allowEquivocation)
+				if err != nil {
+					return nil, err
+				}
+			}
+			result.BlockHashHex = hex.EncodeToString(blockHash)
+
+			// Load stored commitments on demand, rebuilding each only once
+			if _, _, err := findRandCommit(randCommits, block.height); err != nil && st != nil {
+				randCommit, err := loadRandCommit(st, s, block.height)
+				if err != nil {
+					return nil, err
+				}
+				randCommits = append(randCommits, randCommit)
+			}
+			return signFinalityMessage(st, allowEquivocation, randCommits, s, block.height, blockHash)
+		}()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ⚠️  Not signing height %d: %v\n", block.height, err)
+			result.Status = "failed"
+			result.Error = err.Error()
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, results
+}
+
+// signFinalityMessage signs the block at blockHeight with hash blockHash
+// after checking the slashing protection of the store, records the
+// signature and returns the contract message carrying it
+func signFinalityMessage(st *store.Store, allowEquivocation bool, randCommits []*RandCommit, s signer.Signer, blockHeight uint64, blockHash []byte) (*opfinality.SubmitFinalitySignature, error) {
+	bip340PK := s.PublicKey()
+
+	if err := checkSlashingProtection(st, bip340PK, blockHeight, blockHash, allowEquivocation); err != nil {
+		return nil, err
+	}
+
+	randCommit, randIndex, err := findRandCommit(randCommits, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	msgToSign := append(sdk.Uint64ToBigEndian(blockHeight), blockHash...)
+	eotsSig, err := randCommit.signEOTS(s, randIndex, msgToSign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate EOTS signature: %v", err)
+	}
+
+	if st != nil {
+		if err := recordSignature(st, bip340PK, blockHeight, blockHash, eotsSig.MustMarshal(), allowEquivocation); err != nil {
+			return nil, err
+		}
+	}
+
+	return &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: bip340PK.MarshalHex(),
+		Height:      blockHeight,
+		PubRand:     randCommit.PRList[randIndex].MustMarshal(),
+		Proof:       opfinality.NewProof(randCommit.ProofList[randIndex]),
+		BlockHash:   blockHash,
+		Signature:   eotsSig.MustMarshal(),
+	}, nil
+}
+
+// submitFinalitySignatureBatch submits the signed messages in transactions
+// of at most batchSize messages and fills in the result of each one.
+// Transactions are atomic, so when a message makes one fail it is reported
+// as failed and the transaction is sent again without it.
+func submitFinalitySignatureBatch(finalityContract *opfinality.Client, msgs []*opfinality.SubmitFinalitySignature, results []*BatchSignatureResult, batchSize int) *BatchSubmission {
+	resultByHeight := make(map[uint64]*BatchSignatureResult, len(results))
+	for _, result := range results {
+		resultByHeight[result.Height] = result
+	}
+	fail := func(msg *opfinality.SubmitFinalitySignature, err error) {
+		result := resultByHeight[msg.Height]
+		result.Status = "failed"
+		result.Error = err.Error()
+	}
+
+	submission := &BatchSubmission{
+		Transactions: []*bbnclient.TxResult{},
+		Results:      results,
+	}
+	for start := 0; start < len(msgs); start += batchSize {
+		batch := msgs[start:min(start+batchSize, len(msgs))]
+
+		for len(batch) > 0 {
+			fmt.Fprintf(os.Stderr, "  → Submitting %d finality signatures (heights %d to %d) in one tx...\n",
+				len(batch), batch[0].Height, batch[len(batch)-1].Height)
+
+			res, err := finalityContract.SubmitFinalitySignatures(context.Background(), batch)
+			if res != nil && res.Height > 0 {
+				submission.Transactions = append(submission.Transactions, bbnclient.NewTxResult(res))
+			}
+			if err == nil {
+				printTxResult(res)
+				for _, msg := range batch {
+					result := resultByHeight[msg.Height]
+					result.Status = "submitted"
+					result.TxHash = res.TxHash
+					result.TxHeight = res.Height
+				}
+				break
+			}
+
+			index, ok := bbnclient.FailedMsgIndex(err)
+			if !ok || index >= len(batch) {
+				fmt.Fprintf(os.Stderr, "  ⚠️  Batch failed: %v\n", err)
+				for _, msg := range batch {
+					fail(msg, err)
+				}
+				break
+			}
+
+			fmt.Fprintf(os.Stderr, "  ⚠️  Signature for height %d was rejected, resubmitting the others: %v\n", batch[index].Height, err)
+			fail(batch[index], err)
+			batch = append(batch[:index:index], batch[index+1:]...)
+		}
+	}
+
+	for _, result := range results {
+		if result.Status == "submitted" {
+			submission.Submitted++
+		} else {
+			submission.Failed++
+		}
+	}
+	return submission
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadBatchBlocks(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	blocks, err := readBatchBlocks(strings.NewReader(fmt.Sprintf(
+		`[{"height": 1, "block_hash_hex": "%s"}, {"height": 2, "block_hash_hex": "0x%s"}]`, hash, hash)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[1].height != 2 || !bytes.Equal(blocks[1].hash, bytes.Repeat([]byte{0xab}, 32)) {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+
+	for name, hashHex := range map[string]string{
+		"empty":    "",
+		"31 bytes": hash[:62],
+		"33 bytes": hash + "ab",
+		"not hex":  "zz",
+	} {
+		_, err := readBatchBlocks(strings.NewReader(fmt.Sprintf(`[{"height": 1, "block_hash_hex": %q}]`, hashHex)))
+		if err == nil {
+			t.Errorf("%s block hash accepted", name)
+		}
+	}
+
+	_, err = readBatchBlocks(strings.NewReader(fmt.Sprintf(
+		`[{"height": 1, "block_hash_hex": "%s"}, {"height": 1, "block_hash_hex": "%s"}]`, hash, hash)))
+	if err == nil {
+		t.Error("height listed twice accepted")
+	}
+}
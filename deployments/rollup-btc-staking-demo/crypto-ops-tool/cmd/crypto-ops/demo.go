@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/demo"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/stakerclient"
+)
+
+// demoKeys creates the FP keys of the demo and of scenarios in the keystore, at random or
+// derived from a mnemonic at the index of the key
+type demoKeys struct {
+	keys     fpKeys
+	mf       *mnemonicFlags
+	mnemonic string
+}
+
+// NewKey implements demo.Keys
+func (k *demoKeys) NewKey(_ context.Context, name string, index uint32) (signer.Signer, error) {
+	var fpSk *btcec.PrivateKey
+	if k.mnemonic != "" {
+		var err error
+		fpSk, _, err = deriveKey(k.mf, k.mnemonic, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key %d: %v", index, err)
+		}
+	}
+	if _, err := addKey(k.keys, name, fpSk); err != nil {
+		return nil, err
+	}
+	return signer.NewKeystore(k.keys, name)
+}
+
+// Key implements demo.Keys
+func (k *demoKeys) Key(_ context.Context, name string) (signer.Signer, error) {
+	return signer.NewKeystore(k.keys, name)
+}
+
+// stepTimeouts is the repeatable --step-timeout <step>=<duration> flag
+type stepTimeouts map[demo.Step]time.Duration
+
+func (t stepTimeouts) String() string {
+	var parts []string
+	for _, step := range demo.Steps {
+		if timeout, ok := t[step]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%v", step, timeout))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t stepTimeouts) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		step, timeoutStr, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("expected <step>=<duration>, got %q", part)
+		}
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return fmt.Errorf("invalid timeout of step %s: %v", step, err)
+		}
+		t[demo.Step(step)] = timeout
+	}
+	return nil
+}
+
+// runDemo implements `crypto-ops demo`: the flow of
+// rollup-btc-staking-demo.sh against a running deployment, ending with a
+// JSON summary of the run on stdout
+func runDemo(args []string) {
+	fs := flag.NewFlagSet("demo", flag.ExitOnError)
+	chainCfg := addChainFlags(fs)
+	home := addHomeFlag(fs)
+	kf := &keyFlags{}
+	addKeyStoreFlags(fs, kf)
+	mf := addMnemonicFlags(fs)
+	cfg := demo.DefaultConfig()
+	fs.StringVar(&cfg.ContractWasmPath, "contract-wasm", cfg.ContractWasmPath, "op-finality-gadget WASM byte code to deploy")
+	fs.StringVar(&cfg.ContractLabel, "contract-label", cfg.ContractLabel, "label of the contract instance")
+	fs.StringVar(&cfg.ConsumerID, "consumer-id", cfg.ConsumerID, "id of the registered consumer, mixed into the derived randomness")
+	fs.StringVar(&cfg.ConsumerName, "consumer-name", cfg.ConsumerName, "name of the registered consumer")
+	fs.StringVar(&cfg.ConsumerDescription, "consumer-description", cfg.ConsumerDescription, "description of the registered consumer")
+	maxMultiStakedFps := fs.Uint("max-multi-staked-fps", uint(cfg.MaxMultiStakedFps), "maximum number of consumer FPs a delegation may stake to")
+	fs.StringVar(&cfg.BabylonFpKey, "bbn-fp-key", cfg.BabylonFpKey, "keystore name of the new Babylon FP key (--index 0 of a mnemonic)")
+	fs.StringVar(&cfg.ConsumerFpKey, "consumer-fp-key", cfg.ConsumerFpKey, "keystore name of the new consumer FP key (--index 1 of a mnemonic)")
+	fs.StringVar(&cfg.CommissionRate, "commission-rate", cfg.CommissionRate, "commission rate of the FPs")
+	fs.StringVar(&cfg.CommissionMaxRate, "commission-max-rate", cfg.CommissionMaxRate, "maximum commission rate of the FPs")
+	fs.StringVar(&cfg.CommissionMaxChangeRate, "commission-max-change-rate", cfg.CommissionMaxChangeRate, "maximum daily commission change of the FPs")
+	fs.Int64Var(&cfg.StakingAmount, "staking-amount", cfg.StakingAmount, "delegated amount in satoshis")
+	fs.Int64Var(&cfg.StakingTimeBlocks, "staking-time", cfg.StakingTimeBlocks, "staking time lock in BTC blocks")
+	fs.DurationVar(&cfg.ActivationPollInterval, "activation-poll-interval", cfg.ActivationPollInterval, "interval between each check of the active delegations")
+	fs.BoolVar(&cfg.RequireActivation, "require-activation", cfg.RequireActivation, "fail when the delegation is not active in time instead of proceeding with a warning")
+	fs.Uint64Var(&cfg.StartHeight, "start-height", cfg.StartHeight, "first height of the committed randomness and of the signed blocks")
+	fs.Uint64Var(&cfg.NumPubRand, "num-pub-rand", cfg.NumPubRand, "number of committed public randomness values")
+	fs.Uint64Var(&cfg.NumFinalitySigs, "num-finality-sigs", cfg.NumFinalitySigs, "number of blocks signed from --start-height")
+	fs.IntVar(&cfg.VerifyRetries, "verify-retries", cfg.VerifyRetries, "block voter queries made to find each submitted signature")
+	fs.DurationVar(&cfg.VerifyRetryInterval, "verify-retry-interval", cfg.VerifyRetryInterval, "interval between those queries")
+	fs.Var(stepTimeouts(cfg.StepTimeouts), "step-timeout", "timeout of a step as <step>=<duration>, repeatable (steps: "+strings.Join(stepNames(), ", ")+")")
+	fs.DurationVar(&cfg.DefaultStepTimeout, "default-step-timeout", cfg.DefaultStepTimeout, "timeout of the steps without a --step-timeout")
+	stakerAddr := fs.String("staker-rpc", "http://localhost:15912", "JSON-RPC endpoint of the BTC staker daemon")
+	stakerTimeout := fs.Duration("staker-timeout", time.Minute, "timeout of each request to the BTC staker daemon")
+	envFile := fs.String("env-file", "", "file receiving the settings follow-up scenarios source, e.g. equivocation-scenario.sh (default: <home>/demo.env)")
+	fs.StringVar(&cfg.StateFile, "state-file", "", "file the state of the run is saved to after each step (default: <home>/demo-state.json)")
+	resume := fs.Bool("resume", false, "resume from --state-file, skipping the steps that completed and still hold on chain")
+	parseArgs(fs, args)
+	if *home == "" {
+		log.Fatalf("--home is required for demo")
+	}
+	cfg.MaxMultiStakedFps = uint32(*maxMultiStakedFps)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid demo settings: %v", err)
+	}
+	if *envFile == "" {
+		*envFile = filepath.Join(*home, "demo.env")
+	}
+	if cfg.StateFile == "" {
+		cfg.StateFile = filepath.Join(*home, "demo-state.json")
+	}
+	var prev *demo.Summary
+	if *resume {
+		var err error
+		prev, err = demo.LoadState(cfg.StateFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			fmt.Fprintf(os.Stderr, "  ⚠️  No state in %s, starting from the first step\n", cfg.StateFile)
+		case err != nil:
+			log.Fatalf("%v", err)
+		}
+	}
+
+	// Storing and instantiating the contract costs far more gas than a
+	// contract execution, so simulate and price the gas unless it was given
+	setFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if !setFlags["gas"] {
+		chainCfg.Gas = 0
+	}
+	if !setFlags["fees"] {
+		chainCfg.Fees = ""
+	}
+
+	keys, err := openFpKeys(kf, *home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	demoKeys := &demoKeys{keys: keys, mf: mf}
+	if mf.enabled() {
+		demoKeys.mnemonic, err = mf.read()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	st, err := openStore(*home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer st.Close()
+
+	bbnClient, err := bbnclient.New(*chainCfg)
+	if err != nil {
+		log.Fatalf("Failed to create Babylon client: %v", err)
+	}
+	defer bbnClient.Close()
+
+	staker, err := stakerclient.New(*stakerAddr, *stakerTimeout)
+	if err != nil {
+		log.Fatalf("Failed to create staker client: %v", err)
+	}
+
+	d, err := demo.New(cfg, bbnClient, staker, demoKeys, st)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	summary, runErr := d.Resume(ctx, prev)
+	if runErr == nil {
+		if err := writeDemoEnv(*envFile, chainCfg.ChainID, kf, summary); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "  ✅ Wrote %s\n", *envFile)
+	}
+	printJSON(summary)
+	if runErr != nil {
+		log.Fatalf("❌ Demo failed: %v", runErr)
+	}
+}
+
+// stepNames returns the names of the demo steps
+func stepNames() []string {
+	names := make([]string, len(demo.Steps))
+	for i, step := range demo.Steps {
+		names[i] = string(step)
+	}
+	return names
+}
+
+// writeDemoEnv writes the settings of the run sourced by follow-up scenarios
+// such as equivocation-scenario.sh, like the script does
+func writeDemoEnv(path, chainID string, kf *keyFlags, summary *demo.Summary) error {
+	env := fmt.Sprintf("BBN_CHAIN_ID=%s\nCONSUMER_ID=%s\nfinalityContractAddr=%s\nCRYPTO_OPS_PASSPHRASE_FILE=%s\nCONSUMER_FP_KEY=%s\nconsumer_btc_pk=%s\n",
+		chainID,
+		summary.Consumer.ConsumerID,
+		summary.Contract.ContractAddr,
+		kf.PassphraseFile,
+		summary.Keys.ConsumerFp.Name,
+		summary.Keys.ConsumerFp.BtcPkHex,
+	)
+	if err := os.WriteFile(path, []byte(env), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
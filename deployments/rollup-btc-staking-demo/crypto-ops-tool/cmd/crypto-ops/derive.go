@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/hdkey"
+)
+
+// DerivedKey is an FP key derived from a mnemonic
+type DerivedKey struct {
+	Index      uint32 `json:"index"`
+	Path       string `json:"path"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key,omitempty"`
+	// Name is set when the key was stored in the keystore or keyring
+	Name string `json:"name,omitempty"`
+}
+
+// mnemonicFlags select the mnemonic and path FP keys are derived from
+type mnemonicFlags struct {
+	Mnemonic     string
+	MnemonicFile string
+	Passphrase   string
+	Path         string
+}
+
+// addMnemonicFlags registers the HD derivation flags on fs
+func addMnemonicFlags(fs *flag.FlagSet) *mnemonicFlags {
+	mf := &mnemonicFlags{}
+	fs.StringVar(&mf.Mnemonic, "mnemonic", "", "BIP39 mnemonic to derive the FP key from (prefer --mnemonic-file, argv is visible to other users)")
+	fs.StringVar(&mf.MnemonicFile, "mnemonic-file", "", "file (or - for stdin) holding the BIP39 mnemonic")
+	fs.StringVar(&mf.Passphrase, "mnemonic-passphrase", "", "optional BIP39 passphrase of the mnemonic")
+	fs.StringVar(&mf.Path, "path", hdkey.DefaultPath, "BIP32 derivation path, where i is replaced by the key index")
+	return mf
+}
+
+// enabled reports whether a mnemonic was given
+func (mf *mnemonicFlags) enabled() bool {
+	return mf.Mnemonic != "" || mf.MnemonicFile != ""
+}
+
+// read returns the mnemonic given by --mnemonic or --mnemonic-file
+func (mf *mnemonicFlags) read() (string, error) {
+	if mf.Mnemonic != "" && mf.MnemonicFile != "" {
+		return "", fmt.Errorf("--mnemonic and --mnemonic-file are mutually exclusive")
+	}
+	if mf.Mnemonic != "" {
+		return mf.Mnemonic, nil
+	}
+	var data []byte
+	var err error
+	if mf.MnemonicFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(mf.MnemonicFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read mnemonic: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// deriveKey derives the FP key of the given index along the --path template
+func deriveKey(mf *mnemonicFlags, mnemonic string, index uint32) (*btcec.PrivateKey, *DerivedKey, error) {
+	path := hdkey.ExpandPath(mf.Path, index)
+	fpSk, err := hdkey.DeriveKey(mnemonic, mf.Passphrase, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fpSk, &DerivedKey{
+		Index:      index,
+		Path:       path,
+		PublicKey:  bbn.NewBIP340PubKeyFromBTCPK(fpSk.PubKey()).MarshalHex(),
+		PrivateKey: hex.EncodeToString(fpSk.Serialize()),
+	}, nil
+}
+
+// runDerive implements `crypto-ops derive`: the deterministic derivation of
+// --count FP keys from a mnemonic
+func runDerive(args []string) {
+	fs := flag.NewFlagSet("derive", flag.ExitOnError)
+	mf := addMnemonicFlags(fs)
+	home := addHomeFlag(fs)
+	kf := &keyFlags{}
+	addKeyStoreFlags(fs, kf)
+	count := fs.Uint("count", 1, "number of keys to derive")
+	start := fs.Uint("start-index", 0, "index of the first key")
+	namePrefix := fs.String("key-prefix", "", "store the keys as <prefix>-<index> in the keystore under --home (or the --fp-keyring-dir keyring) and print public keys only")
+	printPrivateKey := fs.Bool("insecure-print-private-key", false, "print the unencrypted private keys instead of storing them with --key-prefix")
+	yes := fs.Bool("yes", false, "print the private keys without the interactive confirmation")
+	parseArgs(fs, args)
+	if !mf.enabled() {
+		log.Fatalf("--mnemonic or --mnemonic-file is required for derive")
+	}
+	if *count == 0 {
+		log.Fatalf("--count must be positive")
+	}
+	if *count > 1 && !strings.Contains(mf.Path, hdkey.IndexPlaceholder) {
+		log.Fatalf("--path %s has no %q element, every key would be the same", mf.Path, hdkey.IndexPlaceholder)
+	}
+
+	if *printPrivateKey {
+		if *namePrefix != "" {
+			log.Fatalf("--insecure-print-private-key cannot be used with --key-prefix, use keys export")
+		}
+		if !*yes {
+			if err := confirmPrivateKeyPrint("the derived keys", "print"); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+	}
+
+	mnemonic, err := mf.read()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var keys fpKeys
+	if *namePrefix != "" {
+		keys, err = openFpKeys(kf, *home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	derived := make([]*DerivedKey, 0, *count)
+	for index := uint32(*start); index < uint32(*start+*count); index++ {
+		fpSk, key, err := deriveKey(mf, mnemonic, index)
+		if err != nil {
+			log.Fatalf("Failed to derive key %d: %v", index, err)
+		}
+		if !*printPrivateKey {
+			key.PrivateKey = ""
+		}
+		if keys != nil {
+			key.Name = fmt.Sprintf("%s-%d", *namePrefix, index)
+			if _, err := addKey(keys, key.Name, fpSk); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+		derived = append(derived, key)
+	}
+	printJSON(derived)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	"github.com/babylonlabs-io/babylon/v4/testutil/datagen"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// ExtractedKey is the output of extract-sk
+type ExtractedKey struct {
+	FpPubkeyHex   string `json:"fp_pubkey_hex"`
+	Height        uint64 `json:"height"`
+	PrivateKeyHex string `json:"private_key_hex"`
+	WIF           string `json:"wif"`
+	Network       string `json:"network"`
+}
+
+// btcNetParams returns the parameters of a BTC network by name
+func btcNetParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	case "simnet":
+		return &chaincfg.SimNetParams, nil
+	default:
+		return nil, fmt.Errorf("unknown BTC network %q (mainnet, testnet, signet, regtest, simnet)", network)
+	}
+}
+
+// extractSecretKey recovers the BTC secret key of an FP from two finality
+// signatures at the same height and with the same public randomness over
+// different blocks. BIP340 keys are x-only, so the key is returned in its
+// even-Y form, which is the one the FP effectively signs with.
+func extractSecretKey(sig1, sig2 *opfinality.SubmitFinalitySignature) (*btcec.PrivateKey, error) {
+	if sig1.FpPubkeyHex != sig2.FpPubkeyHex {
+		return nil, fmt.Errorf("signatures are from different FPs: %s and %s", sig1.FpPubkeyHex, sig2.FpPubkeyHex)
+	}
+	if sig1.Height != sig2.Height {
+		return nil, fmt.Errorf("signatures are for different heights: %d and %d", sig1.Height, sig2.Height)
+	}
+	if !bytes.Equal(sig1.PubRand, sig2.PubRand) {
+		return nil, fmt.Errorf("signatures use different public randomness: %x and %x", sig1.PubRand, sig2.PubRand)
+	}
+	if bytes.Equal(sig1.BlockHash, sig2.BlockHash) {
+		return nil, fmt.Errorf("signatures are over the same block %x, this is not an equivocation", sig1.BlockHash)
+	}
+
+	fpPk, err := bbn.NewBIP340PubKeyFromHex(sig1.FpPubkeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FP public key: %v", err)
+	}
+	btcPk, err := fpPk.ToBTCPK()
+	if err != nil {
+		return nil, fmt.Errorf("invalid FP public key: %v", err)
+	}
+	pubRand, err := bbn.NewSchnorrPubRand(sig1.PubRand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public randomness: %v", err)
+	}
+
+	// Both signatures must be valid, otherwise the extracted key is garbage
+	msgs := make([][]byte, 2)
+	scalars := make([]*eots.Signature, 2)
+	for i, sig := range []*opfinality.SubmitFinalitySignature{sig1, sig2} {
+		eotsSig, err := bbn.NewSchnorrEOTSSig(sig.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature %d: %v", i+1, err)
+		}
+		msgs[i] = append(sdk.Uint64ToBigEndian(sig.Height), sig.BlockHash...)
+		scalars[i] = eotsSig.ToModNScalar()
+		if err := eots.Verify(btcPk, pubRand.ToFieldVal(), msgs[i], scalars[i]); err != nil {
+			return nil, fmt.Errorf("signature %d over block %x does not verify: %v", i+1, sig.BlockHash, err)
+		}
+	}
+
+	sk, err := eots.Extract(btcPk, pubRand.ToFieldVal(), msgs[0], scalars[0], msgs[1], scalars[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract secret key: %v", err)
+	}
+
+	// Normalise to the even-Y key and check it against the FP key
+	if sk.PubKey().SerializeCompressed()[0] != 0x02 {
+		negated := new(btcec.ModNScalar).NegateVal(&sk.Key)
+		sk = btcec.PrivKeyFromScalar(negated)
+	}
+	if !bytes.Equal(bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MustMarshal(), fpPk.MustMarshal()) {
+		return nil, fmt.Errorf("extracted key does not match FP public key %s", sig1.FpPubkeyHex)
+	}
+	return sk, nil
+}
+
+// newExtractedKey formats an extracted secret key for the given network
+func newExtractedKey(sk *btcec.PrivateKey, height uint64, network string) (*ExtractedKey, error) {
+	params, err := btcNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+	wif, err := btcutil.NewWIF(sk, params, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode WIF: %v", err)
+	}
+	return &ExtractedKey{
+		FpPubkeyHex:   bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MarshalHex(),
+		Height:        height,
+		PrivateKeyHex: hex.EncodeToString(sk.Serialize()),
+		WIF:           wif.String(),
+		Network:       network,
+	}, nil
+}
+
+// ScenarioVote is one of the two conflicting votes of equivocation-scenario
+type ScenarioVote struct {
+	BlockHashHex string              `json:"block_hash_hex"`
+	Tx           *bbnclient.TxResult `json:"tx,omitempty"`
+	// AlreadyVoted is set when the contract already had this vote, which is
+	// then not submitted again
+	AlreadyVoted bool   `json:"already_voted,omitempty"`
+	Error        string `json:"error,omitempty"`
+	// Recorded is whether the FP is among the block voters afterwards
+	Recorded bool `json:"recorded"`
+}
+
+// FpSlashingState is the state of an FP on Babylon
+type FpSlashingState struct {
+	SlashedBabylonHeight uint64 `json:"slashed_babylon_height"`
+	SlashedBtcHeight     uint32 `json:"slashed_btc_height"`
+	Jailed               bool   `json:"jailed"`
+}
+
+// EquivocationReport is the output of equivocation-scenario
+type EquivocationReport struct {
+	FpPubkeyHex string        `json:"fp_pubkey_hex"`
+	Height      uint64        `json:"height"`
+	Canonical   *ScenarioVote `json:"canonical"`
+	Fork        *ScenarioVote `json:"fork"`
+	// ExtractedKeyMatches is whether the key extracted locally from the two
+	// signatures is the FP key
+	ExtractedKeyMatches bool `json:"extracted_key_matches"`
+	// SlashingEvent is whether the fork tx emitted slashed_finality_provider,
+	// and EventKeyMatches whether the secret key it carries is the FP key
+	SlashingEvent   bool             `json:"slashing_event"`
+	EventKeyMatches bool             `json:"event_key_matches"`
+	Babylon         *FpSlashingState `json:"babylon,omitempty"`
+	BabylonError    string           `json:"babylon_error,omitempty"`
+	Slashed         bool             `json:"slashed"`
+	Jailed          bool             `json:"jailed"`
+}
+
+// runEquivocationScenario makes the FP vote for two different blocks at
+// blockHeight with the same randomness and reports how the contract and
+// Babylon react. The canonical block is the one already signed in the store,
+// if any. Babylon is polled every pollInterval for at most slashingTimeout
+// until the FP shows up as slashed or jailed.
+func runEquivocationScenario(bbnClient *bbnclient.Client, finalityContract *opfinality.Client, st *store.Store, r *mathrand.Rand, randCommits []*RandCommit, s signer.Signer, blockHeight uint64, slashingTimeout, pollInterval time.Duration) (*EquivocationReport, error) {
+	ctx := context.Background()
+	bip340PK := s.PublicKey()
+	report := &EquivocationReport{
+		FpPubkeyHex: bip340PK.MarshalHex(),
+		Height:      blockHeight,
+	}
+
+	// 1. Canonical vote, submitted unless the contract already has it
+	canonicalHash, err := mockBlockHash(st, r, bip340PK, blockHeight, false)
+	if err != nil {
+		return nil, err
+	}
+	canonicalMsg, err := signFinalityMessage(st, false, randCommits, s, blockHeight, canonicalHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign canonical block: %v", err)
+	}
+	report.Canonical = &ScenarioVote{BlockHashHex: hex.EncodeToString(canonicalHash)}
+	if hasVoted(finalityContract, blockHeight, canonicalHash, report.FpPubkeyHex) {
+		fmt.Fprintf(os.Stderr, "  → Canonical block %x at height %d was already voted\n", canonicalHash, blockHeight)
+		report.Canonical.AlreadyVoted = true
+	} else {
+		fmt.Fprintf(os.Stderr, "  → Submitting canonical vote for block %x at height %d...\n", canonicalHash, blockHeight)
+		submitScenarioVote(finalityContract, canonicalMsg, report.Canonical)
+		if report.Canonical.Error != "" {
+			return report, fmt.Errorf("canonical vote was rejected: %s", report.Canonical.Error)
+		}
+	}
+
+	// 2. Conflicting vote for a random fork block
+	forkHash := datagen.GenRandomByteArray(r, 32)
+	for bytes.Equal(forkHash, canonicalHash) {
+		forkHash = datagen.GenRandomByteArray(r, 32)
+	}
+	forkMsg, err := signFinalityMessage(st, true, randCommits, s, blockHeight, forkHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign fork block: %v", err)
+	}
+	report.Fork = &ScenarioVote{BlockHashHex: hex.EncodeToString(forkHash)}
+	fmt.Fprintf(os.Stderr, "  → Submitting conflicting vote for block %x at height %d...\n", forkHash, blockHeight)
+	res := submitScenarioVote(finalityContract, forkMsg, report.Fork)
+
+	// 3. The two signatures must reveal the FP key
+	fpSk, err := extractSecretKey(canonicalMsg, forkMsg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ⚠️  %v\n", err)
+	} else {
+		report.ExtractedKeyMatches = true
+		fmt.Fprintf(os.Stderr, "  ✅ Extracted FP secret key locally\n")
+	}
+
+	// 4. The contract slashes the FP in the fork tx
+	if res != nil {
+		report.SlashingEvent, report.EventKeyMatches = findSlashingEvent(res, fpSk)
+	}
+	if report.SlashingEvent {
+		fmt.Fprintf(os.Stderr, "  ✅ Contract emitted %s (secret key matches: %t)\n", opfinality.EventSlashedFinalityProvider, report.EventKeyMatches)
+	} else {
+		fmt.Fprintf(os.Stderr, "  ⚠️  Contract emitted no %s event\n", opfinality.EventSlashedFinalityProvider)
+	}
+
+	report.Canonical.Recorded = hasVoted(finalityContract, blockHeight, canonicalHash, report.FpPubkeyHex)
+	report.Fork.Recorded = hasVoted(finalityContract, blockHeight, forkHash, report.FpPubkeyHex)
+
+	// 5. Babylon processes the equivocation evidence sent by the contract
+	fmt.Fprintf(os.Stderr, "  → Waiting up to %v for Babylon to slash or jail the FP...\n", slashingTimeout)
+	deadline := time.Now().Add(slashingTimeout)
+	for {
+		fp, err := bbnClient.QueryFinalityProvider(ctx, report.FpPubkeyHex)
+		if err != nil {
+			report.BabylonError = err.Error()
+		} else {
+			report.BabylonError = ""
+			report.Babylon = &FpSlashingState{
+				SlashedBabylonHeight: fp.SlashedBabylonHeight,
+				SlashedBtcHeight:     fp.SlashedBtcHeight,
+				Jailed:               fp.Jailed,
+			}
+			report.Slashed = fp.SlashedBabylonHeight > 0 || fp.SlashedBtcHeight > 0
+			report.Jailed = fp.Jailed
+			if report.Slashed || report.Jailed {
+				fmt.Fprintf(os.Stderr, "  ✅ FP slashed at Babylon height %d (jailed: %t)\n", fp.SlashedBabylonHeight, fp.Jailed)
+				break
+			}
+		}
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			fmt.Fprintln(os.Stderr, "  ⚠️  FP was neither slashed nor jailed on Babylon")
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+
+	return report, nil
+}
+
+// submitScenarioVote submits msg and records the outcome in vote. The
+// response is returned even when the tx failed, nil if it was not included.
+func submitScenarioVote(finalityContract *opfinality.Client, msg *opfinality.SubmitFinalitySignature, vote *ScenarioVote) *sdk.TxResponse {
+	res, err := finalityContract.SubmitFinalitySignature(context.Background(), msg)
+	if res != nil {
+		vote.Tx = bbnclient.NewTxResult(res)
+		printTxResult(res)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  ⚠️  Vote for block %x rejected: %v\n", msg.BlockHash, err)
+		vote.Error = err.Error()
+		if res != nil && res.Code != 0 {
+			return nil
+		}
+	}
+	return res
+}
+
+// hasVoted reports whether fpPubkeyHex is among the voters of the block
+func hasVoted(finalityContract *opfinality.Client, blockHeight uint64, blockHash []byte, fpPubkeyHex string) bool {
+	voters, err := finalityContract.QueryBlockVoters(context.Background(), blockHeight, blockHash)
+	if err != nil {
+		return false
+	}
+	for _, voter := range voters {
+		if voter == fpPubkeyHex {
+			return true
+		}
+	}
+	return false
+}
+
+// findSlashingEvent looks for the slashing event in a tx response and checks
+// the secret key it carries against fpSk, when known
+func findSlashingEvent(res *sdk.TxResponse, fpSk *btcec.PrivateKey) (found, keyMatches bool) {
+	for _, event := range res.Events {
+		if !strings.HasSuffix(event.Type, opfinality.EventSlashedFinalityProvider) {
+			continue
+		}
+		found = true
+		for _, attr := range event.Attributes {
+			if attr.Key != opfinality.AttributeKeySecretKey || fpSk == nil {
+				continue
+			}
+			eventSk, err := hex.DecodeString(attr.Value)
+			if err != nil {
+				continue
+			}
+			// The event key may be either of the two keys of the x-only FP key
+			eventKey, _ := btcec.PrivKeyFromBytes(eventSk)
+			if bytes.Equal(bbn.NewBIP340PubKeyFromBTCPK(eventKey.PubKey()).MustMarshal(),
+				bbn.NewBIP340PubKeyFromBTCPK(fpSk.PubKey()).MustMarshal()) {
+				keyMatches = true
+			}
+		}
+	}
+	return found, keyMatches
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+)
+
+// oddYKey returns a key whose public key has an odd Y coordinate, which
+// BIP340 signs with negated
+func oddYKey(t *testing.T) *btcec.PrivateKey {
+	t.Helper()
+	for b := byte(1); b != 0; b++ {
+		sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{b}, 32))
+		if sk.PubKey().SerializeCompressed()[0] == 0x03 {
+			return sk
+		}
+	}
+	t.Fatal("no odd-Y key found")
+	return nil
+}
+
+// finalitySig signs blockHash at height with the randomness of randCommit
+func finalitySig(t *testing.T, s signer.Signer, randCommit *RandCommit, height uint64, blockHash []byte) *opfinality.SubmitFinalitySignature {
+	t.Helper()
+	pubRand, sig, proof, err := generateFinalitySignature([]*RandCommit{randCommit}, s, height, blockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: s.PublicKey().MarshalHex(),
+		Height:      height,
+		PubRand:     pubRand,
+		Proof:       opfinality.NewProof(proof),
+		BlockHash:   blockHash,
+		Signature:   sig,
+	}
+}
+
+func TestExtractSecretKey(t *testing.T) {
+	const height = 42
+	sk := oddYKey(t)
+	s := signer.NewLocal(sk)
+	randCommit, err := signerRandCommit(s, "consumer-id", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig1 := finalitySig(t, s, randCommit, height, bytes.Repeat([]byte{0x01}, 32))
+	sig2 := finalitySig(t, s, randCommit, height, bytes.Repeat([]byte{0x02}, 32))
+
+	got, err := extractSecretKey(sig1, sig2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key comes back in the even-Y form the FP signs with
+	even := btcec.PrivKeyFromScalar(new(btcec.ModNScalar).NegateVal(&sk.Key))
+	if !bytes.Equal(got.Serialize(), even.Serialize()) {
+		t.Fatalf("extracted key %x, want the even-Y key %x", got.Serialize(), even.Serialize())
+	}
+	if got.PubKey().SerializeCompressed()[0] != 0x02 {
+		t.Fatal("extracted key has an odd-Y public key")
+	}
+	extracted, err := newExtractedKey(got, height, "regtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracted.FpPubkeyHex != sig1.FpPubkeyHex {
+		t.Fatalf("extracted key is of FP %s, want %s", extracted.FpPubkeyHex, sig1.FpPubkeyHex)
+	}
+}
+
+func TestExtractSecretKeyRejects(t *testing.T) {
+	const height = 42
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	s := signer.NewLocal(sk)
+	randCommit, err := signerRandCommit(s, "consumer-id", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCommit, err := signerRandCommit(s, "other-consumer", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockA := bytes.Repeat([]byte{0x01}, 32)
+	blockB := bytes.Repeat([]byte{0x02}, 32)
+	sigA := finalitySig(t, s, randCommit, height, blockA)
+	sigB := finalitySig(t, s, randCommit, height, blockB)
+
+	tampered := *sigB
+	tampered.Signature = append([]byte(nil), sigB.Signature...)
+	tampered.Signature[31] ^= 0x01
+
+	for name, pair := range map[string][2]*opfinality.SubmitFinalitySignature{
+		"same block":           {sigA, finalitySig(t, s, randCommit, height, blockA)},
+		"different pub_rand":   {sigA, finalitySig(t, s, otherCommit, height, blockB)},
+		"different heights":    {sigA, finalitySig(t, s, randCommit, height+1, blockB)},
+		"tampered signature":   {sigA, &tampered},
+		"tampered first block": {{FpPubkeyHex: sigA.FpPubkeyHex, Height: height, PubRand: sigA.PubRand, BlockHash: bytes.Repeat([]byte{0x03}, 32), Signature: sigA.Signature}, sigB},
+	} {
+		if _, err := extractSecretKey(pair[0], pair[1]); err == nil {
+			t.Errorf("%s: key extracted", name)
+		}
+	}
+}
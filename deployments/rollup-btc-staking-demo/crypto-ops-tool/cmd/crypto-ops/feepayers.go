@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/bbnclient"
+)
+
+// feePayer is a key of the fund-fee-payers output
+type feePayer struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// fundFeePayersOutput is the output of fund-fee-payers
+type fundFeePayersOutput struct {
+	TxHash    string     `json:"tx_hash"`
+	FeePayers []feePayer `json:"fee_payers"`
+	// Flag is the --fee-payers flag using them
+	Flag string `json:"flag"`
+}
+
+// runFundFeePayers implements `crypto-ops fund-fee-payers`: creates the keys
+// <prefix>-1 to <prefix>-<count> in the keyring of --keyring-dir unless they
+// exist and funds them from --from, to pass to --fee-payers
+func runFundFeePayers(args []string) {
+	fs := flag.NewFlagSet("fund-fee-payers", flag.ExitOnError)
+	chainCfg := addChainFlags(fs)
+	count := fs.Int("count", 4, "number of fee payers")
+	prefix := fs.String("prefix", "fee-payer", "prefix of the fee payer key names")
+	amountStr := fs.String("amount", "100000000ubbn", "amount sent to each fee payer")
+	parseArgs(fs, args)
+	if *count <= 0 {
+		log.Fatalf("--count must be positive")
+	}
+	amount, err := sdk.ParseCoinsNormalized(*amountStr)
+	if err != nil {
+		log.Fatalf("Invalid --amount: %v", err)
+	}
+
+	// The fee payers are the keys being created, not ones to pay with
+	chainCfg.FeePayers = nil
+
+	bbnClient, err := bbnclient.New(*chainCfg)
+	if err != nil {
+		log.Fatalf("Failed to create Babylon client: %v", err)
+	}
+	defer bbnClient.Close()
+
+	names := make([]string, *count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%d", *prefix, i+1)
+	}
+	fmt.Fprintf(os.Stderr, "  → Funding %d fee payers with %s each from %s\n", *count, amount, chainCfg.Key)
+	addrs, res, err := bbnClient.FundFeePayers(context.Background(), names, amount)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	output := fundFeePayersOutput{TxHash: res.TxHash, Flag: "--fee-payers " + strings.Join(names, ",")}
+	for i, name := range names {
+		output.FeePayers = append(output.FeePayers, feePayer{Name: name, Address: addrs[i]})
+	}
+	printJSON(output)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"crypto-ops-tool/bbnclient"
+)
+
+// parseArgs parses fs from args and returns the positional arguments. Unlike
+// flag.FlagSet.Parse, flags may appear before, between or after positionals.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		// ExitOnError flag sets never return an error
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// addChainFlags registers the Babylon connection flags on fs and returns the
+// config they populate
+func addChainFlags(fs *flag.FlagSet) *bbnclient.Config {
+	cfg := bbnclient.DefaultConfig()
+	fs.StringVar(&cfg.ChainID, "chain-id", cfg.ChainID, "Babylon chain id")
+	fs.StringVar(&cfg.RPCAddr, "node", cfg.RPCAddr, "CometBFT RPC endpoint of the Babylon node")
+	fs.StringVar(&cfg.GRPCAddr, "grpc", cfg.GRPCAddr, "gRPC endpoint of the Babylon node (queries use --node when empty)")
+	fs.StringVar(&cfg.AccountPrefix, "account-prefix", cfg.AccountPrefix, "bech32 prefix of account addresses")
+	fs.StringVar(&cfg.KeyringDir, "keyring-dir", cfg.KeyringDir, "directory of the keyring holding the --from key")
+	fs.StringVar(&cfg.KeyringBackend, "keyring-backend", cfg.KeyringBackend, "keyring backend (test, file, os)")
+	fs.StringVar(&cfg.Key, "from", cfg.Key, "name of the key that signs and pays for transactions")
+	fs.Var((*keyNames)(&cfg.FeePayers), "fee-payers", "comma-separated funded keys of the keyring that take turns with --from paying for contract executions, repeatable")
+	fs.IntVar(&cfg.MaxSequenceRetries, "max-sequence-retries", cfg.MaxSequenceRetries, "times a tx rejected for its account sequence is signed again with the expected one")
+	fs.Uint64Var(&cfg.Gas, "gas", cfg.Gas, "gas limit per transaction (0 to simulate)")
+	fs.Float64Var(&cfg.GasAdjustment, "gas-adjustment", cfg.GasAdjustment, "factor applied to simulated gas")
+	fs.StringVar(&cfg.GasPrices, "gas-prices", cfg.GasPrices, "gas prices used when --fees is empty")
+	fs.StringVar(&cfg.Fees, "fees", cfg.Fees, "fixed fees per transaction")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "timeout of each query and broadcast")
+	fs.DurationVar(&cfg.TxWaitTimeout, "wait-timeout", cfg.TxWaitTimeout, "maximum time to wait for a tx to be included")
+	fs.DurationVar(&cfg.TxPollInterval, "poll-interval", cfg.TxPollInterval, "interval between tx queries while waiting for inclusion")
+	fs.BoolVar(&cfg.UseWebsocket, "websocket", cfg.UseWebsocket, "wait for inclusion through a websocket Tx event subscription instead of polling")
+	return &cfg
+}
+
+// keyNames is a comma-separated and repeatable list of key names
+type keyNames []string
+
+func (k *keyNames) String() string {
+	return strings.Join(*k, ",")
+}
+
+func (k *keyNames) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*k = append(*k, name)
+		}
+	}
+	return nil
+}
+
+// addHomeFlag registers the --home flag selecting the directory of the
+// randomness and signing store; the store is disabled when it is empty, and
+// signing commands then refuse to sign unless --allow-equivocation is set
+func addHomeFlag(fs *flag.FlagSet) *string {
+	return fs.String("home", "", "crypto-ops home directory holding the randomness and signing store (disabled when empty; signing then requires --allow-equivocation)")
+}
+
+// addAllowEquivocationFlag registers the --allow-equivocation testing flag
+// that disables the slashing protection of the store
+func addAllowEquivocationFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("allow-equivocation", false, "TESTING ONLY: sign a height already signed over a different block, or without --home, leaking the FP's secret key")
+}
+
+// blockFlags select the block a finality signature is produced for
+type blockFlags struct {
+	// BlockHashHex is the hash of the block to sign
+	BlockHashHex string
+	// L2RPCAddr is the JSON-RPC endpoint the block is fetched from
+	L2RPCAddr string
+	// L2Timeout bounds each L2 JSON-RPC request
+	L2Timeout time.Duration
+}
+
+// addBlockFlags registers the flags selecting the signed block. A random
+// mock block is signed when neither --block-hash nor --l2-rpc is set.
+func addBlockFlags(fs *flag.FlagSet) *blockFlags {
+	bf := &blockFlags{}
+	fs.StringVar(&bf.BlockHashHex, "block-hash", "", "hex hash of the 32-byte L2 block to sign")
+	fs.StringVar(&bf.L2RPCAddr, "l2-rpc", "", "OP-stack/Ethereum JSON-RPC endpoint to fetch the block to sign from, e.g. http://localhost:8545")
+	fs.DurationVar(&bf.L2Timeout, "l2-timeout", 10*time.Second, "timeout of each L2 JSON-RPC request")
+	return bf
+}
+
+// randFlags select how finality signing commands obtain the randomness of
+// the commitment covering the signed block
+type randFlags struct {
+	// Deterministic derives the randomness from the FP key and ConsumerID
+	Deterministic bool
+	// ConsumerID is the consumer/chain id mixed into derived randomness
+	ConsumerID string
+	// CommitStartHeight is the start height of the covering commitment
+	CommitStartHeight uint64
+	// CommitNumPubRand is the size of the covering commitment
+	CommitNumPubRand uint64
+}
+
+// addRandFlags registers the flags deriving the randomness of the signed
+// block's commitment instead of loading it from the store or stdin
+func addRandFlags(fs *flag.FlagSet) *randFlags {
+	rf := &randFlags{}
+	fs.BoolVar(&rf.Deterministic, "deterministic", false, "derive the randomness from the FP key and --consumer-id instead of reading it from stdin")
+	fs.StringVar(&rf.ConsumerID, "consumer-id", "", "consumer/chain id mixed into deterministic randomness")
+	fs.Uint64Var(&rf.CommitStartHeight, "commit-start-height", 1, "start height of the commitment covering the block (with --deterministic)")
+	fs.Uint64Var(&rf.CommitNumPubRand, "commit-num-pub-rand", 0, "number of randomness values in the commitment covering the block (with --deterministic)")
+	return rf
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	"golang.org/x/term"
+
+	"crypto-ops-tool/eotsclient"
+	"crypto-ops-tool/keystore"
+	"crypto-ops-tool/signer"
+)
+
+// passphraseEnv is the environment variable read for the keystore
+// passphrase when --passphrase-file is not set
+const passphraseEnv = "CRYPTO_OPS_PASSPHRASE"
+
+// keyFlags select the FP key of a command from the keystore under --home
+// or from a Cosmos SDK keyring
+type keyFlags struct {
+	// Name is the name of the key, the key is read from argv when empty
+	Name string
+	// PassphraseFile holds the keystore passphrase
+	PassphraseFile string
+	// KeyringDir selects a Cosmos SDK keyring instead of the keystore
+	KeyringDir string
+	// KeyringBackend is the backend of the keyring (test, file, os)
+	KeyringBackend string
+	// Signer selects the kind of signer, or the eotsd://host:port address
+	// of an EOTS manager signing remotely
+	Signer string
+	// FpPkHex is the public key of the FP key held by a remote signer
+	FpPkHex string
+	// SignerTimeout bounds each call to a remote signer
+	SignerTimeout time.Duration
+}
+
+// addKeyFlags registers the flags selecting the FP key. Without --key, the
+// key is the <private_key_hex> positional argument.
+func addKeyFlags(fs *flag.FlagSet) *keyFlags {
+	kf := &keyFlags{}
+	fs.StringVar(&kf.Name, "key", "", "name of the FP key in the keystore under --home (or the --fp-keyring-dir keyring), replacing the <private_key_hex> argument")
+	addKeyStoreFlags(fs, kf)
+	return kf
+}
+
+// addSignerFlags registers the key flags together with the flags selecting
+// the signer. By default the key is signed with in process, --signer picks
+// another kind of signer such as an EOTS manager holding the key.
+func addSignerFlags(fs *flag.FlagSet) *keyFlags {
+	kf := addKeyFlags(fs)
+	fs.StringVar(&kf.Signer, "signer", "", "signer of the FP key: local (<private_key_hex>), keystore (--key) or eotsd://host:port to delegate to an EOTS manager (default: keystore with --key, local otherwise)")
+	fs.StringVar(&kf.FpPkHex, "fp-pk", "", "BIP340 public key (hex) of the FP key held by a remote --signer")
+	fs.DurationVar(&kf.SignerTimeout, "signer-timeout", 10*time.Second, "timeout of each call to a remote --signer")
+	return kf
+}
+
+// addKeyStoreFlags registers the flags locating the FP keys on fs. They
+// are distinct from the --keyring-* chain flags, which select the key paying
+// for transactions.
+func addKeyStoreFlags(fs *flag.FlagSet, kf *keyFlags) {
+	fs.StringVar(&kf.PassphraseFile, "passphrase-file", "", "file holding the keystore (or file keyring) passphrase (default: $"+passphraseEnv+", then a terminal prompt)")
+	fs.StringVar(&kf.KeyringDir, "fp-keyring-dir", "", "Cosmos SDK keyring directory holding the FP keys, e.g. .testnets/eotsmanager (default: the keystore under --home)")
+	fs.StringVar(&kf.KeyringBackend, "fp-keyring-backend", "test", "backend of the --fp-keyring-dir keyring (test, file, os)")
+}
+
+// fpKeys is a store of named FP keys: the encrypted keystore under --home,
+// or a Cosmos SDK keyring
+type fpKeys interface {
+	Add(name string, sk *btcec.PrivateKey) (*keystore.KeyInfo, error)
+	Get(name string) (*btcec.PrivateKey, error)
+	Info(name string) (*keystore.KeyInfo, error)
+	List() ([]*keystore.KeyInfo, error)
+	Dir() string
+}
+
+// passphraseKeystore reads the passphrase of the encrypted keystore the
+// first time a key is added or decrypted
+type passphraseKeystore struct {
+	*keystore.Keystore
+	kf         *keyFlags
+	passphrase []byte
+}
+
+// Add stores sk encrypted, with a passphrase entered twice when prompted
+func (ks *passphraseKeystore) Add(name string, sk *btcec.PrivateKey) (*keystore.KeyInfo, error) {
+	if err := ks.readPassphrase(true); err != nil {
+		return nil, err
+	}
+	return ks.Keystore.Add(name, sk, ks.passphrase)
+}
+
+// Get decrypts the key stored under name
+func (ks *passphraseKeystore) Get(name string) (*btcec.PrivateKey, error) {
+	if err := ks.readPassphrase(false); err != nil {
+		return nil, err
+	}
+	return ks.Keystore.Get(name, ks.passphrase)
+}
+
+func (ks *passphraseKeystore) readPassphrase(confirm bool) error {
+	if ks.passphrase != nil {
+		return nil
+	}
+	passphrase, err := readPassphrase(ks.kf, confirm)
+	if err != nil {
+		return err
+	}
+	ks.passphrase = passphrase
+	return nil
+}
+
+// openFpKeys opens the keyring selected by --fp-keyring-dir, or else the
+// keystore under home
+func openFpKeys(kf *keyFlags, home string) (fpKeys, error) {
+	if kf.KeyringDir == "" {
+		ks, err := openKeystore(home)
+		if err != nil {
+			return nil, err
+		}
+		return &passphraseKeystore{Keystore: ks, kf: kf}, nil
+	}
+
+	// The file backend prompts for its passphrase, possibly twice when the
+	// keyring is created, on its input
+	input := io.Reader(os.Stdin)
+	if kf.KeyringBackend == keyring.BackendFile {
+		passphrase, err := readPassphrase(kf, false)
+		if err != nil {
+			return nil, err
+		}
+		input = strings.NewReader(strings.Repeat(string(passphrase)+"\n", 2))
+	}
+	kr, err := keystore.OpenKeyring(kf.KeyringDir, kf.KeyringBackend, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keyring: %v", err)
+	}
+	return kr, nil
+}
+
+// signerKind returns the kind of signer selected by --signer: eotsd for an
+// eotsd:// address, and by default keystore with --key and local otherwise
+func (kf *keyFlags) signerKind() string {
+	switch {
+	case strings.HasPrefix(kf.Signer, eotsclient.Scheme+"://"):
+		return signer.KindEOTSD
+	case kf.Signer != "":
+		return kf.Signer
+	case kf.Name != "":
+		return signer.KindKeystore
+	default:
+		return signer.KindLocal
+	}
+}
+
+// numKeyArgs returns the number of positional arguments holding the FP key:
+// one for a local signer, none otherwise
+func numKeyArgs(kf *keyFlags) int {
+	if kf.signerKind() == signer.KindLocal {
+		return 1
+	}
+	return 0
+}
+
+// loadSigner returns the signer selected by the key flags. A local signer
+// takes its key from the first of args, which is then dropped from the
+// returned args; the other signers leave args unchanged.
+func loadSigner(kf *keyFlags, home string, args []string) (signer.Signer, []string, error) {
+	cfg := signer.Config{
+		Kind:    kf.signerKind(),
+		KeyName: kf.Name,
+		Timeout: kf.SignerTimeout,
+	}
+	switch cfg.Kind {
+	case signer.KindLocal:
+		if len(args) == 0 {
+			return nil, nil, fmt.Errorf("missing <private_key_hex> argument or --key")
+		}
+		privKeyBytes, err := hex.DecodeString(args[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid private key hex: %v", err)
+		}
+		cfg.PrivateKey, _ = btcec.PrivKeyFromBytes(privKeyBytes)
+		args = args[1:]
+	case signer.KindKeystore:
+		if kf.Name == "" {
+			return nil, nil, fmt.Errorf("--key is required with --signer %s", signer.KindKeystore)
+		}
+		keys, err := openFpKeys(kf, home)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Keys = keys
+	case signer.KindEOTSD:
+		if kf.FpPkHex == "" {
+			return nil, nil, fmt.Errorf("--fp-pk is required with --signer %s", kf.Signer)
+		}
+		cfg.Target = kf.Signer
+	}
+	if kf.FpPkHex != "" {
+		fpPK, err := bbn.NewBIP340PubKeyFromHex(kf.FpPkHex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --fp-pk: %v", err)
+		}
+		cfg.FpPk = fpPK
+	}
+
+	s, err := signer.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch s := s.(type) {
+	case *signer.KeystoreSigner:
+		fmt.Fprintf(os.Stderr, "  → Using key %s (%s)\n", s.Name(), s.PublicKey().MarshalHex())
+	case *signer.RemoteSigner:
+		fmt.Fprintf(os.Stderr, "  → Signing with EOTS manager %s for FP %s\n", s.Target(), s.PublicKey().MarshalHex())
+	}
+	return s, args, nil
+}
+
+// closeSigner releases the connections held by s, if any
+func closeSigner(s signer.Signer) {
+	if c, ok := s.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// openKeystore opens the keystore under home, which must be set
+func openKeystore(home string) (*keystore.Keystore, error) {
+	if home == "" {
+		return nil, fmt.Errorf("--home is required to use the keystore")
+	}
+	ks, err := keystore.Open(home)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keystore: %v", err)
+	}
+	return ks, nil
+}
+
+// readPassphrase reads the keystore passphrase from --passphrase-file, the
+// environment or the terminal. A passphrase typed for a new key has to be
+// entered twice when confirm is set.
+func readPassphrase(kf *keyFlags, confirm bool) ([]byte, error) {
+	if kf.PassphraseFile != "" {
+		data, err := os.ReadFile(kf.PassphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %v", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	// Stdin may carry the command's input, so prompt on the terminal itself
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no passphrase: set --passphrase-file or $%s", passphraseEnv)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, "Keystore passphrase: ")
+	passphrase, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	if confirm {
+		fmt.Fprint(tty, "Repeat passphrase: ")
+		repeated, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(tty)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %v", err)
+		}
+		if !bytes.Equal(passphrase, repeated) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// addKey stores fpSk, or a new random key when fpSk is nil, in keys under
+// name
+func addKey(keys fpKeys, name string, fpSk *btcec.PrivateKey) (*keystore.KeyInfo, error) {
+	if fpSk == nil {
+		var err error
+		fpSk, err = btcec.NewPrivateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate BTC key: %v", err)
+		}
+	}
+	info, err := keys.Add(name, fpSk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store key: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "  ✅ Stored key %s in %s\n", name, keys.Dir())
+	return info, nil
+}
+
+// readPrivateKey reads a hex private key, or the output of
+// generate-keypair, from r
+func readPrivateKey(r io.Reader) (*btcec.PrivateKey, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+	privKeyHex := strings.TrimSpace(string(data))
+	if strings.HasPrefix(privKeyHex, "{") {
+		var keyPair struct {
+			PrivateKey string `json:"private_key"`
+		}
+		if err := json.Unmarshal(data, &keyPair); err != nil {
+			return nil, fmt.Errorf("failed to parse key pair: %v", err)
+		}
+		privKeyHex = keyPair.PrivateKey
+	}
+	privKeyBytes, err := hex.DecodeString(privKeyHex)
+	if err != nil || len(privKeyBytes) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("invalid private key: expected %d hex encoded bytes", btcec.PrivKeyBytesLen)
+	}
+	fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+	return fpSk, nil
+}
+
+// confirmExport asks on the terminal to type the name of the key about to
+// be exported in the clear
+func confirmExport(name string) error {
+	return confirmPrivateKeyPrint(fmt.Sprintf("%q", name), name)
+}
+
+// confirmPrivateKeyPrint asks on the terminal to type answer before the
+// unencrypted private key of the key described by what is printed
+func confirmPrivateKeyPrint(what, answer string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("no terminal to confirm on, pass --yes to print the private key anyway")
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "⚠️  This prints the unencrypted private key of %s. Type %q to confirm: ", what, answer)
+	typed, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %v", err)
+	}
+	if strings.TrimSpace(typed) != answer {
+		return fmt.Errorf("printing the private key was not confirmed")
+	}
+	return nil
+}
+
+// printJSON prints v as JSON on stdout
+func printJSON(v interface{}) {
+	jsonOutput, err := json.Marshal(v)
+	if err != nil {
+		log.Fatalf("Failed to marshal output: %v", err)
+	}
+	fmt.Println(string(jsonOutput))
+}
+
+// runKeys implements `crypto-ops keys <add|import|list|show|export>`
+func runKeys(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Error: Missing subcommand for keys")
+		printUsage()
+		os.Exit(1)
+	}
+	subcommand := args[0]
+
+	fs := flag.NewFlagSet("keys "+subcommand, flag.ExitOnError)
+	home := addHomeFlag(fs)
+	kf := &keyFlags{}
+	addKeyStoreFlags(fs, kf)
+	yes := fs.Bool("yes", false, "export without the interactive confirmation (export only)")
+	positional := parseArgs(fs, args[1:])
+
+	keys, err := openFpKeys(kf, *home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if subcommand == "list" {
+		infos, err := keys.List()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if infos == nil {
+			infos = []*keystore.KeyInfo{}
+		}
+		printJSON(infos)
+		return
+	}
+
+	if len(positional) < 1 {
+		fmt.Printf("Error: Missing key name for keys %s\n", subcommand)
+		printUsage()
+		os.Exit(1)
+	}
+	name := positional[0]
+
+	switch subcommand {
+	case "add", "import":
+		var fpSk *btcec.PrivateKey
+		if subcommand == "import" {
+			in := io.Reader(os.Stdin)
+			if len(positional) > 1 && positional[1] != "-" {
+				f, err := os.Open(positional[1])
+				if err != nil {
+					log.Fatalf("Failed to open private key file: %v", err)
+				}
+				defer f.Close()
+				in = f
+			}
+			fpSk, err = readPrivateKey(in)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+		info, err := addKey(keys, name, fpSk)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		printJSON(info)
+
+	case "show":
+		info, err := keys.Info(name)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		printJSON(info)
+
+	case "export":
+		if !*yes {
+			if err := confirmExport(name); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+		fpSk, err := keys.Get(name)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		printJSON(map[string]string{
+			"name":        name,
+			"public_key":  bbn.NewBIP340PubKeyFromBTCPK(fpSk.PubKey()).MarshalHex(),
+			"private_key": hex.EncodeToString(fpSk.Serialize()),
+		})
+
+	default:
+		fmt.Printf("Unknown keys subcommand: %s\n", subcommand)
+		printUsage()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"crypto-ops-tool/l2client"
+)
+
+// blockHashLen is the length of L2 block hashes
+const blockHashLen = 32
+
+// parseBlockHash decodes the hex, optionally 0x-prefixed, hash of an L2
+// block. A hash of another length is refused, since once signed it would
+// be recorded for its height and the real block refused as a double sign.
+func parseBlockHash(s string) ([]byte, error) {
+	blockHash, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid block hash hex: %v", err)
+	}
+	if len(blockHash) != blockHashLen {
+		return nil, fmt.Errorf("block hash is %d bytes, expected %d", len(blockHash), blockHashLen)
+	}
+	return blockHash, nil
+}
+
+// resolveBlockHash returns the hash of the block to sign at blockHeight, as
+// given with --block-hash or fetched with eth_getBlockByNumber from
+// --l2-rpc. It returns nil when neither is set and a mock block is signed.
+func resolveBlockHash(bf *blockFlags, blockHeight uint64) ([]byte, error) {
+	if bf.BlockHashHex != "" && bf.L2RPCAddr != "" {
+		return nil, fmt.Errorf("--block-hash and --l2-rpc are mutually exclusive")
+	}
+
+	if bf.BlockHashHex != "" {
+		return parseBlockHash(bf.BlockHashHex)
+	}
+
+	if bf.L2RPCAddr != "" {
+		l2Client, err := l2client.New(bf.L2RPCAddr, bf.L2Timeout)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "  → Fetching L2 block %d from %s...\n", blockHeight, bf.L2RPCAddr)
+		block, err := l2Client.BlockByNumber(context.Background(), blockHeight)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch L2 block %d: %v", blockHeight, err)
+		}
+		fmt.Fprintf(os.Stderr, "  → L2 block: height=%d, hash=%x\n", block.Number, block.Hash)
+		return block.Hash, nil
+	}
+
+	return nil, nil
+}
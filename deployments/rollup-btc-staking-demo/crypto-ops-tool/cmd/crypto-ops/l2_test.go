@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+)
+
+// l2Stub serves eth_getBlockByNumber with a single block
+func l2Stub(t *testing.T, number string, blockHash []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64        `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_getBlockByNumber" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var result interface{}
+		if req.Params[0] == number {
+			result = map[string]string{
+				"number":     number,
+				"hash":       "0x" + hex.EncodeToString(blockHash),
+				"parentHash": "0x" + hex.EncodeToString(make([]byte, 32)),
+				"timestamp":  "0x1",
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolveBlockHashSignsL2Block(t *testing.T) {
+	const height = 42
+	blockHash := bytes.Repeat([]byte{0xab}, 32)
+	srv := l2Stub(t, "0x2a", blockHash)
+
+	got, err := resolveBlockHash(&blockFlags{L2RPCAddr: srv.URL, L2Timeout: 5 * time.Second}, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, blockHash) {
+		t.Fatalf("block hash = %x, want %x", got, blockHash)
+	}
+
+	// The finality signature commits to height || hash of the fetched block
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	s := signer.NewLocal(sk)
+	randCommit, err := signerRandCommit(s, "consumer-id", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubRand, sig, proof, err := generateFinalitySignature([]*RandCommit{randCommit}, s, height, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: s.PublicKey().MarshalHex(),
+		Height:      height,
+		PubRand:     pubRand,
+		Proof:       opfinality.NewProof(proof),
+		BlockHash:   got,
+		Signature:   sig,
+	}
+	commit := &opfinality.PubRandCommit{StartHeight: 1, NumPubRand: 100, Commitment: randCommit.Commitment}
+	if err := opfinality.VerifyFinalitySignature(msg, commit); err != nil {
+		t.Fatalf("signature over the L2 block does not verify: %v", err)
+	}
+
+	// It does not verify for another hash at that height
+	msg.BlockHash = bytes.Repeat([]byte{0xcd}, 32)
+	if err := opfinality.VerifyFinalitySignature(msg, commit); err == nil {
+		t.Fatal("signature verifies over another block hash")
+	}
+}
+
+func TestResolveBlockHashMissingBlock(t *testing.T) {
+	srv := l2Stub(t, "0x2a", bytes.Repeat([]byte{0xab}, 32))
+
+	if _, err := resolveBlockHash(&blockFlags{L2RPCAddr: srv.URL, L2Timeout: 5 * time.Second}, 43); err == nil {
+		t.Fatal("missing L2 block resolved")
+	}
+}
+
+func TestResolveBlockHashFlags(t *testing.T) {
+	blockHash := bytes.Repeat([]byte{0xab}, 32)
+	got, err := resolveBlockHash(&blockFlags{BlockHashHex: "0x" + hex.EncodeToString(blockHash)}, 1)
+	if err != nil || !bytes.Equal(got, blockHash) {
+		t.Fatalf("--block-hash = %x, %v", got, err)
+	}
+	for _, bad := range []string{"0xabcd", hex.EncodeToString(blockHash[:31]), hex.EncodeToString(append(blockHash, 0x01)), "zz"} {
+		if _, err := resolveBlockHash(&blockFlags{BlockHashHex: bad}, 1); err == nil {
+			t.Errorf("--block-hash %s accepted", bad)
+		}
+	}
+	if _, err := resolveBlockHash(&blockFlags{BlockHashHex: "ab", L2RPCAddr: "http://localhost:8545"}, 1); err == nil {
+		t.Fatal("--block-hash and --l2-rpc accepted together")
+	}
+	got, err = resolveBlockHash(&blockFlags{}, 1)
+	if err != nil || got != nil {
+		t.Fatalf("no block flags = %x, %v, want a mock block", got, err)
+	}
+}
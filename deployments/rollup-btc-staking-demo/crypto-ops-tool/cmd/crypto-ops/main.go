@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -12,8 +15,11 @@ import (
 	"strconv"
 	"time"
 
-	"os/exec"
-	"strings"
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/hdkey"
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
 
 	appparams "github.com/babylonlabs-io/babylon/v4/app/params"
 	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
@@ -26,24 +32,6 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
-const (
-	BBN_CHAIN_ID    = "chain-test"
-	KEYRING_BACKEND = "test"
-	KEY_NAME        = "test-spending-key"
-)
-
-func execDockerCommand(container string, command ...string) (string, error) {
-	fullCmd := append([]string{"exec", container, "/bin/sh", "-c"}, strings.Join(command, " "))
-	cmd := exec.Command("docker", fullCmd...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Show both the error and the full output for debugging
-		return "", fmt.Errorf("docker command failed: %v\nCommand: docker %s\nOutput: %s",
-			err, strings.Join(fullCmd, " "), string(output))
-	}
-	return strings.TrimSpace(string(output)), nil
-}
-
 // PublicRandomnessCommitment represents the output for pub randomness operations
 type PublicRandomnessCommitment struct {
 	ContractMessage string `json:"contract_message"`
@@ -70,6 +58,7 @@ type ProofOfPossession struct {
 
 // SerializableRandListInfo is a JSON-serializable version of datagen.RandListInfo
 type SerializableRandListInfo struct {
+	StartHeight   uint64   `json:"start_height"`   // height of the first randomness in the list
 	SRListHex     []string `json:"sr_list_hex"`    // hex encoded private randomness
 	PRListHex     []string `json:"pr_list_hex"`    // hex encoded public randomness
 	CommitmentHex string   `json:"commitment_hex"` // hex encoded commitment
@@ -81,9 +70,10 @@ type SerializableRandListInfo struct {
 	} `json:"proof_list_data"`
 }
 
-// ConvertToSerializable converts datagen.RandListInfo to SerializableRandListInfo
-func ConvertToSerializable(randListInfo *datagen.RandListInfo) (*SerializableRandListInfo, error) {
+// ConvertToSerializable converts datagen.RandListInfo committed from startHeight to SerializableRandListInfo
+func ConvertToSerializable(randListInfo *datagen.RandListInfo, startHeight uint64) (*SerializableRandListInfo, error) {
 	serializable := &SerializableRandListInfo{
+		StartHeight:   startHeight,
 		SRListHex:     make([]string, len(randListInfo.SRList)),
 		PRListHex:     make([]string, len(randListInfo.PRList)),
 		CommitmentHex: hex.EncodeToString(randListInfo.Commitment),
@@ -178,11 +168,10 @@ func ConvertFromSerializable(serializable *SerializableRandListInfo) (*datagen.R
 }
 
 // Generate Proof of Possession exactly like datagen.NewPoPBTC
-func generateProofOfPossession(addr sdk.AccAddress, btcSK *btcec.PrivateKey) (*ProofOfPossession, error) {
-	// Use datagen.NewPoPBTC exactly like the reference implementation
-	pop, err := datagen.NewPoPBTC(addr, btcSK)
+func generateProofOfPossession(addr sdk.AccAddress, s signer.Signer) (*ProofOfPossession, error) {
+	pop, err := s.SignPoP(context.Background(), addr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate PoP: %w", err)
+		return nil, err
 	}
 
 	// Convert PoP to hex string exactly like the reference code does
@@ -196,78 +185,99 @@ func generateProofOfPossession(addr sdk.AccAddress, btcSK *btcec.PrivateKey) (*P
 	}, nil
 }
 
-func commitPublicRandomness(r *mathrand.Rand, contractAddr string, consumerFpSk *btcec.PrivateKey, startHeight, numPubRand uint64) (*datagen.RandListInfo, error) {
-	fmt.Fprintln(os.Stderr, "  → Generating public randomness list...")
+// printTxResult logs the outcome of an included transaction as JSON
+func printTxResult(res *sdk.TxResponse) {
+	resJSON, err := json.Marshal(bbnclient.NewTxResult(res))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  → Submission result: txhash=%s height=%d\n", res.TxHash, res.Height)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  → Submission result: %s\n", resJSON)
+}
 
+// commitPublicRandomness commits numPubRand randomness values from
+// startHeight to the contract. With a store, the randomness is derived for
+// the consumer of the contract like --deterministic, so that the store keeps
+// only that consumer id and never the secret randomness.
+func commitPublicRandomness(finalityContract *opfinality.Client, st *store.Store, r *mathrand.Rand, s signer.Signer, startHeight, numPubRand uint64) (*RandCommit, error) {
 	// Follow exact test pattern: btcPK -> bip340PK -> MarshalHex()
-	btcPK := consumerFpSk.PubKey()
-	bip340PK := bbn.NewBIP340PubKeyFromBTCPK(btcPK)
+	bip340PK := s.PublicKey()
 	consumerBtcPk := bip340PK.MarshalHex()
 
 	// Use the provided parameters
 	commitStartHeight := startHeight
 
-	// Generate the message exactly like datagen.GenRandomMsgCommitPubRandList
-	randListInfo, msgCommitPubRandList, err := datagen.GenRandomMsgCommitPubRandList(r, consumerFpSk, commitStartHeight, numPubRand)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate public randomness list: %v", err)
+	var (
+		randCommit *RandCommit
+		signature  []byte
+	)
+	if st != nil {
+		config, err := finalityContract.QueryConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the consumer id of the contract: %v", err)
+		}
+		randCommit, signature, err = generateDeterministicPubRandCommitment(s, config.ConsumerID, commitStartHeight, numPubRand)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		randListInfo, _, sig, err := generatePublicRandomnessCommitment(r, s, commitStartHeight, numPubRand)
+		if err != nil {
+			return nil, err
+		}
+		randCommit, signature = &RandCommit{StartHeight: commitStartHeight, RandListInfo: randListInfo}, sig
 	}
-
-	fmt.Fprintf(os.Stderr, "  → Generated %d public randomness values starting at height %d\n", numPubRand, commitStartHeight)
+	randListInfo := randCommit.RandListInfo
 
 	// Commit public randomness to the consumer finality contract
 	fmt.Fprintln(os.Stderr, "  → Committing to finality contract...")
 
 	// Create the commit message for the finality contract (exactly like the tests)
-	commitMsg := map[string]interface{}{
-		"commit_public_randomness": map[string]interface{}{
-			"fp_pubkey_hex": consumerBtcPk,
-			"start_height":  commitStartHeight,
-			"num_pub_rand":  numPubRand,
-			"commitment":    randListInfo.Commitment,
-			"signature":     msgCommitPubRandList.Sig.MustToBTCSig().Serialize(),
-		},
-	}
-
-	commitMsgBytes, err := json.Marshal(commitMsg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal commit message: %v", err)
+	commitMsg := &opfinality.CommitPublicRandomness{
+		FpPubkeyHex: consumerBtcPk,
+		StartHeight: commitStartHeight,
+		NumPubRand:  numPubRand,
+		Commitment:  randListInfo.Commitment,
+		Signature:   signature,
 	}
 
-	fmt.Fprintf(os.Stderr, "  → Contract: %s\n", contractAddr)
+	fmt.Fprintf(os.Stderr, "  → Contract: %s\n", finalityContract.Address())
 	fmt.Fprintf(os.Stderr, "  → Committing %d public randomness values...\n", numPubRand)
 
 	// Submit to finality contract using wasm execute
-	commitMsgStr := "'" + string(commitMsgBytes) + "'"
-	output, err := execDockerCommand("babylondnode0",
-		"/bin/babylond", "--home", "/babylondhome", "tx", "wasm", "execute", contractAddr,
-		commitMsgStr, "--from", KEY_NAME, "--chain-id", BBN_CHAIN_ID,
-		"--keyring-backend", KEYRING_BACKEND, "--gas", "500000", "--fees", "100000ubbn", "-y", "--output", "json")
+	res, err := finalityContract.CommitPublicRandomness(context.Background(), commitMsg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to commit public randomness: %v", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "  → Submission result: %s\n", output)
-	time.Sleep(8 * time.Second) // Increased delay for transaction processing
+	printTxResult(res)
 
 	// Query the finality contract to verify the commitment was stored
 	fmt.Fprintln(os.Stderr, "  → Verifying commitment was stored (with retry)...")
-	err = verifyPublicRandomnessCommitmentWithRetry(contractAddr, consumerBtcPk, commitStartHeight, numPubRand, randListInfo.Commitment, 5, 3*time.Second)
+	err = verifyPublicRandomnessCommitmentWithRetry(finalityContract, consumerBtcPk, commitStartHeight, numPubRand, randListInfo.Commitment, 5, 3*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify commitment after retries: %v", err)
 	}
 
-	// Return the randListInfo for use in finality signatures
-	return randListInfo, nil
+	// Persist the commitment only once the contract holds it, so that a
+	// failed commit leaves no stored range for a retry to overlap
+	if st != nil {
+		if err := saveRandCommit(st, bip340PK, randCommit); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return the randomness for use in finality signatures
+	return randCommit, nil
 }
 
-func verifyPublicRandomnessCommitmentWithRetry(contractAddr, consumerBtcPk string, expectedStartHeight, expectedNumPubRand uint64, expectedCommitment []byte, maxRetries int, retryInterval time.Duration) error {
+func verifyPublicRandomnessCommitmentWithRetry(finalityContract *opfinality.Client, consumerBtcPk string, expectedStartHeight, expectedNumPubRand uint64, expectedCommitment []byte, maxRetries int, retryInterval time.Duration) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		fmt.Fprintf(os.Stderr, "    → Verification attempt %d/%d...\n", attempt, maxRetries)
 
-		err := verifyPublicRandomnessCommitment(contractAddr, consumerBtcPk, expectedStartHeight, expectedNumPubRand, expectedCommitment)
+		err := verifyPublicRandomnessCommitment(finalityContract, consumerBtcPk, expectedStartHeight, expectedNumPubRand, expectedCommitment)
 		if err == nil {
 			fmt.Fprintf(os.Stderr, "    ✅ Verification succeeded on attempt %d\n", attempt)
 			return nil
@@ -283,56 +293,18 @@ func verifyPublicRandomnessCommitmentWithRetry(contractAddr, consumerBtcPk strin
 	return fmt.Errorf("verification failed after %d attempts, last error: %v", maxRetries, lastErr)
 }
 
-func verifyPublicRandomnessCommitment(contractAddr, consumerBtcPk string, expectedStartHeight, expectedNumPubRand uint64, expectedCommitment []byte) error {
-	// Create query message exactly like the tests do
-	queryMsg := map[string]interface{}{
-		"last_pub_rand_commit": map[string]interface{}{
-			"btc_pk_hex": consumerBtcPk,
-		},
-	}
-
-	queryMsgBytes, err := json.Marshal(queryMsg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal query message: %v", err)
-	}
-
+func verifyPublicRandomnessCommitment(finalityContract *opfinality.Client, consumerBtcPk string, expectedStartHeight, expectedNumPubRand uint64, expectedCommitment []byte) error {
 	// Query the finality contract
-	queryMsgStr := "'" + string(queryMsgBytes) + "'"
-	output, err := execDockerCommand("babylondnode0",
-		"/bin/babylond", "--home", "/babylondhome", "q", "wasm", "contract-state", "smart",
-		contractAddr, queryMsgStr, "--output", "json")
+	commitment, err := finalityContract.QueryLastPubRandCommit(context.Background(), consumerBtcPk)
 	if err != nil {
 		return fmt.Errorf("failed to query finality contract: %v", err)
 	}
 
-	// Parse the response
-	var response struct {
-		Data interface{} `json:"data"`
-	}
-	if err := json.Unmarshal([]byte(output), &response); err != nil {
-		return fmt.Errorf("failed to parse query response: %v", err)
-	}
-
 	// Check if data is null (no commitment found)
-	if response.Data == nil {
+	if commitment == nil {
 		return fmt.Errorf("no public randomness commitment found for FP %s", consumerBtcPk)
 	}
 
-	// Parse the commitment data
-	dataBytes, err := json.Marshal(response.Data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal response data: %v", err)
-	}
-
-	var commitment struct {
-		StartHeight uint64 `json:"start_height"`
-		NumPubRand  uint64 `json:"num_pub_rand"`
-		Commitment  []byte `json:"commitment"` // Array of bytes, not string
-	}
-	if err := json.Unmarshal(dataBytes, &commitment); err != nil {
-		return fmt.Errorf("failed to parse commitment data: %v", err)
-	}
-
 	// Verify the commitment matches what we submitted
 	if commitment.StartHeight != expectedStartHeight {
 		return fmt.Errorf("start height mismatch: expected %d, got %d", expectedStartHeight, commitment.StartHeight)
@@ -352,82 +324,80 @@ func verifyPublicRandomnessCommitment(contractAddr, consumerBtcPk string, expect
 	return nil
 }
 
-func submitFinalitySignature(r *mathrand.Rand, contractAddr string, randListInfo *datagen.RandListInfo, consumerFpSk *btcec.PrivateKey, blockHeight uint64) error {
-	fmt.Fprintln(os.Stderr, "  → Generating mock block to vote on...")
-
+// submitFinalitySignature signs and submits a finality signature for the
+// block at blockHeight with hash blockHash, or for a mock block when
+// blockHash is nil
+func submitFinalitySignature(finalityContract *opfinality.Client, st *store.Store, allowEquivocation bool, r *mathrand.Rand, randCommits []*RandCommit, s signer.Signer, blockHeight uint64, blockHash []byte) error {
 	// Follow exact test pattern: btcPK -> bip340PK -> MarshalHex()
-	btcPK := consumerFpSk.PubKey()
-	bip340PK := bbn.NewBIP340PubKeyFromBTCPK(btcPK)
+	bip340PK := s.PublicKey()
 	consumerBtcPk := bip340PK.MarshalHex()
 
-	// Generate a random block exactly like the tests do
+	if blockHash == nil {
+		// Generate a random block exactly like the tests do
+		fmt.Fprintln(os.Stderr, "  → Generating mock block to vote on...")
+		var err error
+		blockHash, err = mockBlockHash(st, r, bip340PK, blockHeight, allowEquivocation)
+		if err != nil {
+			return err
+		}
+	}
 	blockToVote := &ftypes.IndexedBlock{
 		Height:  blockHeight,
-		AppHash: datagen.GenRandomByteArray(r, 32),
+		AppHash: blockHash,
 	}
 
-	fmt.Fprintf(os.Stderr, "  → Mock block: height=%d, appHash=%x\n", blockToVote.Height, blockToVote.AppHash)
+	fmt.Fprintf(os.Stderr, "  → Block to vote on: height=%d, hash=%x\n", blockToVote.Height, blockToVote.AppHash)
+
+	if err := checkSlashingProtection(st, bip340PK, blockHeight, blockToVote.AppHash, allowEquivocation); err != nil {
+		return err
+	}
 
 	// Create message to sign (exactly like the tests)
 	msgToSign := append(sdk.Uint64ToBigEndian(blockHeight), blockToVote.AppHash...)
 
-	// Calculate randomness index (assuming randomness starts from height 1)
-	if blockHeight < 1 {
-		return fmt.Errorf("block height must be >= 1, got %d", blockHeight)
-	}
-	randIndex := int(blockHeight - 1)
-	if randIndex >= len(randListInfo.SRList) {
-		return fmt.Errorf("block height %d requires randomness index %d, but only %d randomness values available", blockHeight, randIndex, len(randListInfo.SRList))
+	// Find the commitment covering the block and the randomness index within it
+	randCommit, randIndex, err := findRandCommit(randCommits, blockHeight)
+	if err != nil {
+		return err
 	}
+	randListInfo := randCommit.RandListInfo
 
 	// Generate EOTS signature using the calculated randomness index
 	fmt.Fprintf(os.Stderr, "  → Generating EOTS signature using randomness index %d for height %d...\n", randIndex, blockHeight)
-	sig, err := eots.Sign(consumerFpSk, randListInfo.SRList[randIndex], msgToSign)
+	eotsSig, err := randCommit.signEOTS(s, randIndex, msgToSign)
 	if err != nil {
 		return fmt.Errorf("failed to generate EOTS signature: %v", err)
 	}
-	eotsSig := bbn.NewSchnorrEOTSSigFromModNScalar(sig)
 
-	// Create finality signature message for the contract (exactly like the tests)
-	proof := randListInfo.ProofList[randIndex].ToProto()
-	finalitySigMsg := map[string]interface{}{
-		"submit_finality_signature": map[string]interface{}{
-			"fp_pubkey_hex": consumerBtcPk,
-			"height":        blockHeight,
-			"pub_rand":      randListInfo.PRList[randIndex].MustMarshal(),
-			"proof": map[string]interface{}{
-				"total":     uint64(proof.Total),
-				"index":     uint64(proof.Index),
-				"leaf_hash": proof.LeafHash,
-				"aunts":     proof.Aunts,
-			},
-			"block_hash": blockToVote.AppHash,
-			"signature":  eotsSig.MustMarshal(),
-		},
+	if st != nil {
+		if err := recordSignature(st, bip340PK, blockHeight, blockToVote.AppHash, eotsSig.MustMarshal(), allowEquivocation); err != nil {
+			return err
+		}
 	}
 
-	finalitySigMsgBytes, err := json.Marshal(finalitySigMsg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal finality signature message: %v", err)
+	// Create finality signature message for the contract (exactly like the tests)
+	finalitySigMsg := &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: consumerBtcPk,
+		Height:      blockHeight,
+		PubRand:     randListInfo.PRList[randIndex].MustMarshal(),
+		Proof:       opfinality.NewProof(randListInfo.ProofList[randIndex]),
+		BlockHash:   blockToVote.AppHash,
+		Signature:   eotsSig.MustMarshal(),
 	}
 
 	fmt.Fprintf(os.Stderr, "  → Submitting finality signature for block height %d...\n", blockHeight)
 
 	// Submit to finality contract using wasm execute
-	finalitySigMsgStr := "'" + string(finalitySigMsgBytes) + "'"
-	output, err := execDockerCommand("babylondnode0",
-		"/bin/babylond", "--home", "/babylondhome", "tx", "wasm", "execute", contractAddr,
-		finalitySigMsgStr, "--from", KEY_NAME, "--chain-id", BBN_CHAIN_ID,
-		"--keyring-backend", KEYRING_BACKEND, "--gas", "500000", "--fees", "100000ubbn", "-y", "--output", "json")
+	res, err := finalityContract.SubmitFinalitySignature(context.Background(), finalitySigMsg)
 	if err != nil {
 		return fmt.Errorf("failed to submit finality signature: %v", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "  → Submission result: %s\n", output)
+	printTxResult(res)
 
 	// Verify the signature was recorded by querying block voters with retry logic
 	fmt.Fprintln(os.Stderr, "  → Verifying finality signature was recorded (with retry)...")
-	err = verifyFinalitySignatureWithRetry(contractAddr, blockToVote.Height, blockToVote.AppHash, consumerBtcPk, 5, 3*time.Second)
+	err = verifyFinalitySignatureWithRetry(finalityContract, blockToVote.Height, blockToVote.AppHash, consumerBtcPk, 5, 3*time.Second)
 	if err != nil {
 		return fmt.Errorf("failed to verify finality signature after retries: %v", err)
 	}
@@ -437,13 +407,13 @@ func submitFinalitySignature(r *mathrand.Rand, contractAddr string, randListInfo
 	return nil
 }
 
-func verifyFinalitySignatureWithRetry(contractAddr string, blockHeight uint64, blockAppHash []byte, expectedVoter string, maxRetries int, retryInterval time.Duration) error {
+func verifyFinalitySignatureWithRetry(finalityContract *opfinality.Client, blockHeight uint64, blockAppHash []byte, expectedVoter string, maxRetries int, retryInterval time.Duration) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		fmt.Fprintf(os.Stderr, "    → Verification attempt %d/%d...\n", attempt, maxRetries)
 
-		err := verifyFinalitySignature(contractAddr, blockHeight, blockAppHash, expectedVoter)
+		err := verifyFinalitySignature(finalityContract, blockHeight, blockAppHash, expectedVoter)
 		if err == nil {
 			fmt.Fprintf(os.Stderr, "    ✅ Verification succeeded on attempt %d\n", attempt)
 			return nil
@@ -459,40 +429,16 @@ func verifyFinalitySignatureWithRetry(contractAddr string, blockHeight uint64, b
 	return fmt.Errorf("verification failed after %d attempts, last error: %v", maxRetries, lastErr)
 }
 
-func verifyFinalitySignature(contractAddr string, blockHeight uint64, blockAppHash []byte, expectedVoter string) error {
-	// Create query message exactly like the tests do
-	queryMsg := map[string]interface{}{
-		"block_voters": map[string]interface{}{
-			"height": blockHeight,
-			"hash":   hex.EncodeToString(blockAppHash),
-		},
-	}
-
-	queryMsgBytes, err := json.Marshal(queryMsg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal query message: %v", err)
-	}
-
+func verifyFinalitySignature(finalityContract *opfinality.Client, blockHeight uint64, blockAppHash []byte, expectedVoter string) error {
 	// Query the finality contract
-	queryMsgStr := "'" + string(queryMsgBytes) + "'"
-	output, err := execDockerCommand("babylondnode0",
-		"/bin/babylond", "--home", "/babylondhome", "q", "wasm", "contract-state", "smart",
-		contractAddr, queryMsgStr, "--output", "json")
+	voters, err := finalityContract.QueryBlockVoters(context.Background(), blockHeight, blockAppHash)
 	if err != nil {
 		return fmt.Errorf("failed to query finality contract: %v", err)
 	}
 
-	// Parse the response
-	var response struct {
-		Data []string `json:"data"`
-	}
-	if err := json.Unmarshal([]byte(output), &response); err != nil {
-		return fmt.Errorf("failed to parse query response: %v", err)
-	}
-
 	// Check if our finality provider voted
 	found := false
-	for _, voter := range response.Data {
+	for _, voter := range voters {
 		if voter == expectedVoter {
 			found = true
 			break
@@ -508,55 +454,52 @@ func verifyFinalitySignature(contractAddr string, blockHeight uint64, blockAppHa
 }
 
 // Generate public randomness and commitment (crypto only, no chain submission)
-func generatePublicRandomnessCommitment(r *mathrand.Rand, consumerFpSk *btcec.PrivateKey, startHeight, numPubRand uint64) (*datagen.RandListInfo, *bbn.BIP340PubKey, []byte, error) {
-	fmt.Fprintln(os.Stderr, "  → Generating public randomness list...")
+func generatePublicRandomnessCommitment(r *mathrand.Rand, s signer.Signer, startHeight, numPubRand uint64) (*datagen.RandListInfo, *bbn.BIP340PubKey, []byte, error) {
+	// The randomness is generated here, so the signer must sign with
+	// randomness it did not derive
+	if _, ok := s.(signer.SecretRandSigner); !ok {
+		return nil, nil, nil, signer.ErrSecretRandUnsupported
+	}
 
-	// Follow exact test pattern: btcPK -> bip340PK -> MarshalHex()
-	btcPK := consumerFpSk.PubKey()
-	bip340PK := bbn.NewBIP340PubKeyFromBTCPK(btcPK)
+	fmt.Fprintln(os.Stderr, "  → Generating public randomness list...")
 
-	// Generate the message exactly like datagen.GenRandomMsgCommitPubRandList
-	randListInfo, msgCommitPubRandList, err := datagen.GenRandomMsgCommitPubRandList(r, consumerFpSk, startHeight, numPubRand)
+	// Generate the randomness exactly like datagen.GenRandomMsgCommitPubRandList
+	randListInfo, err := datagen.GenRandomPubRandList(r, numPubRand)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to generate public randomness list: %v", err)
 	}
+	signature, err := signCommitment(s, startHeight, numPubRand, randListInfo.Commitment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	fmt.Fprintf(os.Stderr, "  → Generated %d public randomness values starting at height %d\n", numPubRand, startHeight)
 
 	// Return the randomness info, public key, and signature for bash script to submit
-	signature := msgCommitPubRandList.Sig.MustToBTCSig().Serialize()
-	return randListInfo, bip340PK, signature, nil
+	return randListInfo, s.PublicKey(), signature, nil
 }
 
 // Generate finality signature (crypto only, no chain submission)
-func generateFinalitySignature(r *mathrand.Rand, randListInfo *datagen.RandListInfo, consumerFpSk *btcec.PrivateKey, blockHeight uint64, blockHash []byte) (*bbn.BIP340PubKey, []byte, []byte, *merkle.Proof, error) {
+func generateFinalitySignature(randCommits []*RandCommit, s signer.Signer, blockHeight uint64, blockHash []byte) ([]byte, []byte, *merkle.Proof, error) {
 	fmt.Fprintln(os.Stderr, "  → Generating finality signature...")
-
-	// Follow exact test pattern: btcPK -> bip340PK -> MarshalHex()
-	btcPK := consumerFpSk.PubKey()
-	bip340PK := bbn.NewBIP340PubKeyFromBTCPK(btcPK)
-
 	fmt.Fprintf(os.Stderr, "  → Block: height=%d, hash=%x\n", blockHeight, blockHash)
 
 	// Create message to sign (exactly like the tests)
 	msgToSign := append(sdk.Uint64ToBigEndian(blockHeight), blockHash...)
 
-	// Calculate randomness index (assuming randomness starts from height 1)
-	if blockHeight < 1 {
-		return nil, nil, nil, nil, fmt.Errorf("block height must be >= 1, got %d", blockHeight)
-	}
-	randIndex := int(blockHeight - 1)
-	if randIndex >= len(randListInfo.SRList) {
-		return nil, nil, nil, nil, fmt.Errorf("block height %d requires randomness index %d, but only %d randomness values available", blockHeight, randIndex, len(randListInfo.SRList))
+	// Find the commitment covering the block and the randomness index within it
+	randCommit, randIndex, err := findRandCommit(randCommits, blockHeight)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	randListInfo := randCommit.RandListInfo
 
 	// Generate EOTS signature using the calculated randomness index
 	fmt.Fprintf(os.Stderr, "  → Generating EOTS signature using randomness index %d for height %d...\n", randIndex, blockHeight)
-	sig, err := eots.Sign(consumerFpSk, randListInfo.SRList[randIndex], msgToSign)
+	eotsSig, err := randCommit.signEOTS(s, randIndex, msgToSign)
 	if err != nil {
-		return nil, nil, nil, nil, fmt.Errorf("failed to generate EOTS signature: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to generate EOTS signature: %v", err)
 	}
-	eotsSig := bbn.NewSchnorrEOTSSigFromModNScalar(sig)
 
 	// Return all the components needed for bash script to submit
 	publicRandomness := randListInfo.PRList[randIndex].MustMarshal()
@@ -565,37 +508,223 @@ func generateFinalitySignature(r *mathrand.Rand, randListInfo *datagen.RandListI
 
 	fmt.Fprintf(os.Stderr, "  ✅ Finality signature generated for block height %d using randomness index %d\n", blockHeight, randIndex)
 
-	return bip340PK, publicRandomness, signature, proof, nil
+	return publicRandomness, signature, proof, nil
 }
 
 func printUsage() {
 	fmt.Printf(`Usage: %s <command> [args...]
 
 Commands:
-  generate-keypair                                      - Generate a new BTC key pair
+  generate-keypair --home <dir> --key <name>            - Generate a new BTC key pair, stored encrypted in the keystore, and print
+                                                          its public key
+  generate-keypair --insecure-print-private-key [--yes] - Print the unencrypted private key instead, after typing "print" on the
+                                                          terminal unless --yes is set
+  generate-keypair --mnemonic-file <file|-> [--path m/86'/1'/0'/0/i] [--index <i>]
+                                                        - Derive the key pair at a BIP32 path from a BIP39 mnemonic instead
+                                                          (the private key is printed only with --insecure-print-private-key)
+  generate-mnemonic                                     - Generate a new 24-word BIP39 mnemonic
+  derive --mnemonic-file <file|-> --count <n> [--start-index <i>] [--path m/86'/1'/0'/0/i] [--key-prefix <name>]
+                                                        - Derive n FP key pairs deterministically (stored as <name>-<i> with --key-prefix);
+                                                          private keys are printed only with --insecure-print-private-key [--yes]
   generate-pop <private_key_hex> <babylon_address>      - Generate Proof of Possession for FP creation
   
   # Crypto-only operations (recommended)
   generate-pub-rand-commitment <private_key_hex> <start_height> <num_pub_rand> - Generate randomness and commitment data (crypto only)
   generate-finality-sig <private_key_hex> <block_height> - Generate finality signature (crypto only, reads rand_list_info_json from stdin)
+                                                          (stdin may also hold a JSON array of rand_list_info for consecutive commitments)
   
+Deterministic randomness flags (generate-pub-rand-commitment, generate-finality-sig, submit-finality-sig,
+submit-finality-sig-batch, equivocation-scenario):
+  --deterministic              derive randomness from the FP key and consumer id instead of math/rand
+                               (nothing to pipe on stdin; rand_list_info is not printed; implied by --home)
+  --consumer-id <id>           consumer/chain id mixed into the derivation
+  --commit-start-height <h>    start height of the commitment covering the block (signing commands, default 1)
+  --commit-num-pub-rand <n>    size of the commitment covering the block (signing commands; required with
+                               --deterministic, or with an eotsd --signer and no --home)
+
+Signer flags (every command taking <private_key_hex>):
+  --signer <kind>              signer of the FP key: local (<private_key_hex>, the default), keystore (--key, the
+                               default with --key) or eotsd://host:port to delegate randomness and signing to a
+                               running EOTS manager, replacing <private_key_hex>; eotsd derives the randomness like
+                               --deterministic and cannot sign math/rand randomness
+  --fp-pk <hex>                BIP340 public key of the FP key held by eotsd (required with --signer eotsd://)
+  --signer-timeout <d>         timeout of each call to eotsd (default 10s)
+
+  # Offline verification
+  verify-finality-sig --commitment <hex|base64> --commit-start-height <h> --commit-num-pub-rand <n> [<file|->]
+                                                        - Check a generate-finality-sig output (read from stdin by default) like the contract
+                                                          does: merkle inclusion of pub_rand at the right index, then the EOTS signature
+  verify-pub-rand-commitment [--fp-pk <hex>] [<file|->]   - Check a generate-pub-rand-commitment output (stdin by default): the BIP340
+                                                          signature over (start_height, num_pub_rand, commitment) and, when
+                                                          rand_list_info is present, the merkle root of pr_list_hex
+  verify-pop [--btc-network regtest] <btc_pk_hex> <bbn_addr> <pop_hex>
+                                                        - Check a generate-pop output against the FP key and the Babylon address
+                                                          registering it, like create-finality-provider does
+  verify-pop --file <file|->                            - Check the PoP of every FP registration of a JSON file: an array of
+                                                          {btc_pk_hex, bbn_addr, pop_hex}, or the finality_providers of a
+                                                          btcstaking genesis (also a whole genesis file); duplicate keys are rejected
+
+  # Slashing
+  extract-sk [--btc-network regtest] <sig1.json|-> <sig2.json|->  - Recover the FP's BTC secret key (hex and WIF) from two generate-finality-sig
+                                                          outputs at the same height and pub_rand over different blocks
+  equivocation-scenario <private_key_hex> <contract_addr> <block_height>
+                                                        - TESTING ONLY: vote for two conflicting blocks at <block_height>, then report
+                                                          the contract's slashing event and the FP's slashed/jailed state on Babylon
+
+  # Chain operations
+  wait-tx <tx_hash>                                     - Wait for a tx to be included and print its result (code, raw_log, gas, height)
+  fund-fee-payers [--count <n>] [--prefix <name>] [--amount <coins>]
+                                                        - Create the keys <name>-1 to <name>-n in the --keyring-dir keyring unless they
+                                                          exist and fund them from --from, to pass to --fee-payers
+
+  # Slashing protection
+  export-signing-history --home <dir> [--fp-pk <hex>]    - Print the signing history of the store as JSON
+  import-signing-history --home <dir> <file|->           - Merge an exported signing history into the store (refuses conflicts)
+
+  # Keystore (encrypted FP keys under <dir>/keys)
+  keys add --home <dir> <name>                          - Generate a key and store it encrypted with a passphrase
+  keys import --home <dir> <name> [<file|->]            - Store a hex private key (or generate-keypair output) read from a file or stdin
+  keys list --home <dir>                                - List the stored keys and their public keys
+  keys show --home <dir> <name>                         - Print the public key of a stored key
+  keys export --home <dir> [--yes] <name>               - Print the unencrypted private key, after typing its name to confirm
+
+  # Daemon
+  run --home <dir> --l2-rpc <url> --consumer-id <id> <private_key_hex> <contract_addr>
+                                                        - Follow the L2, keep randomness committed ahead of the tip and submit
+                                                          a finality signature for every new block until SIGINT/SIGTERM
+
+  # End-to-end demo
+  demo --home <dir> [--passphrase-file <file>] [--mnemonic-file <file>] [--resume]
+                                                        - Run the flow of rollup-btc-staking-demo.sh against the deployment: deploy the
+                                                          finality contract, register the consumer, create a Babylon and a consumer FP,
+                                                          stake BTC through stakerd, wait for activation, commit randomness and sign
+                                                          blocks; prints a JSON summary and writes <dir>/demo.env. The state
+                                                          saved after each step lets --resume pick up an interrupted run
+  scenario --home <dir> [--passphrase-file <file>] [--mnemonic-file <file>] <scenario_file>
+                                                        - Run a YAML scenario (consumers, FPs, delegations, commit windows, signing
+                                                          schedules) against a fresh deployment and assert its outcomes; prints a
+                                                          JSON report and exits with status 1 when an assertion fails
+  generate-scenario [--consumers <m>] [--fps <n>] [--from-height <h>] [--to-height <h>]
+                                                        - Print a scenario of n consumer FPs spread across m consumers, each FP
+                                                          delegated to and signing every height, for the scenario command to check that
+                                                          block_voters lists all of them at each height
+
   # Legacy combined operations (crypto + chain submission)
   commit-pub-rand <private_key_hex> <contract_addr> <start_height> <num_pub_rand> - Commit pub randomness only
   submit-finality-sig <private_key_hex> <contract_addr> <block_height> - Submit finality signature only (reads rand_list_info_json from stdin)
   commit-and-finalize <private_key_hex> <contract_addr> <start_height> <num_pub_rand> - Commit pub randomness and submit finality signature (legacy)
+  submit-finality-sig-batch <private_key_hex> <contract_addr> <from_height> <to_height>
+  submit-finality-sig-batch --blocks <file|-> <private_key_hex> <contract_addr>
+                                                        - Sign many blocks and submit up to --batch-size signatures per tx
+                                                          (default 1000, like BatchSubmissionSize); prints a result per height
+  
+Block flags (generate-finality-sig, submit-finality-sig, submit-finality-sig-batch; a random mock block is signed when neither is set):
+  --block-hash <hex>           32-byte hash of the block to sign; height || hash is signed as the contract expects
+  --l2-rpc <url>               fetch the block at <block_height> with eth_getBlockByNumber from an OP-stack/Ethereum JSON-RPC endpoint
+  --l2-timeout <dur>           timeout of each L2 JSON-RPC request (default 10s)
+
+Store flag (generate-pub-rand-commitment, generate-finality-sig, commit-pub-rand, submit-finality-sig, submit-finality-sig-batch, commit-and-finalize,
+equivocation-scenario):
+  --home <dir>                 keep commitments and produced signatures in <dir>/data/crypto-ops.db; randomness is then
+                               derived like --deterministic and only its consumer id is stored, and
+                               finality signatures read the randomness from the store instead of stdin
+                               and are refused when the height was already signed for a different block;
+                               signing commands refuse to sign without it
+  --allow-equivocation         TESTING ONLY: sign such conflicting blocks, or without --home, anyway (leaks the FP's secret key)
+
+HD derivation flags (generate-keypair, derive):
+  --mnemonic <words>           BIP39 mnemonic (prefer --mnemonic-file, argv is visible to other users)
+  --mnemonic-file <file|->     file holding the BIP39 mnemonic
+  --mnemonic-passphrase <p>    optional BIP39 passphrase
+  --path <path>                BIP32 path, i is replaced by the key index (default m/86'/1'/0'/0/i)
+
+Key flags (every command taking <private_key_hex>):
+  --key <name>                 use the named key of the keystore under --home and omit the <private_key_hex> argument
+  --passphrase-file <file>     file holding the keystore passphrase (default: $CRYPTO_OPS_PASSPHRASE, then a terminal prompt)
+  --fp-keyring-dir <dir>       take the named keys from a Cosmos SDK keyring instead, e.g. .testnets/eotsmanager (also keys subcommands)
+  --fp-keyring-backend <type>  backend of that keyring: test, file (passphrase as above) or os (default test)
+
+Daemon flags (run; defaults follow consumer-fpd.conf):
+  --num-pub-rand <n>                   randomness values per commitment (default 1000)
+  --timestamping-delay-blocks <n>      blocks between a commitment and the first height it covers (default 4)
+  --randomness-commit-interval <dur>   interval between each attempt to commit randomness (default 5s)
+  --l2-poll-interval <dur>             interval between each poll of the L2 tip (default 5s)
+  --start-height <h>                   first L2 height to sign (default: resume from the store)
+  --submission-retry-interval <dur>, --max-submission-retries <n>   retry policy of submissions (default 1s, 20)
+  --health-addr <addr>                 listen address of the /health endpoint (default 127.0.0.1:12590, empty disables)
+
+Demo flags (demo; defaults follow rollup-btc-staking-demo.sh):
+  --contract-wasm <file>               finality contract to deploy (default artifacts/contracts/op_finality_gadget.wasm)
+  --consumer-id <id>                   consumer to register (default consumer-id)
+  --bbn-fp-key, --consumer-fp-key <name>  keystore names of the new FP keys (default bbn-fp, consumer-fp; mnemonic index 0, 1)
+  --staker-rpc <url>                   JSON-RPC endpoint of stakerd (default http://localhost:15912)
+  --staking-amount <sat>, --staking-time <blocks>   the delegation (default 1000000, 10000)
+  --num-pub-rand <n>, --num-finality-sigs <n>, --start-height <h>   committed randomness and signed blocks (default 1000, 10, 1)
+  --step-timeout <step>=<dur>          timeout of a step, repeatable; steps: deploy-contract, register-consumer, generate-keys,
+                                       create-fps, stake-btc, wait-activation, commit-finalize
+  --require-activation                 fail when the delegation is not active by the wait-activation timeout (default: warn)
+  --state-file <file>                  state saved after each step (default <home>/demo-state.json)
+  --resume                             skip the steps of --state-file that completed and still hold on chain
+
+Scenario flags (scenario; see scenarios/ of the deployment for the format):
+  --contract-wasm <file>               finality contract deployed for every consumer (default artifacts/contracts/op_finality_gadget.wasm)
+  --staker-rpc <url>                   JSON-RPC endpoint of stakerd (default http://localhost:15912)
+  --vote-retries <n>, --vote-retry-interval <dur>   block voter queries made to find each vote (default 5, 5s)
+  --activation-poll-interval <dur>, --slashing-poll-interval <dur>   polling of delegations and slashed FPs (default 10s, 2s)
+  --concurrency <n>                    consumer FPs committing randomness and signing at once (default 16)
+
+Equivocation scenario flags (equivocation-scenario):
+  --slashing-timeout <dur>     maximum time to wait for Babylon to slash or jail the FP (default 1m)
+  --slashing-poll-interval <dur>  interval between each query of the FP on Babylon (default 2s)
+  --expect-slashed             exit with status 1 unless the FP ends up slashed or jailed (default true)
+
+Chain flags (commands that submit to or query Babylon):
+  --node <url>              CometBFT RPC endpoint (default http://localhost:26657)
+  --grpc <addr>             gRPC endpoint, e.g. localhost:9090 (queries use --node when empty)
+  --chain-id <id>           Babylon chain id (default chain-test)
+  --keyring-dir <dir>       keyring directory (default .testnets/node0/babylond)
+  --keyring-backend <type>  keyring backend (default test)
+  --from <name>             key that signs and pays for transactions (default test-spending-key)
+  --fee-payers <names>      comma-separated funded keys taking turns with --from paying for contract executions,
+                            so that concurrent commitments and signatures do not queue behind one account
+  --max-sequence-retries <n>  times a tx rejected for its account sequence is signed again with the expected one (default 5)
+  --gas, --gas-adjustment, --gas-prices, --fees, --timeout, --account-prefix
+  --wait-timeout <dur>      maximum time to wait for a tx to be included (default 1m)
+  --poll-interval <dur>     interval between tx queries while waiting (default 1s)
+  --websocket               wait through a websocket Tx event subscription instead of polling
   
 Examples:
-  %s generate-keypair
+  %s generate-keypair --home ./crypto-ops-home --key fp
   %s generate-pop abc123... bbn1...
   %s generate-pub-rand-commitment abc123... 1 100
-  echo '{...randListInfoJson...}' | %s generate-finality-sig abc123... 1
-  %s commit-pub-rand abc123... bbn1contract... 1 100
-  echo '{...randListInfoJson...}' | %s submit-finality-sig abc123... bbn1contract... 1
-  %s commit-and-finalize abc123... bbn1contract... 1 100
+  echo '{...randListInfoJson...}' | %s generate-finality-sig --allow-equivocation abc123... 1
+  %s generate-pub-rand-commitment --home ./crypto-ops-home --consumer-id consumer-id abc123... 1 100
+  %s generate-finality-sig --home ./crypto-ops-home abc123... 1
+  %s generate-finality-sig --home ./crypto-ops-home --l2-rpc http://localhost:8545 abc123... 42
+  %s generate-pub-rand-commitment --deterministic --consumer-id consumer-id abc123... 1 100
+  %s generate-finality-sig --home ./crypto-ops-home --deterministic --consumer-id consumer-id --commit-num-pub-rand 100 abc123... 1
+  %s verify-pop abc123... bbn1... 00abc...
+  %s verify-pop --file genesis.json
+  %s generate-finality-sig --allow-equivocation abc123... 1 < rand.json | %s verify-finality-sig --commitment <hex> --commit-start-height 1 --commit-num-pub-rand 100
+  %s commit-pub-rand --node http://localhost:26657 abc123... bbn1contract... 1 100
+  echo '{...randListInfoJson...}' | %s submit-finality-sig --allow-equivocation abc123... bbn1contract... 1
+  %s commit-and-finalize --home ./crypto-ops-home abc123... bbn1contract... 1 100
+  %s submit-finality-sig-batch --home ./crypto-ops-home --l2-rpc http://localhost:8545 abc123... bbn1contract... 101 600
+  %s equivocation-scenario --home ./crypto-ops-home abc123... bbn1contract... 5
+  %s keys add --home ./crypto-ops-home consumer-fp
+  %s keys list --fp-keyring-dir .testnets/eotsmanager/keyring-test
+  %s derive --mnemonic-file mnemonic.txt --count 3
+  %s generate-finality-sig --home ./crypto-ops-home --signer eotsd://localhost:15813 --fp-pk abc123... --consumer-id consumer-id --commit-num-pub-rand 100 1
+  %s submit-finality-sig --home ./crypto-ops-home --key consumer-fp bbn1contract... 1
+  %s demo --home .testnets/crypto-ops --passphrase-file .testnets/crypto-ops-passphrase --step-timeout wait-activation=10m
+  %s demo --home .testnets/crypto-ops --passphrase-file .testnets/crypto-ops-passphrase --resume
+  %s generate-scenario --name voting-power --consumers 3 --fps 12 --to-height 20 > voting-power.yaml
+  %s fund-fee-payers --count 8
+  %s scenario --home .testnets/scenario-missed-heights --passphrase-file .testnets/crypto-ops-passphrase scenarios/missed-heights.yaml
   
 Output: All commands output JSON that can be parsed by bash scripts
   
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 }
 
 func main() {
@@ -615,6 +744,74 @@ func main() {
 
 	switch command {
 	case "generate-keypair":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		home := addHomeFlag(fs)
+		keySource := addKeyFlags(fs)
+		hdSource := addMnemonicFlags(fs)
+		index := fs.Uint("index", 0, "index of the key, replacing i in --path")
+		printPrivateKey := fs.Bool("insecure-print-private-key", false, "print the unencrypted private key instead of storing it with --key")
+		yes := fs.Bool("yes", false, "print the private key without the interactive confirmation")
+		parseArgs(fs, os.Args[2:])
+
+		// Without --key the key is only printed, which leaves it in the
+		// terminal and shell history, so it must be asked for explicitly
+		if keySource.Name == "" {
+			if !*printPrivateKey && !hdSource.enabled() {
+				log.Fatalf("generate-keypair stores the key with --key <name>; pass --insecure-print-private-key to print it instead")
+			}
+			if *printPrivateKey && !*yes {
+				if err := confirmPrivateKeyPrint("the generated key", "print"); err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
+		} else if *printPrivateKey {
+			log.Fatalf("--insecure-print-private-key cannot be used with --key, use keys export")
+		}
+
+		var fpSk *btcec.PrivateKey
+		var derived *DerivedKey
+		if hdSource.enabled() {
+			mnemonic, err := hdSource.read()
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			fpSk, derived, err = deriveKey(hdSource, mnemonic, uint32(*index))
+			if err != nil {
+				log.Fatalf("Failed to derive BTC key: %v", err)
+			}
+		}
+
+		// With --key, the key goes to the keystore and only its public key
+		// is printed
+		if keySource.Name != "" {
+			keys, err := openFpKeys(keySource, *home)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			info, err := addKey(keys, keySource.Name, fpSk)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			if derived != nil {
+				derived.Name = info.Name
+				derived.PrivateKey = ""
+				printJSON(derived)
+			} else {
+				printJSON(info)
+			}
+			return
+		}
+
+		// A derived key is printed without its private key unless asked,
+		// the mnemonic derives it again
+		if derived != nil {
+			if !*printPrivateKey {
+				derived.PrivateKey = ""
+			}
+			printJSON(derived)
+			return
+		}
+
 		// Generate random BTC key pair exactly like the tests do
 		fpSk, _, err := datagen.GenRandomBTCKeyPair(r)
 		if err != nil {
@@ -639,23 +836,33 @@ func main() {
 
 		fmt.Println(string(jsonOutput))
 
+	case "generate-mnemonic":
+		mnemonic, err := hdkey.NewMnemonic()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		printJSON(map[string]string{"mnemonic": mnemonic})
+
+	case "derive":
+		runDerive(os.Args[2:])
+
 	case "generate-pop":
-		if len(os.Args) < 4 {
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+1 {
 			fmt.Println("Error: Missing arguments for generate-pop")
 			printUsage()
 			os.Exit(1)
 		}
 
-		privKeyHex := os.Args[2]
-		babylonAddr := os.Args[3]
-
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		fpSigner, args, err := loadSigner(keySource, *home, args)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
-
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		defer closeSigner(fpSigner)
+		babylonAddr := args[0]
 
 		// Parse the Babylon address
 		addr, err := sdk.AccAddressFromBech32(babylonAddr)
@@ -663,7 +870,7 @@ func main() {
 			log.Fatalf("Invalid Babylon address: %v", err)
 		}
 
-		pop, err := generateProofOfPossession(addr, fpSk)
+		pop, err := generateProofOfPossession(addr, fpSigner)
 		if err != nil {
 			log.Fatalf("Failed to generate proof of possession: %v", err)
 		}
@@ -676,23 +883,32 @@ func main() {
 		fmt.Println(string(jsonOutput))
 
 	case "generate-pub-rand-commitment":
-		if len(os.Args) < 5 {
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		deterministic := fs.Bool("deterministic", false, "derive the randomness from the FP key and --consumer-id instead of math/rand (implied by --home)")
+		consumerID := fs.String("consumer-id", "", "consumer/chain id mixed into deterministic randomness")
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+2 {
 			fmt.Println("Error: Missing arguments for generate-pub-rand-commitment")
 			printUsage()
 			os.Exit(1)
 		}
 
-		privKeyHex := os.Args[2]
-		startHeightStr := os.Args[3]
-		numPubRandStr := os.Args[4]
-
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		fpSigner, args, err := loadSigner(keySource, *home, args)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
+		defer closeSigner(fpSigner)
 
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		// Signers deriving their own randomness always derive it like
+		// --deterministic, and so does every signer with a store, which
+		// keeps the consumer id rather than the secret randomness
+		if _, ok := fpSigner.(signer.SecretRandSigner); !ok || *home != "" {
+			*deterministic = true
+		}
+		startHeightStr := args[0]
+		numPubRandStr := args[1]
 
 		// Parse start height and num pub rand
 		startHeight, err := strconv.ParseUint(startHeightStr, 10, 64)
@@ -705,26 +921,65 @@ func main() {
 			log.Fatalf("Invalid num pub rand: %v", err)
 		}
 
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
+		}
+
 		// Generate crypto data only
-		randListInfo, bip340PK, signature, err := generatePublicRandomnessCommitment(r, fpSk, startHeight, numPubRand)
+		var (
+			randCommit *RandCommit
+			signature  []byte
+		)
+		if *deterministic {
+			// The secret randomness stays with the signer, which derives
+			// it again when signing
+			randCommit, signature, err = generateDeterministicPubRandCommitment(fpSigner, *consumerID, startHeight, numPubRand)
+		} else {
+			randCommit = &RandCommit{StartHeight: startHeight}
+			randCommit.RandListInfo, _, signature, err = generatePublicRandomnessCommitment(r, fpSigner, startHeight, numPubRand)
+		}
 		if err != nil {
 			log.Fatalf("Failed to generate public randomness commitment: %v", err)
 		}
+		randListInfo := randCommit.RandListInfo
+		bip340PK := fpSigner.PublicKey()
 
-		// Convert to serializable format
-		serializable, err := ConvertToSerializable(randListInfo)
-		if err != nil {
-			log.Fatalf("Failed to convert randListInfo to serializable: %v", err)
+		if st != nil {
+			if err := saveRandCommit(st, bip340PK, randCommit); err != nil {
+				log.Fatalf("%v", err)
+			}
 		}
 
 		// Create output with all data needed for bash submission
+		contractMsg := &opfinality.ExecuteMsg{
+			CommitPublicRandomness: &opfinality.CommitPublicRandomness{
+				FpPubkeyHex: bip340PK.MarshalHex(),
+				StartHeight: startHeight,
+				NumPubRand:  numPubRand,
+				Commitment:  randListInfo.Commitment,
+				Signature:   signature,
+			},
+		}
 		output := map[string]interface{}{
-			"rand_list_info": serializable,
-			"fp_pubkey_hex":  bip340PK.MarshalHex(),
-			"start_height":   startHeight,
-			"num_pub_rand":   numPubRand,
-			"commitment":     randListInfo.Commitment,
-			"signature":      signature,
+			"fp_pubkey_hex":    bip340PK.MarshalHex(),
+			"start_height":     startHeight,
+			"num_pub_rand":     numPubRand,
+			"commitment":       randListInfo.Commitment,
+			"signature":        signature,
+			"contract_message": contractMsg, // Ready-to-submit execute message
+		}
+
+		// Deterministic randomness is regenerated on demand and never leaves the tool
+		if !*deterministic {
+			serializable, err := ConvertToSerializable(randListInfo, startHeight)
+			if err != nil {
+				log.Fatalf("Failed to convert randListInfo to serializable: %v", err)
+			}
+			output["rand_list_info"] = serializable
 		}
 
 		jsonOutput, err := json.Marshal(output)
@@ -735,22 +990,26 @@ func main() {
 		fmt.Println(string(jsonOutput))
 
 	case "generate-finality-sig":
-		if len(os.Args) < 4 {
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		randSource := addRandFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		allowEquivocation := addAllowEquivocationFlag(fs)
+		blockSource := addBlockFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+1 {
 			fmt.Println("Error: Missing arguments for generate-finality-sig")
 			printUsage()
 			os.Exit(1)
 		}
 
-		privKeyHex := os.Args[2]
-		blockHeightStr := os.Args[3]
-
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		fpSigner, args, err := loadSigner(keySource, *home, args)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
-
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		defer closeSigner(fpSigner)
+		fpPK := fpSigner.PublicKey()
+		blockHeightStr := args[0]
 
 		// Parse block height
 		blockHeight, err := strconv.ParseUint(blockHeightStr, 10, 64)
@@ -758,48 +1017,67 @@ func main() {
 			log.Fatalf("Invalid block height: %v", err)
 		}
 
-		// Generate random block hash internally (like submitFinalitySignature does)
-		blockHash := datagen.GenRandomByteArray(r, 32)
-
-		// Read randListInfo from stdin instead of command line to avoid "Argument list too long"
-		fmt.Fprintln(os.Stderr, "  → Reading randomness data from stdin...")
-		stdinBytes, err := io.ReadAll(os.Stdin)
+		st, err := openStore(*home)
 		if err != nil {
-			log.Fatalf("Failed to read from stdin: %v", err)
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
 		}
 
-		// Parse randListInfo
-		var serializable SerializableRandListInfo
-		if err := json.Unmarshal(stdinBytes, &serializable); err != nil {
-			log.Fatalf("Failed to parse randListInfo: %v", err)
+		// Sign the given or fetched block, or a random mock block (like submitFinalitySignature does)
+		blockHash, err := resolveBlockHash(blockSource, blockHeight)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if blockHash == nil {
+			blockHash, err = mockBlockHash(st, r, fpPK, blockHeight, *allowEquivocation)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+		if err := checkSlashingProtection(st, fpPK, blockHeight, blockHash, *allowEquivocation); err != nil {
+			log.Fatalf("%v", err)
 		}
 
-		randListInfo, err := ConvertFromSerializable(&serializable)
+		randCommits, err := resolveRandCommits(randSource, st, fpSigner, blockHeight)
 		if err != nil {
-			log.Fatalf("Failed to convert serializable to randListInfo: %v", err)
+			log.Fatalf("%v", err)
 		}
 
 		// Generate finality signature (crypto only)
-		bip340PK, publicRandomness, signature, proof, err := generateFinalitySignature(r, randListInfo, fpSk, blockHeight, blockHash)
+		bip340PK := fpPK
+		publicRandomness, signature, proof, err := generateFinalitySignature(randCommits, fpSigner, blockHeight, blockHash)
 		if err != nil {
 			log.Fatalf("Failed to generate finality signature: %v", err)
 		}
 
+		if st != nil {
+			if err := recordSignature(st, bip340PK, blockHeight, blockHash, signature, *allowEquivocation); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
 		// Create output with all data needed for bash submission
-		protoProof := proof.ToProto()
-		output := map[string]interface{}{
-			"fp_pubkey_hex": bip340PK.MarshalHex(),
-			"height":        blockHeight,
-			"pub_rand":      publicRandomness,
-			"proof": map[string]interface{}{
-				"total":     uint64(protoProof.Total),
-				"index":     uint64(protoProof.Index),
-				"leaf_hash": protoProof.LeafHash,
-				"aunts":     protoProof.Aunts,
+		contractMsg := &opfinality.ExecuteMsg{
+			SubmitFinalitySignature: &opfinality.SubmitFinalitySignature{
+				FpPubkeyHex: bip340PK.MarshalHex(),
+				Height:      blockHeight,
+				PubRand:     publicRandomness,
+				Proof:       opfinality.NewProof(proof),
+				BlockHash:   blockHash,
+				Signature:   signature,
 			},
-			"block_hash":     blockHash,                     // Byte array for contract submission
-			"block_hash_hex": hex.EncodeToString(blockHash), // Hex string for verification query
-			"signature":      signature,
+		}
+		output := map[string]interface{}{
+			"fp_pubkey_hex":    bip340PK.MarshalHex(),
+			"height":           blockHeight,
+			"pub_rand":         publicRandomness,
+			"proof":            contractMsg.SubmitFinalitySignature.Proof,
+			"block_hash":       blockHash,                     // Byte array for contract submission
+			"block_hash_hex":   hex.EncodeToString(blockHash), // Hex string for verification query
+			"signature":        signature,
+			"contract_message": contractMsg, // Ready-to-submit execute message
 		}
 
 		jsonOutput, err := json.Marshal(output)
@@ -809,25 +1087,331 @@ func main() {
 
 		fmt.Println(string(jsonOutput))
 
-	case "commit-pub-rand":
-		if len(os.Args) < 6 {
-			fmt.Println("Error: Missing arguments for commit-pub-rand")
+	case "verify-finality-sig":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		commitmentArg := fs.String("commitment", "", "on-chain commitment covering the height (hex, or base64 as printed by the contract)")
+		commitStartHeight := fs.Uint64("commit-start-height", 0, "start height of the commitment")
+		commitNumPubRand := fs.Uint64("commit-num-pub-rand", 0, "number of randomness values in the commitment")
+		args := parseArgs(fs, os.Args[2:])
+		if *commitmentArg == "" || *commitStartHeight == 0 || *commitNumPubRand == 0 {
+			fmt.Println("Error: --commitment, --commit-start-height and --commit-num-pub-rand are required for verify-finality-sig")
 			printUsage()
 			os.Exit(1)
 		}
 
-		privKeyHex := os.Args[2]
-		contractAddr := os.Args[3]
-		startHeightStr := os.Args[4]
-		numPubRandStr := os.Args[5]
+		commitment, err := decodeBytesArg(*commitmentArg)
+		if err != nil {
+			log.Fatalf("Invalid commitment: %v", err)
+		}
 
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		// The signature is read from a file or, by default, stdin
+		var input io.Reader = os.Stdin
+		if len(args) > 0 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				log.Fatalf("Failed to open finality signature: %v", err)
+			}
+			defer f.Close()
+			input = f
+		}
+		finalitySig, err := readFinalitySignature(input)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
 
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		err = opfinality.VerifyFinalitySignature(finalitySig, &opfinality.PubRandCommit{
+			StartHeight: *commitStartHeight,
+			NumPubRand:  *commitNumPubRand,
+			Commitment:  commitment,
+		})
+		result := newFinalitySigVerificationResult(err)
+
+		jsonOutput, jsonErr := json.Marshal(result)
+		if jsonErr != nil {
+			log.Fatalf("Failed to marshal output: %v", jsonErr)
+		}
+
+		fmt.Println(string(jsonOutput))
+		if !result.Valid {
+			fmt.Fprintf(os.Stderr, "  ❌ Finality signature for height %d is invalid: %s\n", finalitySig.Height, result.Reason)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "  ✅ Finality signature for height %d is valid\n", finalitySig.Height)
+
+	case "verify-pub-rand-commitment":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		fpPubkeyHex := fs.String("fp-pk", "", "BIP340 public key hex of the FP (defaults to fp_pubkey_hex of the input)")
+		args := parseArgs(fs, os.Args[2:])
+
+		// The commitment is read from a file or, by default, stdin
+		var input io.Reader = os.Stdin
+		if len(args) > 0 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				log.Fatalf("Failed to open commitment: %v", err)
+			}
+			defer f.Close()
+			input = f
+		}
+
+		var result *VerificationResult
+		commitMsg, prList, err := readPubRandCommitment(input)
+		switch {
+		case errors.Is(err, opfinality.ErrCommitmentMismatch), errors.Is(err, opfinality.ErrInvalidPubRand):
+			result = &VerificationResult{Failure: failureName(err, pubRandCommitmentFailures), Reason: err.Error()}
+		case err != nil:
+			log.Fatalf("%v", err)
+		default:
+			if *fpPubkeyHex == "" {
+				*fpPubkeyHex = commitMsg.FpPubkeyHex
+			}
+			if *fpPubkeyHex == "" {
+				log.Fatalf("--fp-pk is required when the input has no fp_pubkey_hex")
+			}
+			if prList == nil {
+				fmt.Fprintln(os.Stderr, "  ⚠️  No pr_list_hex in the input, only checking the signature")
+			}
+			result = verifyPubRandCommitment(commitMsg, *fpPubkeyHex, prList)
+		}
+
+		jsonOutput, err := json.Marshal(result)
+		if err != nil {
+			log.Fatalf("Failed to marshal output: %v", err)
+		}
+
+		fmt.Println(string(jsonOutput))
+		if !result.Valid {
+			fmt.Fprintf(os.Stderr, "  ❌ Randomness commitment is invalid: %s\n", result.Reason)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "  ✅ Randomness commitment for heights %d to %d is valid\n",
+			commitMsg.StartHeight, commitMsg.StartHeight+commitMsg.NumPubRand-1)
+
+	case "verify-pop":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		file := fs.String("file", "", "JSON file (or - for stdin) of FP registrations to verify instead of a single PoP")
+		network := fs.String("btc-network", "regtest", "BTC network of BIP322 and ECDSA proofs (mainnet, testnet, signet, regtest, simnet)")
+		args := parseArgs(fs, os.Args[2:])
+		if (*file == "" && len(args) < 3) || (*file != "" && len(args) > 0) {
+			fmt.Println("Error: verify-pop takes either <btc_pk_hex> <bbn_addr> <pop_hex> or --file")
+			printUsage()
+			os.Exit(1)
+		}
+
+		netParams, err := btcNetParams(*network)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		if *file == "" {
+			result := newPoPVerificationResult(verifyPoP(args[0], args[1], args[2], netParams))
+			printJSON(result)
+			if !result.Valid {
+				fmt.Fprintf(os.Stderr, "  ❌ Proof of possession is invalid: %s\n", result.Reason)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "  ✅ Proof of possession of %s by %s is valid\n", args[0], args[1])
+			return
+		}
+
+		var input io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				log.Fatalf("Failed to open registrations: %v", err)
+			}
+			defer f.Close()
+			input = f
+		}
+		entries, err := readFPRegistrations(input)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		batch := verifyPoPBatch(entries, netParams)
+		printJSON(batch)
+		for _, result := range batch.Results {
+			if !result.Valid {
+				fmt.Fprintf(os.Stderr, "  ❌ Registration %d (%s): %s\n", result.Index, result.BtcPkHex, result.Reason)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  → %d of %d registrations are valid\n", batch.Valid, batch.Total)
+		if batch.Invalid > 0 {
+			os.Exit(1)
+		}
+
+	case "extract-sk":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		network := fs.String("btc-network", "regtest", "BTC network of the WIF output (mainnet, testnet, signet, regtest, simnet)")
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < 2 {
+			fmt.Println("Error: Missing arguments for extract-sk")
+			printUsage()
+			os.Exit(1)
+		}
+		if args[0] == "-" && args[1] == "-" {
+			log.Fatalf("only one of the signatures can be read from stdin")
+		}
+
+		var sigs []*opfinality.SubmitFinalitySignature
+		for _, arg := range args[:2] {
+			var input io.Reader = os.Stdin
+			if arg != "-" {
+				f, err := os.Open(arg)
+				if err != nil {
+					log.Fatalf("Failed to open finality signature: %v", err)
+				}
+				defer f.Close()
+				input = f
+			}
+			sig, err := readFinalitySignature(input)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			sigs = append(sigs, sig)
+		}
+
+		sk, err := extractSecretKey(sigs[0], sigs[1])
+		if err != nil {
+			log.Fatalf("Failed to extract secret key: %v", err)
+		}
+		extracted, err := newExtractedKey(sk, sigs[0].Height, *network)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		jsonOutput, err := json.Marshal(extracted)
+		if err != nil {
+			log.Fatalf("Failed to marshal output: %v", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "  ✅ Recovered the secret key of FP %s from its equivocation at height %d\n", extracted.FpPubkeyHex, extracted.Height)
+		fmt.Println(string(jsonOutput))
+
+	case "wait-tx":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < 1 {
+			fmt.Println("Error: Missing arguments for wait-tx")
+			printUsage()
+			os.Exit(1)
+		}
+
+		// Waiting needs no signing key
+		chainCfg.Key = ""
+		bbnClient, err := bbnclient.New(*chainCfg)
+		if err != nil {
+			log.Fatalf("Failed to create Babylon client: %v", err)
+		}
+		defer bbnClient.Close()
+
+		res, err := bbnClient.WaitForTx(context.Background(), args[0])
+		if res == nil {
+			log.Fatalf("Failed to wait for tx: %v", err)
+		}
+
+		jsonOutput, jsonErr := json.Marshal(bbnclient.NewTxResult(res))
+		if jsonErr != nil {
+			log.Fatalf("Failed to marshal output: %v", jsonErr)
+		}
+
+		fmt.Println(string(jsonOutput))
+		if err != nil {
+			// The tx was included but failed; the result above has the details
+			os.Exit(1)
+		}
+
+	case "export-signing-history":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		home := addHomeFlag(fs)
+		fpPubkeyHex := fs.String("fp-pk", "", "only export the history of this FP (BIP340 public key hex)")
+		parseArgs(fs, os.Args[2:])
+		if *home == "" {
+			log.Fatalf("--home is required for export-signing-history")
+		}
+
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer st.Close()
+
+		history, err := exportSigningHistory(st, *fpPubkeyHex)
+		if err != nil {
+			log.Fatalf("Failed to export signing history: %v", err)
+		}
+
+		jsonOutput, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal output: %v", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "  ✅ Exported %d signature records\n", len(history.Records))
+		fmt.Println(string(jsonOutput))
+
+	case "import-signing-history":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		home := addHomeFlag(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < 1 {
+			fmt.Println("Error: Missing arguments for import-signing-history")
+			printUsage()
+			os.Exit(1)
+		}
+		if *home == "" {
+			log.Fatalf("--home is required for import-signing-history")
+		}
+
+		var historyBytes []byte
+		var err error
+		if args[0] == "-" {
+			historyBytes, err = io.ReadAll(os.Stdin)
+		} else {
+			historyBytes, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			log.Fatalf("Failed to read signing history: %v", err)
+		}
+
+		var history SigningHistory
+		if err := json.Unmarshal(historyBytes, &history); err != nil {
+			log.Fatalf("Failed to parse signing history: %v", err)
+		}
+
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer st.Close()
+
+		imported, err := importSigningHistory(st, &history)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		fmt.Printf(`{"imported": %d, "skipped": %d}`+"\n", imported, len(history.Records)-imported)
+
+	case "commit-pub-rand":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+3 {
+			fmt.Println("Error: Missing arguments for commit-pub-rand")
+			printUsage()
+			os.Exit(1)
+		}
+
+		fpSigner, args, err := loadSigner(keySource, *home, args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer closeSigner(fpSigner)
+		contractAddr := args[0]
+		startHeightStr := args[1]
+		numPubRandStr := args[2]
 
 		// Parse start height and num pub rand
 		startHeight, err := strconv.ParseUint(startHeightStr, 10, 64)
@@ -840,12 +1424,27 @@ func main() {
 			log.Fatalf("Invalid num pub rand: %v", err)
 		}
 
-		randListInfo, err := commitPublicRandomness(r, contractAddr, fpSk, startHeight, numPubRand)
+		bbnClient, err := bbnclient.New(*chainCfg)
+		if err != nil {
+			log.Fatalf("Failed to create Babylon client: %v", err)
+		}
+		defer bbnClient.Close()
+
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
+		}
+
+		finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+		randCommit, err := commitPublicRandomness(finalityContract, st, r, fpSigner, startHeight, numPubRand)
 		if err != nil {
 			log.Fatalf("Failed to generate public randomness commitment: %v", err)
 		}
 
-		serializable, err := ConvertToSerializable(randListInfo)
+		serializable, err := ConvertToSerializable(randCommit.RandListInfo, startHeight)
 		if err != nil {
 			log.Fatalf("Failed to convert randListInfo to serializable: %v", err)
 		}
@@ -858,23 +1457,27 @@ func main() {
 		fmt.Println(string(jsonOutput))
 
 	case "submit-finality-sig":
-		if len(os.Args) < 5 {
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		randSource := addRandFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		allowEquivocation := addAllowEquivocationFlag(fs)
+		blockSource := addBlockFlags(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+2 {
 			fmt.Println("Error: Missing arguments for submit-finality-sig")
 			printUsage()
 			os.Exit(1)
 		}
 
-		privKeyHex := os.Args[2]
-		contractAddr := os.Args[3]
-		blockHeightStr := os.Args[4]
-
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		fpSigner, args, err := loadSigner(keySource, *home, args)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
-
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		defer closeSigner(fpSigner)
+		contractAddr := args[0]
+		blockHeightStr := args[1]
 
 		// Parse block height
 		blockHeight, err := strconv.ParseUint(blockHeightStr, 10, 64)
@@ -882,50 +1485,245 @@ func main() {
 			log.Fatalf("Invalid block height: %v", err)
 		}
 
-		// Read randListInfo from stdin instead of command line to avoid "Argument list too long"
-		fmt.Fprintln(os.Stderr, "  → Reading randomness data from stdin...")
-		stdinBytes, err := io.ReadAll(os.Stdin)
+		st, err := openStore(*home)
 		if err != nil {
-			log.Fatalf("Failed to read from stdin: %v", err)
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
 		}
 
-		// Parse randListInfo
-		var serializable SerializableRandListInfo
-		if err := json.Unmarshal(stdinBytes, &serializable); err != nil {
-			log.Fatalf("Failed to parse randListInfo: %v", err)
+		randCommits, err := resolveRandCommits(randSource, st, fpSigner, blockHeight)
+		if err != nil {
+			log.Fatalf("%v", err)
 		}
 
-		randListInfo, err := ConvertFromSerializable(&serializable)
+		bbnClient, err := bbnclient.New(*chainCfg)
 		if err != nil {
-			log.Fatalf("Failed to convert serializable to randListInfo: %v", err)
+			log.Fatalf("Failed to create Babylon client: %v", err)
 		}
+		defer bbnClient.Close()
 
-		err = submitFinalitySignature(r, contractAddr, randListInfo, fpSk, blockHeight)
+		finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+		blockHash, err := resolveBlockHash(blockSource, blockHeight)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		err = submitFinalitySignature(finalityContract, st, *allowEquivocation, r, randCommits, fpSigner, blockHeight, blockHash)
 		if err != nil {
 			log.Fatalf("Failed to submit finality signature: %v", err)
 		}
 
 		// Success - no output needed, bash will detect success via exit code
 
-	case "commit-and-finalize":
-		if len(os.Args) < 6 {
-			fmt.Println("Error: Missing arguments for commit-and-finalize")
+	case "submit-finality-sig-batch":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		randSource := addRandFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		allowEquivocation := addAllowEquivocationFlag(fs)
+		blockSource := addBlockFlags(fs)
+		blocksFile := fs.String("blocks", "", "JSON file (or - for stdin) listing the blocks to sign as [{\"height\": h, \"block_hash_hex\": \"...\"}]")
+		batchSize := fs.Int("batch-size", defaultBatchSize, "maximum number of finality signatures per transaction")
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+1 || (*blocksFile == "" && len(args) < numKeyArgs(keySource)+3) {
+			fmt.Println("Error: Missing arguments for submit-finality-sig-batch")
 			printUsage()
 			os.Exit(1)
 		}
+		if *batchSize <= 0 {
+			log.Fatalf("--batch-size must be positive")
+		}
+
+		fpSigner, args, err := loadSigner(keySource, *home, args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer closeSigner(fpSigner)
+		contractAddr := args[0]
+
+		var blocks []batchBlock
+		if *blocksFile != "" {
+			if len(args) > 1 {
+				log.Fatalf("--blocks and a height range are mutually exclusive")
+			}
+			if blockSource.BlockHashHex != "" || blockSource.L2RPCAddr != "" {
+				log.Fatalf("--blocks already lists the block hashes, --block-hash and --l2-rpc cannot be used with it")
+			}
+			if *blocksFile == "-" {
+				if *home == "" {
+					log.Fatalf("--home is required with --blocks -, stdin cannot hold both the blocks and the randomness")
+				}
+				blocks, err = readBatchBlocks(os.Stdin)
+			} else {
+				var f *os.File
+				f, err = os.Open(*blocksFile)
+				if err != nil {
+					log.Fatalf("Failed to open blocks file: %v", err)
+				}
+				blocks, err = readBatchBlocks(f)
+				f.Close()
+			}
+		} else {
+			var fromHeight, toHeight uint64
+			fromHeight, err = strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid from height: %v", err)
+			}
+			toHeight, err = strconv.ParseUint(args[2], 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid to height: %v", err)
+			}
+			blocks, err = rangeBatchBlocks(blockSource, fromHeight, toHeight)
+		}
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
+		}
+
+		// Stored commitments are loaded on demand while signing, the others
+		// are derived or read once for every block (the height only selects
+		// a stored commitment)
+		var randCommits []*RandCommit
+		if st == nil || randSource.Deterministic {
+			randCommits, err = resolveRandCommits(randSource, st, fpSigner, 0)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		// A fixed gas limit and fee sized for one message cannot pay for a
+		// whole batch, so simulate and price the gas unless they were given
+		setFlags := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+		if !setFlags["gas"] {
+			chainCfg.Gas = 0
+		}
+		if !setFlags["fees"] {
+			chainCfg.Fees = ""
+		}
+
+		bbnClient, err := bbnclient.New(*chainCfg)
+		if err != nil {
+			log.Fatalf("Failed to create Babylon client: %v", err)
+		}
+		defer bbnClient.Close()
+
+		msgs, results := signFinalityBatch(st, *allowEquivocation, r, randCommits, fpSigner, blocks)
+
+		finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+		submission := submitFinalitySignatureBatch(finalityContract, msgs, results, *batchSize)
+
+		jsonOutput, err := json.Marshal(submission)
+		if err != nil {
+			log.Fatalf("Failed to marshal output: %v", err)
+		}
+
+		fmt.Println(string(jsonOutput))
+		fmt.Fprintf(os.Stderr, "  → Submitted %d finality signatures in %d transactions, %d failed\n",
+			submission.Submitted, len(submission.Transactions), submission.Failed)
+		if submission.Failed > 0 {
+			os.Exit(1)
+		}
+
+	case "equivocation-scenario":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		randSource := addRandFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		slashingTimeout := fs.Duration("slashing-timeout", time.Minute, "maximum time to wait for Babylon to slash or jail the FP")
+		pollInterval := fs.Duration("slashing-poll-interval", 2*time.Second, "interval between each query of the FP on Babylon")
+		expectSlashed := fs.Bool("expect-slashed", true, "exit with status 1 unless the FP ends up slashed or jailed")
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+2 {
+			fmt.Println("Error: Missing arguments for equivocation-scenario")
+			printUsage()
+			os.Exit(1)
+		}
+
+		fpSigner, args, err := loadSigner(keySource, *home, args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer closeSigner(fpSigner)
+		contractAddr := args[0]
+
+		blockHeight, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid block height: %v", err)
+		}
 
-		privKeyHex := os.Args[2]
-		contractAddr := os.Args[3]
-		startHeightStr := os.Args[4]
-		numPubRandStr := os.Args[5]
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
+		}
 
-		// Parse the private key
-		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		randCommits, err := resolveRandCommits(randSource, st, fpSigner, blockHeight)
 		if err != nil {
-			log.Fatalf("Invalid private key hex: %v", err)
+			log.Fatalf("%v", err)
 		}
 
-		fpSk, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+		bbnClient, err := bbnclient.New(*chainCfg)
+		if err != nil {
+			log.Fatalf("Failed to create Babylon client: %v", err)
+		}
+		defer bbnClient.Close()
+
+		finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+		report, err := runEquivocationScenario(bbnClient, finalityContract, st, r, randCommits, fpSigner, blockHeight, *slashingTimeout, *pollInterval)
+		if report != nil {
+			jsonOutput, err := json.Marshal(report)
+			if err != nil {
+				log.Fatalf("Failed to marshal output: %v", err)
+			}
+			fmt.Println(string(jsonOutput))
+		}
+		if err != nil {
+			log.Fatalf("Equivocation scenario failed: %v", err)
+		}
+		if *expectSlashed && !report.Slashed && !report.Jailed {
+			fmt.Fprintln(os.Stderr, "❌ The FP was expected to be slashed")
+			os.Exit(1)
+		}
+
+	case "commit-and-finalize":
+		fs := flag.NewFlagSet(command, flag.ExitOnError)
+		chainCfg := addChainFlags(fs)
+		home := addHomeFlag(fs)
+		keySource := addSignerFlags(fs)
+		allowEquivocation := addAllowEquivocationFlag(fs)
+		args := parseArgs(fs, os.Args[2:])
+		if len(args) < numKeyArgs(keySource)+3 {
+			fmt.Println("Error: Missing arguments for commit-and-finalize")
+			printUsage()
+			os.Exit(1)
+		}
+		if *home == "" && !*allowEquivocation {
+			// Fail before committing rather than when signing
+			log.Fatalf("--home is required for commit-and-finalize unless --allow-equivocation is set")
+		}
+
+		fpSigner, args, err := loadSigner(keySource, *home, args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer closeSigner(fpSigner)
+		contractAddr := args[0]
+		startHeightStr := args[1]
+		numPubRandStr := args[2]
 
 		// Parse start height and num pub rand
 		startHeight, err := strconv.ParseUint(startHeightStr, 10, 64)
@@ -938,18 +1736,52 @@ func main() {
 			log.Fatalf("Invalid num pub rand: %v", err)
 		}
 
-		randListInfo, err := commitPublicRandomness(r, contractAddr, fpSk, startHeight, numPubRand)
+		bbnClient, err := bbnclient.New(*chainCfg)
+		if err != nil {
+			log.Fatalf("Failed to create Babylon client: %v", err)
+		}
+		defer bbnClient.Close()
+
+		st, err := openStore(*home)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if st != nil {
+			defer st.Close()
+		}
+
+		finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+		randCommit, err := commitPublicRandomness(finalityContract, st, r, fpSigner, startHeight, numPubRand)
 		if err != nil {
 			log.Fatalf("Failed to generate public randomness commitment: %v", err)
 		}
 
-		err = submitFinalitySignature(r, contractAddr, randListInfo, fpSk, startHeight)
+		randCommits := []*RandCommit{randCommit}
+		err = submitFinalitySignature(finalityContract, st, *allowEquivocation, r, randCommits, fpSigner, startHeight, nil)
 		if err != nil {
 			log.Fatalf("Failed to submit finality signature: %v", err)
 		}
 
 		fmt.Println(`{"result": "Public randomness committed and finality signature submitted successfully"}`)
 
+	case "run":
+		runDaemon(os.Args[2:])
+
+	case "demo":
+		runDemo(os.Args[2:])
+
+	case "scenario":
+		runScenario(os.Args[2:])
+
+	case "generate-scenario":
+		runGenerateScenario(os.Args[2:])
+
+	case "fund-fee-payers":
+		runFundFeePayers(os.Args[2:])
+
+	case "keys":
+		runKeys(os.Args[2:])
+
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	"github.com/btcsuite/btcd/chaincfg"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Reasons a proof of possession is rejected
+var (
+	errInvalidBtcPk   = errors.New("invalid BTC public key")
+	errInvalidAddress = errors.New("invalid Babylon address")
+	errInvalidPoP     = errors.New("invalid proof of possession")
+	errBadPoP         = errors.New("proof of possession does not verify")
+	errDuplicateBtcPk = errors.New("BTC public key registered twice")
+)
+
+// popFailures names the reasons of verifyPoP
+var popFailures = []failureReason{
+	{errInvalidBtcPk, "invalid_btc_pk"},
+	{errInvalidAddress, "invalid_address"},
+	{errInvalidPoP, "invalid_pop"},
+	{errBadPoP, "bad_signature"},
+	{errDuplicateBtcPk, "duplicate_btc_pk"},
+}
+
+// FPRegistration is a finality provider registration whose proof of
+// possession is checked by verify-pop
+type FPRegistration struct {
+	BtcPkHex string `json:"btc_pk_hex"`
+	BbnAddr  string `json:"bbn_addr"`
+	PopHex   string `json:"pop_hex"`
+}
+
+// UnmarshalJSON accepts the fields of verify-pop and generate-pop
+// (btc_pk_hex, bbn_addr, pop_hex) as well as those of a finality provider
+// in the btcstaking genesis or a create-finality-provider message (btc_pk,
+// addr, pop as {btc_sig_type, btc_sig})
+func (r *FPRegistration) UnmarshalJSON(data []byte) error {
+	var input struct {
+		BtcPkHex string          `json:"btc_pk_hex"`
+		BtcPk    string          `json:"btc_pk"`
+		BbnAddr  string          `json:"bbn_addr"`
+		Addr     string          `json:"addr"`
+		PopHex   string          `json:"pop_hex"`
+		Pop      json.RawMessage `json:"pop"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	r.BtcPkHex = firstNonEmpty(input.BtcPkHex, input.BtcPk)
+	r.BbnAddr = firstNonEmpty(input.BbnAddr, input.Addr)
+	r.PopHex = input.PopHex
+	if r.PopHex == "" && len(input.Pop) > 0 && !bytes.Equal(input.Pop, []byte("null")) {
+		popHex, err := popHexFromJSON(input.Pop)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidPoP, err)
+		}
+		r.PopHex = popHex
+	}
+	return nil
+}
+
+// popHexFromJSON converts a ProofOfPossessionBTC in its JSON form, with the
+// signature type given by name or number and the signature in base64, to the
+// hex encoding printed by generate-pop
+func popHexFromJSON(data []byte) (string, error) {
+	var popJSON struct {
+		BtcSigType json.RawMessage `json:"btc_sig_type"`
+		BtcSig     []byte          `json:"btc_sig"`
+	}
+	if err := json.Unmarshal(data, &popJSON); err != nil {
+		return "", err
+	}
+
+	sigType := bstypes.BTCSigType_BIP340
+	if len(popJSON.BtcSigType) > 0 {
+		var name string
+		if err := json.Unmarshal(popJSON.BtcSigType, &name); err != nil {
+			name = string(popJSON.BtcSigType)
+		}
+		if value, ok := bstypes.BTCSigType_value[name]; ok {
+			sigType = bstypes.BTCSigType(value)
+		} else if value, err := strconv.ParseInt(name, 10, 32); err == nil {
+			sigType = bstypes.BTCSigType(value)
+		} else {
+			return "", fmt.Errorf("unknown btc_sig_type %s", popJSON.BtcSigType)
+		}
+	}
+
+	pop := &bstypes.ProofOfPossessionBTC{BtcSigType: sigType, BtcSig: popJSON.BtcSig}
+	return pop.ToHexStr()
+}
+
+// firstNonEmpty returns the first of values that is set
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// verifyPoP checks that popHex proves the possession of the BTC key
+// btcPkHex by the Babylon address bbnAddr, like Babylon does when the FP is
+// created
+func verifyPoP(btcPkHex, bbnAddr, popHex string, net *chaincfg.Params) error {
+	btcPk, err := bbn.NewBIP340PubKeyFromHex(btcPkHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidBtcPk, err)
+	}
+	addr, err := sdk.AccAddressFromBech32(bbnAddr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidAddress, err)
+	}
+	pop, err := bstypes.NewPoPBTCFromHex(popHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPoP, err)
+	}
+	if err := pop.ValidateBasic(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidPoP, err)
+	}
+	if err := pop.Verify(addr, btcPk, net); err != nil {
+		return fmt.Errorf("%w: %v", errBadPoP, err)
+	}
+	return nil
+}
+
+// newPoPVerificationResult converts the outcome of verifyPoP
+func newPoPVerificationResult(err error) *VerificationResult {
+	if err == nil {
+		return &VerificationResult{Valid: true, Checked: []string{"signature"}}
+	}
+	return &VerificationResult{Failure: failureName(err, popFailures), Reason: err.Error()}
+}
+
+// PoPBatchResult is the output of verify-pop --file
+type PoPBatchResult struct {
+	Total   int                    `json:"total"`
+	Valid   int                    `json:"valid"`
+	Invalid int                    `json:"invalid"`
+	Results []*PoPBatchEntryResult `json:"results"`
+}
+
+// PoPBatchEntryResult is the verification result of one registration
+type PoPBatchEntryResult struct {
+	Index    int    `json:"index"`
+	BtcPkHex string `json:"btc_pk_hex"`
+	BbnAddr  string `json:"bbn_addr"`
+	*VerificationResult
+}
+
+// readFPRegistrations reads the registrations to verify from r: a JSON
+// array of registrations, an object listing them under finality_providers
+// (the btcstaking genesis state), or a whole genesis file
+func readFPRegistrations(r io.Reader) ([]json.RawMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registrations: %v", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var entries []json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse registrations: %v", err)
+		}
+		return entries, nil
+	}
+
+	var input struct {
+		FinalityProviders []json.RawMessage `json:"finality_providers"`
+		AppState          *struct {
+			BTCStaking *struct {
+				FinalityProviders []json.RawMessage `json:"finality_providers"`
+			} `json:"btcstaking"`
+		} `json:"app_state"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("failed to parse registrations: %v", err)
+	}
+	switch {
+	case input.FinalityProviders != nil:
+		return input.FinalityProviders, nil
+	case input.AppState != nil && input.AppState.BTCStaking != nil:
+		return input.AppState.BTCStaking.FinalityProviders, nil
+	default:
+		return nil, fmt.Errorf("no registrations found: expected a JSON array, finality_providers or app_state.btcstaking.finality_providers")
+	}
+}
+
+// verifyPoPBatch verifies the proof of possession of every registration.
+// A BTC key can only be registered once, so every registration of a key
+// after the first one is rejected.
+func verifyPoPBatch(entries []json.RawMessage, net *chaincfg.Params) *PoPBatchResult {
+	batch := &PoPBatchResult{Total: len(entries), Results: make([]*PoPBatchEntryResult, 0, len(entries))}
+	seen := map[string]int{}
+	for i, entry := range entries {
+		var reg FPRegistration
+		err := json.Unmarshal(entry, &reg)
+		if err == nil {
+			err = verifyPoP(reg.BtcPkHex, reg.BbnAddr, reg.PopHex, net)
+		} else if !errors.Is(err, errInvalidPoP) {
+			err = fmt.Errorf("%w: %v", errInvalidPoP, err)
+		}
+		if err == nil {
+			key := strings.ToLower(reg.BtcPkHex)
+			if first, ok := seen[key]; ok {
+				err = fmt.Errorf("%w: already registered by entry %d", errDuplicateBtcPk, first)
+			} else {
+				seen[key] = i
+			}
+		}
+
+		result := newPoPVerificationResult(err)
+		if result.Valid {
+			batch.Valid++
+		} else {
+			batch.Invalid++
+		}
+		batch.Results = append(batch.Results, &PoPBatchEntryResult{
+			Index:              i,
+			BtcPkHex:           reg.BtcPkHex,
+			BbnAddr:            reg.BbnAddr,
+			VerificationResult: result,
+		})
+	}
+	return batch
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	"github.com/babylonlabs-io/babylon/v4/testutil/datagen"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	ftypes "github.com/babylonlabs-io/babylon/v4/x/finality/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/randgen"
+	"crypto-ops-tool/signer"
+)
+
+// signerRandCommit has s derive the public randomness of a commitment for
+// consumerID and rebuilds the commitment over it. The secret randomness
+// stays with the signer, which derives it again when signing.
+func signerRandCommit(s signer.Signer, consumerID string, startHeight, numPubRand uint64) (*RandCommit, error) {
+	if consumerID == "" {
+		return nil, fmt.Errorf("consumer id is required to derive randomness")
+	}
+
+	prList, err := s.PubRandList(context.Background(), []byte(consumerID), startHeight, numPubRand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive randomness list: %v", err)
+	}
+	randList, err := randgen.NewPubRandList(startHeight, prList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild commitment: %v", err)
+	}
+
+	return &RandCommit{
+		StartHeight:  startHeight,
+		ChainID:      []byte(consumerID),
+		RandListInfo: newRandListInfo(randList),
+	}, nil
+}
+
+// signCommitment signs a randomness commitment exactly like
+// datagen.GenRandomMsgCommitPubRandList
+func signCommitment(s signer.Signer, startHeight, numPubRand uint64, commitment []byte) ([]byte, error) {
+	msg := &ftypes.MsgCommitPubRandList{
+		FpBtcPk:     s.PublicKey(),
+		StartHeight: startHeight,
+		NumPubRand:  numPubRand,
+		Commitment:  commitment,
+	}
+	hash, err := msg.HashToSign()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash commitment: %v", err)
+	}
+	sig, err := s.SignSchnorr(context.Background(), hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign commitment: %v", err)
+	}
+	return sig.Serialize(), nil
+}
+
+// newRandListInfo converts a randomness list to the datagen representation
+// used by the signing code
+func newRandListInfo(randList *randgen.RandList) *datagen.RandListInfo {
+	randListInfo := &datagen.RandListInfo{
+		SRList:     make([]*eots.PrivateRand, len(randList.SRList)),
+		PRList:     make([]bbn.SchnorrPubRand, len(randList.PRList)),
+		Commitment: randList.Commitment,
+		ProofList:  randList.ProofList,
+	}
+	for i, sr := range randList.SRList {
+		randListInfo.SRList[i] = sr
+	}
+	for i, pr := range randList.PRList {
+		randListInfo.PRList[i] = *bbn.NewSchnorrPubRandFromFieldVal(pr)
+	}
+	return randListInfo
+}
+
+// Generate deterministic public randomness and commitment (crypto only, no chain submission)
+func generateDeterministicPubRandCommitment(s signer.Signer, consumerID string, startHeight, numPubRand uint64) (*RandCommit, []byte, error) {
+	fmt.Fprintln(os.Stderr, "  → Deriving public randomness list with the signer...")
+
+	randCommit, err := signerRandCommit(s, consumerID, startHeight, numPubRand)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := signCommitment(s, startHeight, numPubRand, randCommit.Commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "  → Derived %d public randomness values starting at height %d\n", numPubRand, startHeight)
+
+	return randCommit, sig, nil
+}
+
+// RandCommit is the randomness list of one commitment together with the
+// height of its first randomness. A list without secret randomness was
+// derived by the signer for ChainID, and is signed with by the signer
+// deriving the randomness again.
+type RandCommit struct {
+	StartHeight uint64
+	ChainID     []byte
+	*datagen.RandListInfo
+}
+
+// EndHeight returns the last height covered by the commitment
+func (c *RandCommit) EndHeight() uint64 {
+	return c.StartHeight + uint64(len(c.PRList)) - 1
+}
+
+// signEOTS signs msg with s and the randomness of the commitment at
+// randIndex
+func (c *RandCommit) signEOTS(s signer.Signer, randIndex int, msg []byte) (*bbn.SchnorrEOTSSig, error) {
+	var sr *btcec.ModNScalar
+	if len(c.SRList) > 0 {
+		sr = c.SRList[randIndex]
+	}
+	sig, err := signer.SignEOTSWithRand(context.Background(), s, c.ChainID, c.StartHeight+uint64(randIndex), sr, msg)
+	if err != nil {
+		return nil, err
+	}
+	return bbn.NewSchnorrEOTSSigFromModNScalar(sig), nil
+}
+
+// findRandCommit returns the commitment covering blockHeight and the index of
+// the block's randomness within it
+func findRandCommit(randCommits []*RandCommit, blockHeight uint64) (*RandCommit, int, error) {
+	for _, c := range randCommits {
+		if len(c.PRList) == 0 || blockHeight < c.StartHeight || blockHeight > c.EndHeight() {
+			continue
+		}
+		randIndex := int(blockHeight - c.StartHeight)
+		if randIndex >= len(c.ProofList) || (len(c.SRList) > 0 && randIndex >= len(c.SRList)) {
+			return nil, 0, fmt.Errorf("commitment starting at height %d has no randomness or proof for height %d", c.StartHeight, blockHeight)
+		}
+		return c, randIndex, nil
+	}
+
+	covered := make([]string, 0, len(randCommits))
+	for _, c := range randCommits {
+		covered = append(covered, fmt.Sprintf("[%d, %d]", c.StartHeight, c.EndHeight()))
+	}
+	return nil, 0, fmt.Errorf("no randomness available for block height %d (committed ranges: %s)", blockHeight, strings.Join(covered, ", "))
+}
+
+// readRandCommits reads randomness lists from r, either a single
+// SerializableRandListInfo or a JSON array of them for consecutive commit
+// windows. Lists without start_height predate it and start from height 1.
+func readRandCommits(r io.Reader) ([]*RandCommit, error) {
+	// Read randListInfo from stdin instead of command line to avoid "Argument list too long"
+	fmt.Fprintln(os.Stderr, "  → Reading randomness data from stdin...")
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %v", err)
+	}
+
+	var serializables []SerializableRandListInfo
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &serializables); err != nil {
+			return nil, fmt.Errorf("failed to parse randListInfo list: %v", err)
+		}
+	} else {
+		var serializable SerializableRandListInfo
+		if err := json.Unmarshal(data, &serializable); err != nil {
+			return nil, fmt.Errorf("failed to parse randListInfo: %v", err)
+		}
+		serializables = append(serializables, serializable)
+	}
+	if len(serializables) == 0 {
+		return nil, fmt.Errorf("no randomness lists provided")
+	}
+
+	randCommits := make([]*RandCommit, 0, len(serializables))
+	for i := range serializables {
+		randListInfo, err := ConvertFromSerializable(&serializables[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert randListInfo %d: %v", i, err)
+		}
+		startHeight := serializables[i].StartHeight
+		if startHeight == 0 {
+			startHeight = 1
+		}
+		randCommits = append(randCommits, &RandCommit{StartHeight: startHeight, RandListInfo: randListInfo})
+	}
+
+	// Overlapping windows would make the randomness of a height ambiguous
+	sort.Slice(randCommits, func(i, j int) bool { return randCommits[i].StartHeight < randCommits[j].StartHeight })
+	for i := 1; i < len(randCommits); i++ {
+		prev, cur := randCommits[i-1], randCommits[i]
+		if len(prev.PRList) > 0 && cur.StartHeight <= prev.EndHeight() {
+			return nil, fmt.Errorf("randomness lists starting at heights %d and %d overlap", prev.StartHeight, cur.StartHeight)
+		}
+	}
+
+	return randCommits, nil
+}
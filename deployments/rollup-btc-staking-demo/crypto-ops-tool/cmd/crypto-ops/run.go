@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/daemon"
+	"crypto-ops-tool/l2client"
+	"crypto-ops-tool/opfinality"
+)
+
+// runDaemon implements `crypto-ops run`: a long-running finality signer
+// following an L2 until it receives SIGINT or SIGTERM
+func runDaemon(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	chainCfg := addChainFlags(fs)
+	home := addHomeFlag(fs)
+	keySource := addSignerFlags(fs)
+	cfg := daemon.DefaultConfig()
+	fs.StringVar(&cfg.ConsumerID, "consumer-id", cfg.ConsumerID, "consumer/chain id mixed into the derived randomness")
+	fs.Uint64Var(&cfg.NumPubRand, "num-pub-rand", cfg.NumPubRand, "number of public randomness values in each commitment")
+	fs.Uint64Var(&cfg.TimestampingDelayBlocks, "timestamping-delay-blocks", cfg.TimestampingDelayBlocks, "blocks between a commitment and the first height it covers")
+	fs.DurationVar(&cfg.RandomnessCommitInterval, "randomness-commit-interval", cfg.RandomnessCommitInterval, "interval between each attempt to commit public randomness")
+	fs.DurationVar(&cfg.PollInterval, "l2-poll-interval", cfg.PollInterval, "interval between each poll of the L2 tip")
+	fs.Uint64Var(&cfg.StartHeight, "start-height", cfg.StartHeight, "first L2 height to sign (0 resumes from the store)")
+	fs.DurationVar(&cfg.SubmissionRetryInterval, "submission-retry-interval", cfg.SubmissionRetryInterval, "interval between submission attempts after a failure")
+	fs.IntVar(&cfg.MaxSubmissionRetries, "max-submission-retries", cfg.MaxSubmissionRetries, "maximum number of attempts of each submission")
+	fs.StringVar(&cfg.HealthAddr, "health-addr", cfg.HealthAddr, "listen address of the /health endpoint (empty to disable)")
+	l2RPCAddr := fs.String("l2-rpc", "", "OP-stack/Ethereum JSON-RPC endpoint of the L2 to follow, e.g. http://localhost:8545")
+	l2Timeout := fs.Duration("l2-timeout", 10*time.Second, "timeout of each L2 JSON-RPC request")
+	positional := parseArgs(fs, args)
+	if len(positional) < numKeyArgs(keySource)+1 {
+		fmt.Println("Error: Missing arguments for run")
+		printUsage()
+		os.Exit(1)
+	}
+	if *home == "" {
+		log.Fatalf("--home is required for run")
+	}
+	if *l2RPCAddr == "" {
+		log.Fatalf("--l2-rpc is required for run")
+	}
+
+	fpSigner, positional, err := loadSigner(keySource, *home, positional)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer closeSigner(fpSigner)
+	contractAddr := positional[0]
+
+	st, err := openStore(*home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer st.Close()
+
+	l2Client, err := l2client.New(*l2RPCAddr, *l2Timeout)
+	if err != nil {
+		log.Fatalf("Failed to create L2 client: %v", err)
+	}
+
+	bbnClient, err := bbnclient.New(*chainCfg)
+	if err != nil {
+		log.Fatalf("Failed to create Babylon client: %v", err)
+	}
+	defer bbnClient.Close()
+
+	finalityContract := opfinality.NewClient(bbnClient, contractAddr)
+	d, err := daemon.New(cfg, fpSigner, l2Client, finalityContract, st)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := d.Run(ctx); err != nil {
+		log.Fatalf("Daemon failed: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"crypto-ops-tool/bbnclient"
+	"crypto-ops-tool/scenario"
+	"crypto-ops-tool/stakerclient"
+)
+
+// runScenario implements `crypto-ops scenario`: runs a scenario file against
+// the deployment, prints the JSON report on stdout and exits with status 1
+// unless every assertion passed
+func runScenario(args []string) {
+	fs := flag.NewFlagSet("scenario", flag.ExitOnError)
+	chainCfg := addChainFlags(fs)
+	home := addHomeFlag(fs)
+	kf := &keyFlags{}
+	addKeyStoreFlags(fs, kf)
+	mf := addMnemonicFlags(fs)
+	cfg := scenario.DefaultConfig()
+	fs.StringVar(&cfg.ContractWasmPath, "contract-wasm", cfg.ContractWasmPath, "op-finality-gadget WASM byte code deployed for every consumer")
+	fs.DurationVar(&cfg.ActivationPollInterval, "activation-poll-interval", cfg.ActivationPollInterval, "interval between each check of the delegations")
+	fs.IntVar(&cfg.VoteRetries, "vote-retries", cfg.VoteRetries, "block voter queries made to find each submitted vote")
+	fs.DurationVar(&cfg.VoteRetryInterval, "vote-retry-interval", cfg.VoteRetryInterval, "interval between those queries")
+	fs.DurationVar(&cfg.SlashingPollInterval, "slashing-poll-interval", cfg.SlashingPollInterval, "interval between each check of the FPs expected to be slashed")
+	fs.IntVar(&cfg.Concurrency, "concurrency", cfg.Concurrency, "number of consumer FPs committing randomness and signing at once")
+	stakerAddr := fs.String("staker-rpc", "http://localhost:15912", "JSON-RPC endpoint of the BTC staker daemon")
+	stakerTimeout := fs.Duration("staker-timeout", time.Minute, "timeout of each request to the BTC staker daemon")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		log.Fatalf("Usage: scenario --home <dir> [flags] <scenario_file>")
+	}
+	if *home == "" {
+		log.Fatalf("--home is required for scenario")
+	}
+
+	sc, err := scenario.Load(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	// Storing and instantiating the contract costs far more gas than a
+	// contract execution, so simulate and price the gas unless it was given
+	setFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if !setFlags["gas"] {
+		chainCfg.Gas = 0
+	}
+	if !setFlags["fees"] {
+		chainCfg.Fees = ""
+	}
+
+	keys, err := openFpKeys(kf, *home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	scenarioKeys := &demoKeys{keys: keys, mf: mf}
+	if mf.enabled() {
+		scenarioKeys.mnemonic, err = mf.read()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	st, err := openStore(*home)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer st.Close()
+
+	bbnClient, err := bbnclient.New(*chainCfg)
+	if err != nil {
+		log.Fatalf("Failed to create Babylon client: %v", err)
+	}
+	defer bbnClient.Close()
+
+	staker, err := stakerclient.New(*stakerAddr, *stakerTimeout)
+	if err != nil {
+		log.Fatalf("Failed to create staker client: %v", err)
+	}
+
+	runner, err := scenario.New(cfg, bbnClient, staker, scenarioKeys, st)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "  → Running scenario %s\n", sc.Name)
+	report, runErr := runner.Run(ctx, sc)
+	printJSON(report)
+	if runErr != nil {
+		log.Fatalf("❌ Scenario %s failed: %v", sc.Name, runErr)
+	}
+	if !report.Passed {
+		for _, a := range report.Failed() {
+			fmt.Fprintf(os.Stderr, "  ❌ %s: %s\n", a.Name, a.Detail)
+		}
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "  ✅ Scenario %s passed (%d assertions)\n", sc.Name, len(report.Assertions))
+}
+
+// runGenerateScenario implements `crypto-ops generate-scenario`: prints the
+// scenario of N consumer FPs spread across M consumers as YAML, to run with
+// `crypto-ops scenario` as is or after editing
+func runGenerateScenario(args []string) {
+	fs := flag.NewFlagSet("generate-scenario", flag.ExitOnError)
+	t := scenario.DefaultTopology()
+	fs.StringVar(&t.Name, "name", t.Name, "name of the scenario, prefixing its consumer IDs and key names")
+	fs.IntVar(&t.Consumers, "consumers", t.Consumers, "number of consumers, each with its own finality contract")
+	fs.IntVar(&t.FinalityProviders, "fps", t.FinalityProviders, "number of consumer FPs, spread evenly across the consumers")
+	fs.Uint64Var(&t.FromHeight, "from-height", t.FromHeight, "first height every consumer FP signs")
+	fs.Uint64Var(&t.ToHeight, "to-height", t.ToHeight, "last height every consumer FP signs")
+	fs.Int64Var(&t.StakingAmount, "staking-amount", t.StakingAmount, "amount in satoshis of the delegation to each consumer FP")
+	fs.Int64Var(&t.StakingTime, "staking-time", t.StakingTime, "staking time lock in BTC blocks of those delegations")
+	parseArgs(fs, args)
+	if fs.NArg() != 0 {
+		log.Fatalf("Usage: generate-scenario [--consumers <m>] [--fps <n>] [--from-height <h>] [--to-height <h>]")
+	}
+
+	sc, err := scenario.Generate(t)
+	if err != nil {
+		log.Fatalf("Invalid topology: %v", err)
+	}
+	out, err := yaml.Marshal(sc)
+	if err != nil {
+		log.Fatalf("Failed to encode scenario: %v", err)
+	}
+	fmt.Print(string(out))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"crypto-ops-tool/store"
+)
+
+// signingHistoryVersion is the version of the signing history format
+const signingHistoryVersion = 1
+
+// SigningHistory is the JSON interchange format of the slashing-protection
+// history, carried along when an FP migrates between machines
+type SigningHistory struct {
+	Version int                    `json:"version"`
+	Records []SigningHistoryRecord `json:"records"`
+}
+
+// SigningHistoryRecord is a finality signature produced by an FP
+type SigningHistoryRecord struct {
+	FpPubkeyHex  string    `json:"fp_pubkey_hex"`
+	Height       uint64    `json:"height"`
+	BlockHashHex string    `json:"block_hash_hex"`
+	SignatureHex string    `json:"signature_hex,omitempty"`
+	SignedAt     time.Time `json:"signed_at"`
+}
+
+// exportSigningHistory returns the signing history of the FP, or of all FPs
+// when fpPubkeyHex is empty
+func exportSigningHistory(st *store.Store, fpPubkeyHex string) (*SigningHistory, error) {
+	var fpBtcPk []byte
+	if fpPubkeyHex != "" {
+		var err error
+		fpBtcPk, err = hex.DecodeString(fpPubkeyHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FP public key hex: %v", err)
+		}
+	}
+
+	records, err := st.ListSignRecords(fpBtcPk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sign records: %v", err)
+	}
+
+	history := &SigningHistory{
+		Version: signingHistoryVersion,
+		Records: make([]SigningHistoryRecord, 0, len(records)),
+	}
+	for _, r := range records {
+		history.Records = append(history.Records, SigningHistoryRecord{
+			FpPubkeyHex:  hex.EncodeToString(r.FpBtcPk),
+			Height:       r.Height,
+			BlockHashHex: hex.EncodeToString(r.BlockHash),
+			SignatureHex: hex.EncodeToString(r.Signature),
+			SignedAt:     r.SignedAt,
+		})
+	}
+	return history, nil
+}
+
+// importSigningHistory merges an exported signing history into the store and
+// returns the number of new records. Nothing is imported if any record
+// conflicts with the local history.
+func importSigningHistory(st *store.Store, history *SigningHistory) (int, error) {
+	if history.Version != signingHistoryVersion {
+		return 0, fmt.Errorf("unsupported signing history version %d", history.Version)
+	}
+
+	records := make([]*store.SignRecord, 0, len(history.Records))
+	for i, r := range history.Records {
+		fpBtcPk, err := hex.DecodeString(r.FpPubkeyHex)
+		if err != nil || len(fpBtcPk) != 32 {
+			return 0, fmt.Errorf("record %d: invalid FP public key %q", i, r.FpPubkeyHex)
+		}
+		blockHash, err := hex.DecodeString(r.BlockHashHex)
+		if err != nil {
+			return 0, fmt.Errorf("record %d: invalid block hash: %v", i, err)
+		}
+		signature, err := hex.DecodeString(r.SignatureHex)
+		if err != nil {
+			return 0, fmt.Errorf("record %d: invalid signature: %v", i, err)
+		}
+		records = append(records, &store.SignRecord{
+			FpBtcPk:   fpBtcPk,
+			Height:    r.Height,
+			BlockHash: blockHash,
+			Signature: signature,
+			SignedAt:  r.SignedAt,
+		})
+	}
+
+	imported, err := st.ImportSignRecords(records)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import signing history: %v", err)
+	}
+	return imported, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"crypto-ops-tool/store"
+)
+
+func TestSigningHistoryRoundTrip(t *testing.T) {
+	fpPk := bytes.Repeat([]byte{0x0a}, 32)
+	src, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	for h := uint64(1); h <= 3; h++ {
+		r := &store.SignRecord{FpBtcPk: fpPk, Height: h, BlockHash: bytes.Repeat([]byte{byte(h)}, 32), Signature: []byte{0xee}}
+		if err := src.SaveSignRecord(r, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := exportSigningHistory(src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The history travels as JSON between machines
+	data, err := json.Marshal(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SigningHistory
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	imported, err := importSigningHistory(dst, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 3 {
+		t.Fatalf("imported %d records, want 3", imported)
+	}
+	reexported, err := exportSigningHistory(dst, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := json.Marshal(reexported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Fatalf("history changed on import:\n%s\nwant:\n%s", again, data)
+	}
+
+	// A conflicting history is refused as a whole
+	decoded.Records[0].BlockHashHex = strings.Repeat("ff", 32)
+	if _, err := importSigningHistory(dst, &decoded); err == nil {
+		t.Fatal("conflicting history imported")
+	}
+}
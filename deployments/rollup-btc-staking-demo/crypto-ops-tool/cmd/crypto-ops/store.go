@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+
+	"github.com/babylonlabs-io/babylon/v4/testutil/datagen"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// openStore opens the randomness and signing store under home, or returns
+// nil when no home directory is configured
+func openStore(home string) (*store.Store, error) {
+	if home == "" {
+		return nil, nil
+	}
+	st, err := store.Open(home)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "  → Using store %s\n", st.Path())
+	return st, nil
+}
+
+// saveRandCommit stores the chain id the signer derives the randomness of a
+// commitment for, so that later signatures can be produced without piping
+// it between commands. The secret randomness itself is never stored, as
+// anyone reading it and a signature made with it could extract the FP key.
+func saveRandCommit(st *store.Store, bip340PK *bbn.BIP340PubKey, randCommit *RandCommit) error {
+	if len(randCommit.ChainID) == 0 {
+		return fmt.Errorf("only randomness derived for a consumer id can be stored")
+	}
+
+	err := st.SavePubRandCommit(&store.PubRandCommit{
+		FpBtcPk:     bip340PK.MustMarshal(),
+		StartHeight: randCommit.StartHeight,
+		NumPubRand:  uint64(len(randCommit.PRList)),
+		Commitment:  randCommit.Commitment,
+		ChainID:     randCommit.ChainID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store commitment: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "  → Stored commitment for heights %d to %d\n", randCommit.StartHeight, randCommit.EndHeight())
+	return nil
+}
+
+// loadRandCommit rebuilds the stored commitment of the FP of s covering
+// blockHeight from the public randomness s derives
+func loadRandCommit(st *store.Store, s signer.Signer, blockHeight uint64) (*RandCommit, error) {
+	bip340PK := s.PublicKey()
+
+	commit, err := st.GetPubRandCommit(bip340PK.MustMarshal(), blockHeight)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("no stored randomness covers block height %d for FP %s", blockHeight, bip340PK.MarshalHex())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commitment: %v", err)
+	}
+
+	randCommit, err := signerRandCommit(s, string(commit.ChainID), commit.StartHeight, commit.NumPubRand)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(randCommit.Commitment, commit.Commitment) {
+		return nil, fmt.Errorf("stored randomness does not match stored commitment %x", commit.Commitment)
+	}
+
+	fmt.Fprintf(os.Stderr, "  → Loaded stored commitment for heights %d to %d\n", commit.StartHeight, commit.EndHeight())
+	return randCommit, nil
+}
+
+// resolveRandCommits returns the commitments covering blockHeight for the FP
+// of s. They are derived from rf with --deterministic, and so are those of a
+// signer deriving its own randomness without a store, which has nothing to
+// read from stdin; otherwise they are loaded from st or else read from stdin.
+func resolveRandCommits(rf *randFlags, st *store.Store, s signer.Signer, blockHeight uint64) ([]*RandCommit, error) {
+	_, secretRand := s.(signer.SecretRandSigner)
+	if rf.Deterministic || (!secretRand && st == nil) {
+		// Regenerate the randomness of the whole commitment to rebuild the merkle proof
+		if rf.CommitNumPubRand == 0 {
+			return nil, fmt.Errorf("--commit-num-pub-rand is required with --deterministic or a remote --signer")
+		}
+		randCommit, err := signerRandCommit(s, rf.ConsumerID, rf.CommitStartHeight, rf.CommitNumPubRand)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive randListInfo: %v", err)
+		}
+		return []*RandCommit{randCommit}, nil
+	}
+	if st != nil {
+		randCommit, err := loadRandCommit(st, s, blockHeight)
+		if err != nil {
+			return nil, err
+		}
+		return []*RandCommit{randCommit}, nil
+	}
+	randCommits, err := readRandCommits(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read randListInfo: %v", err)
+	}
+	return randCommits, nil
+}
+
+// mockBlockHash returns the hash of the mock block to sign at blockHeight. A
+// block already signed at that height is signed again instead of a new
+// random one, which would be an equivocation, unless allowEquivocation is
+// set.
+func mockBlockHash(st *store.Store, r *mathrand.Rand, bip340PK *bbn.BIP340PubKey, blockHeight uint64, allowEquivocation bool) ([]byte, error) {
+	if st != nil && !allowEquivocation {
+		record, err := st.GetSignRecord(bip340PK.MustMarshal(), blockHeight)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "  → Height %d was already signed, reusing block hash %x\n", blockHeight, record.BlockHash)
+			return record.BlockHash, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("failed to load sign record: %v", err)
+		}
+	}
+	return datagen.GenRandomByteArray(r, 32), nil
+}
+
+// checkSlashingProtection refuses to sign blockHash at blockHeight when the
+// FP already signed a different block at that height, or when there is no
+// store to tell, unless allowEquivocation is set
+func checkSlashingProtection(st *store.Store, bip340PK *bbn.BIP340PubKey, blockHeight uint64, blockHash []byte, allowEquivocation bool) error {
+	if st == nil {
+		if !allowEquivocation {
+			return fmt.Errorf("slashing protection: signing requires the store of --home to refuse conflicting blocks (--allow-equivocation signs without it)")
+		}
+		fmt.Fprintf(os.Stderr, "  ⚠️  Signing height %d without slashing protection (allowed by --allow-equivocation)\n", blockHeight)
+		return nil
+	}
+	err := st.CheckDoubleSign(bip340PK.MustMarshal(), blockHeight, blockHash)
+	if errors.Is(err, store.ErrDoubleSign) && allowEquivocation {
+		fmt.Fprintf(os.Stderr, "  ⚠️  %v (allowed by --allow-equivocation)\n", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("slashing protection: %v", err)
+	}
+	return nil
+}
+
+// recordSignature records a finality signature in the store
+func recordSignature(st *store.Store, bip340PK *bbn.BIP340PubKey, blockHeight uint64, blockHash, signature []byte, allowEquivocation bool) error {
+	err := st.SaveSignRecord(&store.SignRecord{
+		FpBtcPk:   bip340PK.MustMarshal(),
+		Height:    blockHeight,
+		BlockHash: blockHash,
+		Signature: signature,
+	}, allowEquivocation)
+	if err != nil {
+		return fmt.Errorf("failed to record signature: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"crypto-ops-tool/eotsclient"
+	"crypto-ops-tool/signer"
+)
+
+func TestResolveRandCommitsRemoteSignerWithoutStore(t *testing.T) {
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	fake := eotsclient.NewFakeServer(sk)
+	target, err := fake.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Stop)
+	remote, err := signer.DialRemote(target, bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { remote.Close() })
+
+	// A remote signer has no randomness to read from stdin, it derives it
+	// from the flags like --deterministic
+	rf := &randFlags{ConsumerID: "consumer-id", CommitStartHeight: 1, CommitNumPubRand: 100}
+	got, err := resolveRandCommits(rf, nil, remote, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := signerRandCommit(signer.NewLocal(sk), "consumer-id", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !bytes.Equal(got[0].Commitment, want.Commitment) {
+		t.Fatalf("derived commitment differs from the local signer's %x", want.Commitment)
+	}
+
+	rf.CommitNumPubRand = 0
+	if _, err := resolveRandCommits(rf, nil, remote, 42); err == nil {
+		t.Fatal("derived randomness without --commit-num-pub-rand")
+	}
+}
+
+func TestResolveRandCommitsFromStore(t *testing.T) {
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	s := signer.NewLocal(sk)
+	st, err := openStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	randCommit, err := signerRandCommit(s, "consumer-id", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveRandCommit(st, s.PublicKey(), randCommit); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveRandCommits(&randFlags{}, st, s, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !bytes.Equal(got[0].Commitment, randCommit.Commitment) {
+		t.Fatal("stored commitment not loaded")
+	}
+	if _, err := resolveRandCommits(&randFlags{}, st, s, 101); err == nil {
+		t.Fatal("loaded a commitment not covering the height")
+	}
+}
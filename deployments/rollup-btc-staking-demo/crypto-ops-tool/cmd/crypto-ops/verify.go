@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"crypto-ops-tool/opfinality"
+)
+
+// VerificationResult is the output of the verify commands
+type VerificationResult struct {
+	Valid bool `json:"valid"`
+	// Checked lists the checks that passed, when a command runs several
+	Checked []string `json:"checked,omitempty"`
+	// Failure is a stable identifier of the failed check, Reason the details
+	Failure string `json:"failure,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// failureReason names a verification failure in the output
+type failureReason struct {
+	err  error
+	name string
+}
+
+// finalitySigFailures names the reasons of opfinality.VerifyFinalitySignature
+var finalitySigFailures = []failureReason{
+	{opfinality.ErrInvalidFpPubKey, "invalid_fp_pubkey"},
+	{opfinality.ErrInvalidPubRand, "invalid_pub_rand"},
+	{opfinality.ErrInvalidSignature, "invalid_signature"},
+	{opfinality.ErrEmptyBlockHash, "empty_block_hash"},
+	{opfinality.ErrHeightNotCovered, "height_not_covered"},
+	{opfinality.ErrWrongProofIndex, "wrong_index"},
+	{opfinality.ErrWrongProofTotal, "wrong_total"},
+	{opfinality.ErrBadProof, "bad_proof"},
+	{opfinality.ErrBadSignature, "bad_signature"},
+}
+
+// pubRandCommitmentFailures names the reasons of
+// opfinality.VerifyPubRandCommitment and opfinality.VerifyPubRandList
+var pubRandCommitmentFailures = []failureReason{
+	{opfinality.ErrInvalidFpPubKey, "invalid_fp_pubkey"},
+	{opfinality.ErrInvalidSignature, "invalid_signature"},
+	{opfinality.ErrInvalidPubRand, "invalid_pub_rand"},
+	{opfinality.ErrEmptyCommitment, "empty_commitment"},
+	{opfinality.ErrBadCommitSignature, "bad_signature"},
+	{opfinality.ErrPubRandCountMismatch, "pub_rand_count_mismatch"},
+	{opfinality.ErrCommitmentMismatch, "commitment_mismatch"},
+}
+
+// newFinalitySigVerificationResult converts the outcome of
+// opfinality.VerifyFinalitySignature
+func newFinalitySigVerificationResult(err error) *VerificationResult {
+	if err == nil {
+		return &VerificationResult{Valid: true}
+	}
+	return &VerificationResult{Failure: failureName(err, finalitySigFailures), Reason: err.Error()}
+}
+
+// failureName returns the name of the reason err wraps
+func failureName(err error, failures []failureReason) string {
+	for _, f := range failures {
+		if errors.Is(err, f.err) {
+			return f.name
+		}
+	}
+	return "invalid"
+}
+
+// readFinalitySignature reads a finality signature from r: the output of
+// generate-finality-sig, a submit_finality_signature execute message or the
+// bare message
+func readFinalitySignature(r io.Reader) (*opfinality.SubmitFinalitySignature, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read finality signature: %v", err)
+	}
+
+	var input struct {
+		opfinality.SubmitFinalitySignature
+		ExecuteMsg *opfinality.SubmitFinalitySignature `json:"submit_finality_signature"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("failed to parse finality signature: %v", err)
+	}
+	if input.ExecuteMsg != nil {
+		return input.ExecuteMsg, nil
+	}
+	return &input.SubmitFinalitySignature, nil
+}
+
+// pubRandCommitmentInput is a randomness commitment to verify: the output of
+// generate-pub-rand-commitment, a commit_public_randomness execute message
+// or the bare message, optionally with the public randomness list
+type pubRandCommitmentInput struct {
+	opfinality.CommitPublicRandomness
+	ExecuteMsg   *opfinality.CommitPublicRandomness `json:"commit_public_randomness"`
+	RandListInfo *SerializableRandListInfo          `json:"rand_list_info"`
+	// PRListHex and CommitmentHex are set when a bare rand_list_info is given
+	PRListHex     []string `json:"pr_list_hex"`
+	CommitmentHex string   `json:"commitment_hex"`
+}
+
+// readPubRandCommitment reads a randomness commitment from r and returns the
+// message and the public randomness list, which is nil when r has none
+func readPubRandCommitment(r io.Reader) (*opfinality.CommitPublicRandomness, [][]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read commitment: %v", err)
+	}
+	var input pubRandCommitmentInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse commitment: %v", err)
+	}
+
+	msg := &input.CommitPublicRandomness
+	if input.ExecuteMsg != nil {
+		msg = input.ExecuteMsg
+	}
+
+	prListHex, commitmentHex := input.PRListHex, input.CommitmentHex
+	if input.RandListInfo != nil {
+		prListHex, commitmentHex = input.RandListInfo.PRListHex, input.RandListInfo.CommitmentHex
+	}
+	if commitmentHex != "" {
+		commitment, err := hex.DecodeString(commitmentHex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid commitment_hex: %v", err)
+		}
+		if len(msg.Commitment) == 0 {
+			msg.Commitment = commitment
+		} else if !bytes.Equal(msg.Commitment, commitment) {
+			return nil, nil, fmt.Errorf("%w: commitment_hex %x differs from commitment %x",
+				opfinality.ErrCommitmentMismatch, commitment, msg.Commitment)
+		}
+	}
+
+	if prListHex == nil {
+		return msg, nil, nil
+	}
+	prList := make([][]byte, len(prListHex))
+	for i, prHex := range prListHex {
+		prList[i], err = hex.DecodeString(prHex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: value %d: %v", opfinality.ErrInvalidPubRand, i, err)
+		}
+	}
+	return msg, prList, nil
+}
+
+// verifyPubRandCommitment checks the signature of msg under fpPubkeyHex and,
+// when prList is given, that it hashes to the commitment
+func verifyPubRandCommitment(msg *opfinality.CommitPublicRandomness, fpPubkeyHex string, prList [][]byte) *VerificationResult {
+	result := &VerificationResult{}
+	if err := opfinality.VerifyPubRandCommitment(msg, fpPubkeyHex); err != nil {
+		result.Failure = failureName(err, pubRandCommitmentFailures)
+		result.Reason = err.Error()
+		return result
+	}
+	result.Checked = append(result.Checked, "signature")
+
+	if prList != nil {
+		if err := opfinality.VerifyPubRandList(msg, prList); err != nil {
+			result.Failure = failureName(err, pubRandCommitmentFailures)
+			result.Reason = err.Error()
+			return result
+		}
+		result.Checked = append(result.Checked, "merkle_root")
+	}
+
+	result.Valid = true
+	return result
+}
+
+// decodeBytesArg decodes a hex argument, or a base64 one as the contract
+// queries print binary fields
+func decodeBytesArg(s string) ([]byte, error) {
+	if b, err := hex.DecodeString(strings.TrimPrefix(s, "0x")); err == nil {
+		return b, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither hex nor base64", s)
+	}
+	return b, nil
+}
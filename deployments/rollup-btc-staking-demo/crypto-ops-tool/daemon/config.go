@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds the parameters of the signer daemon. Field names follow
+// consumer-fpd.conf where the daemon mirrors a finality provider setting.
+type Config struct {
+	// ConsumerID is the consumer chain id mixed into the derivation of the
+	// EOTS randomness
+	ConsumerID string
+	// NumPubRand is the number of public randomness values in each commitment
+	NumPubRand uint64
+	// TimestampingDelayBlocks is the number of blocks between a randomness
+	// commitment and the first height it covers
+	TimestampingDelayBlocks uint64
+	// RandomnessCommitInterval is the interval between each attempt to
+	// commit public randomness
+	RandomnessCommitInterval time.Duration
+	// PollInterval is the interval between each poll of the L2 tip
+	PollInterval time.Duration
+	// StartHeight is the first L2 height to sign. Zero resumes from the
+	// last signed height, or starts from the first committed height.
+	StartHeight uint64
+	// SubmissionRetryInterval is the interval between submission attempts
+	// after a failure
+	SubmissionRetryInterval time.Duration
+	// MaxSubmissionRetries is the maximum number of attempts of a submission
+	MaxSubmissionRetries int
+	// HealthAddr is the listen address of the health endpoint; empty
+	// disables it
+	HealthAddr string
+}
+
+// DefaultConfig returns the settings of artifacts/consumer-fpd.conf
+func DefaultConfig() Config {
+	return Config{
+		ConsumerID:               "",
+		NumPubRand:               1000,
+		TimestampingDelayBlocks:  4,
+		RandomnessCommitInterval: 5 * time.Second,
+		PollInterval:             5 * time.Second,
+		StartHeight:              0,
+		SubmissionRetryInterval:  1 * time.Second,
+		MaxSubmissionRetries:     20,
+		HealthAddr:               "127.0.0.1:12590",
+	}
+}
+
+// Validate checks that the configuration is usable
+func (cfg *Config) Validate() error {
+	if cfg.ConsumerID == "" {
+		return fmt.Errorf("consumer id must be set")
+	}
+	if cfg.NumPubRand == 0 {
+		return fmt.Errorf("num pub rand must be positive")
+	}
+	if cfg.RandomnessCommitInterval <= 0 {
+		return fmt.Errorf("randomness commit interval must be positive")
+	}
+	if cfg.PollInterval <= 0 {
+		return fmt.Errorf("poll interval must be positive")
+	}
+	if cfg.SubmissionRetryInterval <= 0 {
+		return fmt.Errorf("submission retry interval must be positive")
+	}
+	if cfg.MaxSubmissionRetries <= 0 {
+		return fmt.Errorf("max submission retries must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Package daemon implements a lightweight finality provider for rollup
+// integration tests. It follows an OP-stack L2, keeps a window of public
+// randomness committed ahead of the L2 tip and submits a finality signature
+// to the op-finality-gadget contract for every new block, without the full
+// fpd/eotsd stack.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/l2client"
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// L2Client is the L2 endpoint the daemon follows
+type L2Client interface {
+	LatestBlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, height uint64) (*l2client.Block, error)
+}
+
+// FinalityContract is the op-finality-gadget contract the daemon submits to
+type FinalityContract interface {
+	CommitPublicRandomness(ctx context.Context, msg *opfinality.CommitPublicRandomness) (*sdk.TxResponse, error)
+	SubmitFinalitySignature(ctx context.Context, msg *opfinality.SubmitFinalitySignature) (*sdk.TxResponse, error)
+	QueryLastPubRandCommit(ctx context.Context, btcPkHex string) (*opfinality.PubRandCommit, error)
+	QueryBlockVoters(ctx context.Context, height uint64, blockHash []byte) ([]string, error)
+}
+
+// Daemon signs every new L2 block with a single finality provider key
+type Daemon struct {
+	cfg      Config
+	signer   signer.Signer
+	fpPk     *bbn.BIP340PubKey
+	l2       L2Client
+	contract FinalityContract
+	store    *store.Store
+
+	// randCache is the randomness list last used by the sign loop
+	randCache *commitRand
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a daemon signing with s. The store keeps the committed
+// randomness, or the chain id the signer derives it for, and the signing
+// history across restarts.
+func New(cfg Config, s signer.Signer, l2 L2Client, contract FinalityContract, st *store.Store) (*Daemon, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid daemon config: %w", err)
+	}
+	if st == nil {
+		return nil, fmt.Errorf("the daemon requires a store")
+	}
+
+	fpPk := s.PublicKey()
+	return &Daemon{
+		cfg:      cfg,
+		signer:   s,
+		fpPk:     fpPk,
+		l2:       l2,
+		contract: contract,
+		store:    st,
+		status: Status{
+			FpPubkeyHex: fpPk.MarshalHex(),
+		},
+	}, nil
+}
+
+// Run commits randomness and signs new blocks until ctx is cancelled. The
+// submission in flight when ctx is cancelled is completed before returning.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.updateStatus(func(s *Status) { s.StartedAt = time.Now().UTC() })
+
+	var health *healthServer
+	if d.cfg.HealthAddr != "" {
+		var err error
+		health, err = d.startHealthServer()
+		if err != nil {
+			return err
+		}
+		defer health.shutdown()
+	}
+
+	// Commit before signing so that the first blocks have randomness
+	d.commitRandomnessIfNeeded(ctx)
+
+	nextHeight, err := d.resumeHeight(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Signing L2 blocks from height %d as FP %s", nextHeight, d.fpPk.MarshalHex())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.commitLoop(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		d.signLoop(ctx, nextHeight)
+	}()
+	wg.Wait()
+
+	log.Printf("Daemon stopped")
+	return nil
+}
+
+// resumeHeight returns the first height to sign: the configured start
+// height, or the height after the last signed one. The last signed height is
+// signed again if its vote did not make it into the contract.
+func (d *Daemon) resumeHeight(ctx context.Context) (uint64, error) {
+	if d.cfg.StartHeight > 0 {
+		return d.cfg.StartHeight, nil
+	}
+
+	lastSigned, err := d.store.LastSignedHeight(d.fpPk.MustMarshal())
+	if errors.Is(err, store.ErrNotFound) {
+		// Start from the first committed height
+		commits, err := d.store.ListPubRandCommits(d.fpPk.MustMarshal())
+		if err != nil {
+			return 0, fmt.Errorf("failed to list stored commitments: %w", err)
+		}
+		if len(commits) == 0 {
+			return 1, nil
+		}
+		return commits[0].StartHeight, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load last signed height: %w", err)
+	}
+	d.updateStatus(func(s *Status) { s.LastSignedHeight = lastSigned })
+
+	record, err := d.store.GetSignRecord(d.fpPk.MustMarshal(), lastSigned)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load sign record of height %d: %w", lastSigned, err)
+	}
+	voters, err := d.contract.QueryBlockVoters(ctx, lastSigned, record.BlockHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query voters of height %d: %w", lastSigned, err)
+	}
+	for _, voter := range voters {
+		if voter == d.fpPk.MarshalHex() {
+			return lastSigned + 1, nil
+		}
+	}
+	log.Printf("Vote for last signed height %d is not in the contract, submitting it again", lastSigned)
+	return lastSigned, nil
+}
+
+// retry runs fn up to MaxSubmissionRetries times. Attempts are not
+// interrupted by ctx, so that a tx in flight at shutdown is completed, but
+// no new attempt starts once ctx is cancelled.
+func (d *Daemon) retry(ctx context.Context, what string, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= d.cfg.MaxSubmissionRetries; attempt++ {
+		err = fn(context.WithoutCancel(ctx))
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to %s (attempt %d/%d): %v", what, attempt, d.cfg.MaxSubmissionRetries, err)
+
+		if attempt == d.cfg.MaxSubmissionRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to %s before shutdown: %w", what, err)
+		case <-time.After(d.cfg.SubmissionRetryInterval):
+		}
+	}
+	return fmt.Errorf("failed to %s after %d attempts: %w", what, d.cfg.MaxSubmissionRetries, err)
+}
+
+// sleep waits for interval and reports whether ctx is still active
+func sleep(ctx context.Context, interval time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(interval):
+		return true
+	}
+}
@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Status is the state of the daemon reported by the health endpoint
+type Status struct {
+	FpPubkeyHex         string    `json:"fp_pubkey_hex"`
+	Healthy             bool      `json:"healthy"`
+	StartedAt           time.Time `json:"started_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	L2TipHeight         uint64    `json:"l2_tip_height"`
+	LastCommittedHeight uint64    `json:"last_committed_height"`
+	LastSignedHeight    uint64    `json:"last_signed_height"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorAt         time.Time `json:"last_error_at"`
+}
+
+// updateStatus applies update to the status under the lock
+func (d *Daemon) updateStatus(update func(s *Status)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	update(&d.status)
+	d.status.UpdatedAt = time.Now().UTC()
+}
+
+// reportError logs err and keeps it as the last error of the status
+func (d *Daemon) reportError(err error) {
+	log.Printf("Error: %v", err)
+	d.updateStatus(func(s *Status) {
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now().UTC()
+	})
+}
+
+// getStatus returns a copy of the status. The daemon is healthy as long as
+// it made progress within the last few poll intervals.
+func (d *Daemon) getStatus() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := d.status
+	staleAfter := 3 * max(d.cfg.PollInterval, d.cfg.RandomnessCommitInterval)
+	status.Healthy = time.Since(status.UpdatedAt) < staleAfter &&
+		(status.LastError == "" || time.Since(status.LastErrorAt) >= staleAfter)
+	return status
+}
+
+type healthServer struct {
+	server *http.Server
+}
+
+// startHealthServer serves the status as JSON on /health, with status code
+// 503 when the daemon is unhealthy
+func (d *Daemon) startHealthServer() (*healthServer, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		status := d.getStatus()
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(status)
+	})
+
+	listener, err := net.Listen("tcp", d.cfg.HealthAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", d.cfg.HealthAddr, err)
+	}
+
+	hs := &healthServer{
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+	}
+	go func() {
+		if err := hs.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Health server stopped: %v", err)
+		}
+	}()
+	log.Printf("Health endpoint listening on http://%s/health", listener.Addr())
+
+	return hs, nil
+}
+
+func (hs *healthServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = hs.server.Shutdown(ctx)
+}
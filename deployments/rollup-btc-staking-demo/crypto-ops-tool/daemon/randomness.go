@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+
+	ftypes "github.com/babylonlabs-io/babylon/v4/x/finality/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/randgen"
+	"crypto-ops-tool/store"
+)
+
+// commitLoop keeps a window of public randomness committed ahead of the L2
+// tip, checking every RandomnessCommitInterval
+func (d *Daemon) commitLoop(ctx context.Context) {
+	for sleep(ctx, d.cfg.RandomnessCommitInterval) {
+		d.commitRandomnessIfNeeded(ctx)
+	}
+}
+
+// commitRandomnessIfNeeded commits NumPubRand new randomness values when
+// the committed randomness does not cover NumPubRand heights past the tip
+// plus the timestamping delay, like the randomness committer of fpd
+func (d *Daemon) commitRandomnessIfNeeded(ctx context.Context) {
+	tip, err := d.l2.LatestBlockNumber(ctx)
+	if err != nil {
+		d.reportError(fmt.Errorf("failed to query L2 tip: %w", err))
+		return
+	}
+
+	lastCommit, err := d.contract.QueryLastPubRandCommit(ctx, d.fpPk.MarshalHex())
+	if err != nil {
+		d.reportError(fmt.Errorf("failed to query last randomness commitment: %w", err))
+		return
+	}
+	var lastCommitted uint64
+	if lastCommit != nil {
+		lastCommitted = lastCommit.EndHeight()
+	}
+	d.updateStatus(func(s *Status) {
+		s.L2TipHeight = tip
+		s.LastCommittedHeight = lastCommitted
+	})
+
+	startHeight, ok := d.nextCommitStartHeight(tip, lastCommitted)
+	if !ok {
+		return
+	}
+
+	if err := d.commitRandomness(ctx, startHeight); err != nil {
+		d.reportError(err)
+		return
+	}
+	d.updateStatus(func(s *Status) { s.LastCommittedHeight = startHeight + d.cfg.NumPubRand - 1 })
+}
+
+// nextCommitStartHeight returns the start height of the next commitment, or
+// false if the committed randomness is sufficient
+func (d *Daemon) nextCommitStartHeight(tip, lastCommitted uint64) (uint64, bool) {
+	tipWithDelay := tip + d.cfg.TimestampingDelayBlocks
+
+	var startHeight uint64
+	switch {
+	case lastCommitted < tipWithDelay:
+		// Randomness is committed before it is needed, skipping the heights
+		// the commitment cannot be timestamped for in time
+		startHeight = tipWithDelay + 1
+	case lastCommitted < tipWithDelay+d.cfg.NumPubRand:
+		startHeight = lastCommitted + 1
+	default:
+		return 0, false
+	}
+
+	return max(startHeight, d.cfg.StartHeight), true
+}
+
+// commitRandomness has the signer derive NumPubRand randomness values
+// starting at startHeight, then commits and stores them. The commitment is
+// only stored once the contract holds it, so that the sign loop never signs
+// with uncommitted randomness and a failed commit leaves nothing behind; the
+// signer derives the secret randomness again when signing.
+func (d *Daemon) commitRandomness(ctx context.Context, startHeight uint64) error {
+	prList, err := d.signer.PubRandList(ctx, []byte(d.cfg.ConsumerID), startHeight, d.cfg.NumPubRand)
+	if err != nil {
+		return fmt.Errorf("failed to derive randomness: %w", err)
+	}
+	randList, err := randgen.NewPubRandList(startHeight, prList)
+	if err != nil {
+		return fmt.Errorf("failed to build commitment: %w", err)
+	}
+
+	// Sign the commitment like datagen.GenRandomMsgCommitPubRandList
+	commitMsg := &ftypes.MsgCommitPubRandList{
+		FpBtcPk:     d.fpPk,
+		StartHeight: startHeight,
+		NumPubRand:  d.cfg.NumPubRand,
+		Commitment:  randList.Commitment,
+	}
+	hash, err := commitMsg.HashToSign()
+	if err != nil {
+		return fmt.Errorf("failed to hash commitment: %w", err)
+	}
+	sig, err := d.signer.SignSchnorr(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign commitment: %w", err)
+	}
+
+	msg := &opfinality.CommitPublicRandomness{
+		FpPubkeyHex: d.fpPk.MarshalHex(),
+		StartHeight: startHeight,
+		NumPubRand:  d.cfg.NumPubRand,
+		Commitment:  randList.Commitment,
+		Signature:   sig.Serialize(),
+	}
+	err = d.retry(ctx, "commit public randomness", func(ctx context.Context) error {
+		res, err := d.contract.CommitPublicRandomness(ctx, msg)
+		if err != nil {
+			// An earlier attempt may have been included although waiting
+			// for it failed, in which case this one is rejected
+			if held, queryErr := d.holdsCommitment(ctx, startHeight, randList.Commitment); queryErr == nil && held {
+				log.Printf("Randomness for heights %d to %d was committed by an earlier attempt", startHeight, startHeight+d.cfg.NumPubRand-1)
+				return nil
+			}
+			return err
+		}
+		log.Printf("Committed randomness for heights %d to %d in tx %s", startHeight, startHeight+d.cfg.NumPubRand-1, res.TxHash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.store.SavePubRandCommit(&store.PubRandCommit{
+		FpBtcPk:     d.fpPk.MustMarshal(),
+		StartHeight: startHeight,
+		NumPubRand:  d.cfg.NumPubRand,
+		Commitment:  randList.Commitment,
+		ChainID:     []byte(d.cfg.ConsumerID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store commitment: %w", err)
+	}
+	return nil
+}
+
+// holdsCommitment reports whether the last commitment of the FP in the
+// contract is the one starting at startHeight
+func (d *Daemon) holdsCommitment(ctx context.Context, startHeight uint64, commitment []byte) (bool, error) {
+	last, err := d.contract.QueryLastPubRandCommit(ctx, d.fpPk.MarshalHex())
+	if err != nil {
+		return false, err
+	}
+	return last != nil && last.StartHeight == startHeight && bytes.Equal(last.Commitment, commitment), nil
+}
@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/randgen"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// signLoop signs every L2 block from nextHeight on, polling the tip every
+// PollInterval
+func (d *Daemon) signLoop(ctx context.Context, nextHeight uint64) {
+	for {
+		nextHeight = d.signNewBlocks(ctx, nextHeight)
+		if !sleep(ctx, d.cfg.PollInterval) {
+			return
+		}
+	}
+}
+
+// signNewBlocks signs the blocks from nextHeight up to the L2 tip and
+// returns the next height to sign
+func (d *Daemon) signNewBlocks(ctx context.Context, nextHeight uint64) uint64 {
+	tip, err := d.l2.LatestBlockNumber(ctx)
+	if err != nil {
+		d.reportError(fmt.Errorf("failed to query L2 tip: %w", err))
+		return nextHeight
+	}
+	d.updateStatus(func(s *Status) { s.L2TipHeight = tip })
+
+	for nextHeight <= tip && ctx.Err() == nil {
+		randList, randIndex, err := d.randomness(ctx, nextHeight)
+		if errors.Is(err, errSkipHeight) {
+			log.Printf("Skipping height %d: %v", nextHeight, err)
+			nextHeight++
+			continue
+		}
+		if err != nil {
+			// Wait for the commit loop to commit randomness for the height
+			log.Printf("Waiting to sign height %d: %v", nextHeight, err)
+			return nextHeight
+		}
+
+		err = d.signBlock(ctx, nextHeight, randList, randIndex)
+		if errors.Is(err, errSkipHeight) {
+			d.reportError(fmt.Errorf("skipping height %d: %w", nextHeight, err))
+			nextHeight++
+			continue
+		}
+		if err != nil {
+			d.reportError(err)
+			return nextHeight
+		}
+		d.updateStatus(func(s *Status) {
+			s.LastSignedHeight = nextHeight
+			s.LastError = ""
+		})
+		nextHeight++
+	}
+	return nextHeight
+}
+
+// errSkipHeight is returned for heights the daemon can never sign
+var errSkipHeight = errors.New("height cannot be signed")
+
+// commitRand is the randomness list of a stored commitment. A list without
+// secret randomness is signed with by the signer deriving it again for
+// chainID.
+type commitRand struct {
+	*randgen.RandList
+	chainID []byte
+}
+
+// randomness returns the stored randomness list covering height and the
+// index of height in it
+func (d *Daemon) randomness(ctx context.Context, height uint64) (*commitRand, int, error) {
+	commit, err := d.store.GetPubRandCommit(d.fpPk.MustMarshal(), height)
+	if errors.Is(err, store.ErrNotFound) {
+		// Heights below the last committed one are either before the first
+		// commitment, in the gap left by the timestamping delay, or covered
+		// by randomness committed without this store
+		if height <= d.getStatus().LastCommittedHeight {
+			return nil, 0, fmt.Errorf("%w: no stored randomness covers it", errSkipHeight)
+		}
+		return nil, 0, fmt.Errorf("no randomness committed yet")
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load commitment: %w", err)
+	}
+
+	// Rebuilding the merkle proofs of a commitment is costly, so the list of
+	// the current commitment is kept around
+	if d.randCache != nil && d.randCache.StartHeight == commit.StartHeight &&
+		bytes.Equal(d.randCache.Commitment, commit.Commitment) {
+		return d.randCache, int(height - commit.StartHeight), nil
+	}
+
+	randList, err := d.rebuildRandList(ctx, commit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(randList.Commitment, commit.Commitment) {
+		return nil, 0, fmt.Errorf("stored randomness does not match stored commitment %x", commit.Commitment)
+	}
+	d.randCache = &commitRand{RandList: randList, chainID: commit.ChainID}
+	return d.randCache, int(height - commit.StartHeight), nil
+}
+
+// rebuildRandList rebuilds the randomness list of a stored commitment from
+// the public randomness the signer derives for its chain id
+func (d *Daemon) rebuildRandList(ctx context.Context, commit *store.PubRandCommit) (*randgen.RandList, error) {
+	prList, err := d.signer.PubRandList(ctx, commit.ChainID, commit.StartHeight, commit.NumPubRand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive randomness: %w", err)
+	}
+	randList, err := randgen.NewPubRandList(commit.StartHeight, prList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild randomness list: %w", err)
+	}
+	return randList, nil
+}
+
+// signBlock signs the L2 block at height, records the signature and submits
+// it to the finality contract
+func (d *Daemon) signBlock(ctx context.Context, height uint64, randList *commitRand, randIndex int) error {
+	block, err := d.l2.BlockByNumber(ctx, height)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L2 block %d: %w", height, err)
+	}
+
+	// Never sign a second block at the same height, that would leak the key
+	fpBtcPk := d.fpPk.MustMarshal()
+	if err := d.store.CheckDoubleSign(fpBtcPk, height, block.Hash); err != nil {
+		if errors.Is(err, store.ErrDoubleSign) {
+			return fmt.Errorf("%w: slashing protection: %v", errSkipHeight, err)
+		}
+		return fmt.Errorf("slashing protection: %w", err)
+	}
+
+	msgToSign := append(sdk.Uint64ToBigEndian(height), block.Hash...)
+	var sr *btcec.ModNScalar
+	if len(randList.SRList) > 0 {
+		sr = randList.SRList[randIndex]
+	}
+	sig, err := signer.SignEOTSWithRand(ctx, d.signer, randList.chainID, height, sr, msgToSign)
+	if err != nil {
+		return fmt.Errorf("failed to sign height %d: %w", height, err)
+	}
+	eotsSig := bbn.NewSchnorrEOTSSigFromModNScalar(sig).MustMarshal()
+
+	err = d.store.SaveSignRecord(&store.SignRecord{
+		FpBtcPk:   fpBtcPk,
+		Height:    height,
+		BlockHash: block.Hash,
+		Signature: eotsSig,
+	}, false)
+	if err != nil {
+		return fmt.Errorf("failed to record signature of height %d: %w", height, err)
+	}
+
+	msg := &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: d.fpPk.MarshalHex(),
+		Height:      height,
+		PubRand:     bbn.NewSchnorrPubRandFromFieldVal(randList.PRList[randIndex]).MustMarshal(),
+		Proof:       opfinality.NewProof(randList.ProofList[randIndex]),
+		BlockHash:   block.Hash,
+		Signature:   eotsSig,
+	}
+	return d.retry(ctx, fmt.Sprintf("submit finality signature for height %d", height), func(ctx context.Context) error {
+		res, err := d.contract.SubmitFinalitySignature(ctx, msg)
+		if err != nil {
+			return err
+		}
+		log.Printf("Submitted finality signature for height %d (hash %x) in tx %s", height, block.Hash, res.TxHash)
+		return nil
+	})
+}
@@ -0,0 +1,165 @@
+package demo
+
+import (
+	"fmt"
+	"time"
+
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+)
+
+// Config holds the parameters of a demo run. The defaults are the values
+// hard-coded in rollup-btc-staking-demo.sh.
+type Config struct {
+	// ContractWasmPath is the op-finality-gadget WASM byte code to deploy
+	ContractWasmPath string
+	// ContractLabel is the label of the contract instance
+	ContractLabel string
+	// ConsumerID is the id the consumer is registered under, also mixed
+	// into the derived EOTS randomness
+	ConsumerID string
+	// ConsumerName and ConsumerDescription describe the consumer
+	ConsumerName        string
+	ConsumerDescription string
+	// MaxMultiStakedFps is the maximum number of FPs of the consumer a
+	// delegation may stake to
+	MaxMultiStakedFps uint32
+	// BabylonFpKey and ConsumerFpKey are the names of the FP keys
+	BabylonFpKey  string
+	ConsumerFpKey string
+	// CommissionRate, CommissionMaxRate and CommissionMaxChangeRate are the
+	// commission parameters of both FPs, as decimals
+	CommissionRate          string
+	CommissionMaxRate       string
+	CommissionMaxChangeRate string
+	// StakingAmount is the delegated amount in satoshis
+	StakingAmount int64
+	// StakingTimeBlocks is the staking time lock in BTC blocks
+	StakingTimeBlocks int64
+	// ActivationPollInterval is the interval between each check of the
+	// active delegations
+	ActivationPollInterval time.Duration
+	// RequireActivation fails the run when the delegation is not active
+	// within the timeout of the activation step, instead of proceeding
+	// with a warning like the script
+	RequireActivation bool
+	// StartHeight is the first height of the committed randomness and of
+	// the finality signatures
+	StartHeight uint64
+	// NumPubRand is the number of committed public randomness values
+	NumPubRand uint64
+	// NumFinalitySigs is the number of heights signed from StartHeight
+	NumFinalitySigs uint64
+	// VerifyRetries is the number of block voter queries made to find a
+	// submitted signature
+	VerifyRetries int
+	// VerifyRetryInterval is the interval between those queries
+	VerifyRetryInterval time.Duration
+	// StepTimeouts bound each step; a step without a timeout is bound by
+	// DefaultStepTimeout
+	StepTimeouts map[Step]time.Duration
+	// DefaultStepTimeout bounds the steps missing from StepTimeouts
+	DefaultStepTimeout time.Duration
+	// StateFile receives the summary of the run after each step, to resume
+	// the run from with LoadState and Resume. No state is saved when it is
+	// empty.
+	StateFile string
+}
+
+// DefaultConfig returns the settings of rollup-btc-staking-demo.sh
+func DefaultConfig() Config {
+	return Config{
+		ContractWasmPath:        "artifacts/contracts/op_finality_gadget.wasm",
+		ContractLabel:           "finality",
+		ConsumerID:              "consumer-id",
+		ConsumerName:            "consumer-name",
+		ConsumerDescription:     "consumer-description",
+		MaxMultiStakedFps:       2,
+		BabylonFpKey:            "bbn-fp",
+		ConsumerFpKey:           "consumer-fp",
+		CommissionRate:          "0.05",
+		CommissionMaxRate:       "0.10",
+		CommissionMaxChangeRate: "0.01",
+		StakingAmount:           1000000,
+		StakingTimeBlocks:       10000,
+		ActivationPollInterval:  10 * time.Second,
+		RequireActivation:       false,
+		StartHeight:             1,
+		NumPubRand:              1000,
+		NumFinalitySigs:         10,
+		VerifyRetries:           5,
+		VerifyRetryInterval:     5 * time.Second,
+		StepTimeouts: map[Step]time.Duration{
+			StepDeployContract:    3 * time.Minute,
+			StepRegisterConsumer:  2 * time.Minute,
+			StepGenerateKeys:      1 * time.Minute,
+			StepCreateFPs:         3 * time.Minute,
+			StepStakeBTC:          3 * time.Minute,
+			StepWaitActivation:    5 * time.Minute,
+			StepCommitAndFinalize: 10 * time.Minute,
+		},
+		DefaultStepTimeout: 5 * time.Minute,
+		StateFile:          "",
+	}
+}
+
+// Validate checks that the configuration is usable
+func (cfg *Config) Validate() error {
+	if cfg.ContractWasmPath == "" {
+		return fmt.Errorf("contract wasm path must be set")
+	}
+	if cfg.ConsumerID == "" {
+		return fmt.Errorf("consumer id must be set")
+	}
+	if cfg.BabylonFpKey == "" || cfg.ConsumerFpKey == "" {
+		return fmt.Errorf("FP key names must be set")
+	}
+	if cfg.BabylonFpKey == cfg.ConsumerFpKey {
+		return fmt.Errorf("the Babylon and consumer FPs must use different keys")
+	}
+	if _, err := cfg.commission(); err != nil {
+		return err
+	}
+	if cfg.StakingAmount <= 0 {
+		return fmt.Errorf("staking amount must be positive")
+	}
+	if cfg.StakingTimeBlocks <= 0 {
+		return fmt.Errorf("staking time must be positive")
+	}
+	if cfg.ActivationPollInterval <= 0 {
+		return fmt.Errorf("activation poll interval must be positive")
+	}
+	if cfg.NumPubRand == 0 {
+		return fmt.Errorf("num pub rand must be positive")
+	}
+	if cfg.NumFinalitySigs > cfg.NumPubRand {
+		return fmt.Errorf("cannot sign %d heights with %d committed randomness values", cfg.NumFinalitySigs, cfg.NumPubRand)
+	}
+	if cfg.VerifyRetries <= 0 {
+		return fmt.Errorf("verify retries must be positive")
+	}
+	for step, timeout := range cfg.StepTimeouts {
+		if !step.valid() {
+			return fmt.Errorf("timeout of unknown step %q", step)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("timeout of step %s must be positive", step)
+		}
+	}
+	if cfg.DefaultStepTimeout <= 0 {
+		return fmt.Errorf("default step timeout must be positive")
+	}
+	return nil
+}
+
+// stepTimeout returns the timeout of step
+func (cfg *Config) stepTimeout(step Step) time.Duration {
+	if timeout, ok := cfg.StepTimeouts[step]; ok {
+		return timeout
+	}
+	return cfg.DefaultStepTimeout
+}
+
+// commission parses the commission parameters of the FPs
+func (cfg *Config) commission() (bstypes.CommissionRates, error) {
+	return ParseCommission(cfg.CommissionRate, cfg.CommissionMaxRate, cfg.CommissionMaxChangeRate)
+}
@@ -0,0 +1,263 @@
+// Package demo runs the end-to-end flow of rollup-btc-staking-demo.sh
+// against a running deployment: it deploys the finality contract, registers
+// the consumer, creates a Babylon and a consumer finality provider, stakes
+// BTC to both, waits for the delegation to become active, and has the
+// consumer FP commit randomness and sign blocks. Every step is bounded by its
+// own timeout and yields a typed result, and a run ends with a Summary, so
+// that integration tests can drive the flow, or single steps of it, from Go.
+// The summary is saved to a state file after each step, from which an
+// interrupted run can be resumed.
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/stakerclient"
+	"crypto-ops-tool/store"
+)
+
+// Step names a step of the demo
+type Step string
+
+// The steps of the demo, in the order they run
+const (
+	StepDeployContract    Step = "deploy-contract"
+	StepRegisterConsumer  Step = "register-consumer"
+	StepGenerateKeys      Step = "generate-keys"
+	StepCreateFPs         Step = "create-fps"
+	StepStakeBTC          Step = "stake-btc"
+	StepWaitActivation    Step = "wait-activation"
+	StepCommitAndFinalize Step = "commit-finalize"
+)
+
+// Steps lists the steps of the demo in the order they run
+var Steps = []Step{
+	StepDeployContract,
+	StepRegisterConsumer,
+	StepGenerateKeys,
+	StepCreateFPs,
+	StepStakeBTC,
+	StepWaitActivation,
+	StepCommitAndFinalize,
+}
+
+func (s Step) valid() bool {
+	for _, step := range Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Chain is the Babylon node the demo transacts with, paying with a single
+// key. It is satisfied by bbnclient.Client.
+type Chain interface {
+	opfinality.ExecuteQuerier
+	Address() string
+	BroadcastAndWait(ctx context.Context, msgs ...sdk.Msg) (*sdk.TxResponse, error)
+	StoreCode(ctx context.Context, wasmByteCode []byte) (uint64, *sdk.TxResponse, error)
+	InstantiateContract(ctx context.Context, codeID uint64, admin, label string, msg []byte) (string, *sdk.TxResponse, error)
+	QueryFinalityProviders(ctx context.Context) ([]*bstypes.FinalityProviderResponse, error)
+	QueryConsumerFinalityProviders(ctx context.Context, consumerID string) ([]*bsctypes.FinalityProviderResponse, error)
+	QueryBTCDelegations(ctx context.Context, status bstypes.BTCDelegationStatus) ([]*bstypes.BTCDelegationResponse, error)
+	QueryBTCDelegation(ctx context.Context, stakingTxHashHex string) (*bstypes.BTCDelegationResponse, error)
+	QueryConsumerRegistration(ctx context.Context, consumerID string) (*bsctypes.ConsumerRegisterResponse, error)
+}
+
+// Staker delegates BTC. It is satisfied by stakerclient.Client.
+type Staker interface {
+	StakerAddresses(ctx context.Context) ([]string, error)
+	Stake(ctx context.Context, req stakerclient.StakeRequest) (string, error)
+}
+
+// Keys creates the FP keys of the demo
+type Keys interface {
+	// NewKey creates the key name, the index-th FP key of the demo, and
+	// returns a signer of it
+	NewKey(ctx context.Context, name string, index uint32) (signer.Signer, error)
+	// Key returns a signer of the existing key name
+	Key(ctx context.Context, name string) (signer.Signer, error)
+}
+
+// Demo runs the steps of the demo. The result of each step is kept in the
+// summary and read by the following steps.
+type Demo struct {
+	cfg    Config
+	chain  Chain
+	staker Staker
+	keys   Keys
+	store  *store.Store
+
+	// bbnFp and consumerFp sign for the FPs once the keys are generated
+	bbnFp      signer.Signer
+	consumerFp signer.Signer
+
+	summary Summary
+}
+
+// New creates a demo transacting on chain and staking with staker. The
+// store keeps the randomness and the signatures of the consumer FP, so that
+// follow-up scenarios can sign with it again.
+func New(cfg Config, chain Chain, staker Staker, keys Keys, st *store.Store) (*Demo, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid demo config: %w", err)
+	}
+	if st == nil {
+		return nil, fmt.Errorf("the demo requires a store")
+	}
+	return &Demo{
+		cfg:    cfg,
+		chain:  chain,
+		staker: staker,
+		keys:   keys,
+		store:  st,
+	}, nil
+}
+
+// Run runs every step in order and returns the summary of the run. It
+// stops at the first failing step; the summary then records the steps that
+// completed and the error of the failing one.
+func (d *Demo) Run(ctx context.Context) (*Summary, error) {
+	return d.Resume(ctx, nil)
+}
+
+// Resume runs the steps that the earlier run summarized by prev did not
+// complete, and returns the summary of the whole run. A step prev completed
+// is only validated against the chain state; it runs again if its result no
+// longer holds or a step it builds on ran again. Resume with a nil prev is
+// Run.
+func (d *Demo) Resume(ctx context.Context, prev *Summary) (*Summary, error) {
+	start := time.Now()
+	err := d.run(ctx, prev)
+	d.summary.Success = err == nil
+	d.summary.Duration = Duration(time.Since(start))
+	if saveErr := d.saveState(); saveErr != nil {
+		if err == nil {
+			return &d.summary, saveErr
+		}
+		log.Printf("%v", saveErr)
+	}
+	return &d.summary, err
+}
+
+func (d *Demo) run(ctx context.Context, prev *Summary) error {
+	if prev != nil {
+		if err := d.restore(prev); err != nil {
+			return err
+		}
+	}
+
+	ran := map[Step]bool{}
+	for i, step := range Steps {
+		if prev != nil && prev.completed(step) && !dependsOnAny(step, ran) {
+			err := d.resumeStep(ctx, step)
+			if err == nil {
+				continue
+			}
+			log.Printf("Step %s no longer holds, running it again: %v", step, err)
+			d.clearResult(step)
+		}
+		ran[step] = true
+		if err := d.RunStep(ctx, step); err != nil {
+			for _, skipped := range Steps[i+1:] {
+				d.summary.Steps = append(d.summary.Steps, StepResult{Step: skipped, Status: StatusSkipped})
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary returns the results of the steps run so far
+func (d *Demo) Summary() *Summary {
+	return &d.summary
+}
+
+// RunStep runs a single step, bounded by its timeout, and records its
+// result in the summary. The steps it depends on must have run before.
+func (d *Demo) RunStep(ctx context.Context, step Step) error {
+	var run func(ctx context.Context) error
+	switch step {
+	case StepDeployContract:
+		run = d.deployContract
+	case StepRegisterConsumer:
+		run = d.registerConsumer
+	case StepGenerateKeys:
+		run = d.generateKeys
+	case StepCreateFPs:
+		run = d.createFinalityProviders
+	case StepStakeBTC:
+		run = d.stakeBTC
+	case StepWaitActivation:
+		run = d.waitForActivation
+	case StepCommitAndFinalize:
+		run = d.commitAndFinalize
+	default:
+		return fmt.Errorf("unknown step %q", step)
+	}
+
+	timeout := d.cfg.stepTimeout(step)
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Printf("Step %s (timeout %v)", step, timeout)
+	start := time.Now()
+	err := run(stepCtx)
+	result := StepResult{Step: step, Status: StatusOK, Duration: Duration(time.Since(start))}
+
+	var warning *stepWarning
+	switch {
+	case errors.As(err, &warning):
+		result.Status = StatusWarning
+		result.Warning = warning.Error()
+		log.Printf("Step %s completed with a warning: %v", step, warning)
+		err = nil
+	case err != nil:
+		if stepCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			err = fmt.Errorf("timed out after %v: %w", timeout, err)
+		}
+		err = fmt.Errorf("step %s failed: %w", step, err)
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	default:
+		log.Printf("Step %s completed in %v", step, time.Duration(result.Duration).Round(time.Millisecond))
+	}
+	d.summary.Steps = append(d.summary.Steps, result)
+	if saveErr := d.saveState(); saveErr != nil {
+		if err == nil {
+			return saveErr
+		}
+		log.Printf("%v", saveErr)
+	}
+	return err
+}
+
+// stepWarning is returned by a step that completed without reaching its
+// goal, when the demo proceeds anyway
+type stepWarning struct {
+	msg string
+}
+
+func (w *stepWarning) Error() string {
+	return w.msg
+}
+
+// requireResult fails a step whose input, the result of an earlier step, is
+// missing
+func requireResult(ok bool, step Step) error {
+	if !ok {
+		return fmt.Errorf("step %s has not run", step)
+	}
+	return nil
+}
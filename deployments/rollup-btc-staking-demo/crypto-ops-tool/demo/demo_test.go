@@ -0,0 +1,562 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/stakerclient"
+	"crypto-ops-tool/store"
+)
+
+// fakeChain is a Babylon node holding a single finality contract. It
+// records the calls made to it, and hangs until the context is done in the
+// calls listed in hang.
+type fakeChain struct {
+	mu    sync.Mutex
+	calls map[string]int
+	hang  map[string]bool
+
+	contractAddr string
+	contractCfg  *opfinality.Config
+	consumers    map[string]*bsctypes.ConsumerRegisterResponse
+	bbnFps       []*bstypes.FinalityProviderResponse
+	consumerFps  map[string][]*bsctypes.FinalityProviderResponse
+	delegations  map[string]*bstypes.BTCDelegationResponse
+	commits      map[string][]*opfinality.PubRandCommit
+	voters       map[string][]string
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		calls:       map[string]int{},
+		hang:        map[string]bool{},
+		consumers:   map[string]*bsctypes.ConsumerRegisterResponse{},
+		consumerFps: map[string][]*bsctypes.FinalityProviderResponse{},
+		delegations: map[string]*bstypes.BTCDelegationResponse{},
+		commits:     map[string][]*opfinality.PubRandCommit{},
+		voters:      map[string][]string{},
+	}
+}
+
+// call records a call of method and hangs if it should
+func (f *fakeChain) call(ctx context.Context, method string) error {
+	f.mu.Lock()
+	f.calls[method]++
+	hang := f.hang[method]
+	f.mu.Unlock()
+	if hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (f *fakeChain) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeChain) Address() string {
+	return sdk.AccAddress(bytes.Repeat([]byte{0x01}, 20)).String()
+}
+
+func (f *fakeChain) txResponse(method string) *sdk.TxResponse {
+	return &sdk.TxResponse{TxHash: fmt.Sprintf("%s-%d", method, f.calls[method])}
+}
+
+func (f *fakeChain) BroadcastAndWait(ctx context.Context, msgs ...sdk.Msg) (*sdk.TxResponse, error) {
+	if err := f.call(ctx, "BroadcastAndWait"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case *bsctypes.MsgRegisterConsumer:
+			f.consumers[msg.ConsumerId] = &bsctypes.ConsumerRegisterResponse{
+				ConsumerId:                    msg.ConsumerId,
+				RollupFinalityContractAddress: msg.RollupFinalityContractAddress,
+			}
+		case *bstypes.MsgCreateFinalityProvider:
+			if msg.ConsumerId == "" {
+				f.bbnFps = append(f.bbnFps, &bstypes.FinalityProviderResponse{BtcPk: msg.BtcPk})
+			} else {
+				f.consumerFps[msg.ConsumerId] = append(f.consumerFps[msg.ConsumerId], &bsctypes.FinalityProviderResponse{BtcPk: msg.BtcPk})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected message %T", msg)
+		}
+	}
+	return f.txResponse("BroadcastAndWait"), nil
+}
+
+func (f *fakeChain) StoreCode(ctx context.Context, wasmByteCode []byte) (uint64, *sdk.TxResponse, error) {
+	if err := f.call(ctx, "StoreCode"); err != nil {
+		return 0, nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return uint64(f.calls["StoreCode"]), f.txResponse("StoreCode"), nil
+}
+
+func (f *fakeChain) InstantiateContract(ctx context.Context, codeID uint64, admin, label string, msg []byte) (string, *sdk.TxResponse, error) {
+	if err := f.call(ctx, "InstantiateContract"); err != nil {
+		return "", nil, err
+	}
+	var instantiateMsg opfinality.InstantiateMsg
+	if err := json.Unmarshal(msg, &instantiateMsg); err != nil {
+		return "", nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.contractAddr = fmt.Sprintf("contract-%d", f.calls["InstantiateContract"])
+	f.contractCfg = &opfinality.Config{ConsumerID: instantiateMsg.ConsumerID}
+	f.commits = map[string][]*opfinality.PubRandCommit{}
+	f.voters = map[string][]string{}
+	return f.contractAddr, f.txResponse("InstantiateContract"), nil
+}
+
+func (f *fakeChain) ExecuteContract(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error) {
+	if err := f.call(ctx, "ExecuteContract"); err != nil {
+		return nil, err
+	}
+	var execMsg opfinality.ExecuteMsg
+	if err := json.Unmarshal(msg, &execMsg); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if contractAddr != f.contractAddr {
+		return nil, fmt.Errorf("no contract at %s", contractAddr)
+	}
+	switch {
+	case execMsg.CommitPublicRandomness != nil:
+		c := execMsg.CommitPublicRandomness
+		f.commits[c.FpPubkeyHex] = append(f.commits[c.FpPubkeyHex], &opfinality.PubRandCommit{
+			StartHeight: c.StartHeight,
+			NumPubRand:  c.NumPubRand,
+			Commitment:  c.Commitment,
+		})
+	case execMsg.SubmitFinalitySignature != nil:
+		s := execMsg.SubmitFinalitySignature
+		block := blockKey(s.Height, s.BlockHash)
+		f.voters[block] = append(f.voters[block], s.FpPubkeyHex)
+	default:
+		return nil, fmt.Errorf("unexpected execute message %s", msg)
+	}
+	return f.txResponse("ExecuteContract"), nil
+}
+
+func (f *fakeChain) ExecuteContractBatch(ctx context.Context, contractAddr string, msgs [][]byte) (*sdk.TxResponse, error) {
+	return nil, fmt.Errorf("not used by the demo")
+}
+
+func (f *fakeChain) ExecuteContractFromKey(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error) {
+	return nil, fmt.Errorf("not used by the demo")
+}
+
+func blockKey(height uint64, blockHash []byte) string {
+	return fmt.Sprintf("%d/%x", height, blockHash)
+}
+
+func (f *fakeChain) QuerySmart(ctx context.Context, contractAddr string, query []byte) ([]byte, error) {
+	if err := f.call(ctx, "QuerySmart"); err != nil {
+		return nil, err
+	}
+	var q opfinality.QueryMsg
+	if err := json.Unmarshal(query, &q); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if contractAddr != f.contractAddr {
+		return nil, fmt.Errorf("no contract at %s", contractAddr)
+	}
+	var res interface{}
+	switch {
+	case q.Config != nil:
+		res = f.contractCfg
+	case q.BlockVoters != nil:
+		blockHash, err := hex.DecodeString(q.BlockVoters.Hash)
+		if err != nil {
+			return nil, err
+		}
+		res = f.voters[blockKey(q.BlockVoters.Height, blockHash)]
+	case q.FirstPubRandCommit != nil:
+		if commits := f.commits[q.FirstPubRandCommit.BtcPkHex]; len(commits) > 0 {
+			res = commits[0]
+		}
+	case q.LastPubRandCommit != nil:
+		if commits := f.commits[q.LastPubRandCommit.BtcPkHex]; len(commits) > 0 {
+			res = commits[len(commits)-1]
+		}
+	default:
+		return nil, fmt.Errorf("unexpected query %s", query)
+	}
+	return json.Marshal(res)
+}
+
+func (f *fakeChain) QueryFinalityProviders(ctx context.Context) ([]*bstypes.FinalityProviderResponse, error) {
+	if err := f.call(ctx, "QueryFinalityProviders"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bbnFps, nil
+}
+
+func (f *fakeChain) QueryConsumerFinalityProviders(ctx context.Context, consumerID string) ([]*bsctypes.FinalityProviderResponse, error) {
+	if err := f.call(ctx, "QueryConsumerFinalityProviders"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.consumerFps[consumerID], nil
+}
+
+func (f *fakeChain) QueryBTCDelegations(ctx context.Context, status bstypes.BTCDelegationStatus) ([]*bstypes.BTCDelegationResponse, error) {
+	if err := f.call(ctx, "QueryBTCDelegations"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var dels []*bstypes.BTCDelegationResponse
+	for _, del := range f.delegations {
+		if del.Active {
+			dels = append(dels, del)
+		}
+	}
+	return dels, nil
+}
+
+func (f *fakeChain) QueryBTCDelegation(ctx context.Context, stakingTxHashHex string) (*bstypes.BTCDelegationResponse, error) {
+	if err := f.call(ctx, "QueryBTCDelegation"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	del, ok := f.delegations[stakingTxHashHex]
+	if !ok {
+		return nil, fmt.Errorf("delegation %s not found", stakingTxHashHex)
+	}
+	return del, nil
+}
+
+func (f *fakeChain) QueryConsumerRegistration(ctx context.Context, consumerID string) (*bsctypes.ConsumerRegisterResponse, error) {
+	if err := f.call(ctx, "QueryConsumerRegistration"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	consumer, ok := f.consumers[consumerID]
+	if !ok {
+		return nil, fmt.Errorf("consumer %s not registered", consumerID)
+	}
+	return consumer, nil
+}
+
+// fakeStaker delegates on chain, with the delegations becoming active at
+// once unless inactive is set
+type fakeStaker struct {
+	chain    *fakeChain
+	inactive bool
+}
+
+func (s *fakeStaker) StakerAddresses(ctx context.Context) ([]string, error) {
+	return []string{"bcrt1qstaker"}, nil
+}
+
+func (s *fakeStaker) Stake(ctx context.Context, req stakerclient.StakeRequest) (string, error) {
+	if err := s.chain.call(ctx, "Stake"); err != nil {
+		return "", err
+	}
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+
+	// A staking tx unique to each delegation
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(req.StakingAmount, []byte{byte(len(s.chain.delegations))}))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	txHash := tx.TxHash().String()
+	s.chain.delegations[txHash] = &bstypes.BTCDelegationResponse{
+		StakingTxHex: hex.EncodeToString(buf.Bytes()),
+		Active:       !s.inactive,
+		StatusDesc:   "PENDING",
+	}
+	return txHash, nil
+}
+
+// fakeKeys derives the index-th key from the private key index+1
+type fakeKeys struct {
+	keys map[string]signer.Signer
+}
+
+func (k *fakeKeys) NewKey(ctx context.Context, name string, index uint32) (signer.Signer, error) {
+	if _, ok := k.keys[name]; ok {
+		return nil, fmt.Errorf("key %s exists", name)
+	}
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{byte(index + 1)}, 32))
+	k.keys[name] = signer.NewLocal(sk)
+	return k.keys[name], nil
+}
+
+func (k *fakeKeys) Key(ctx context.Context, name string) (signer.Signer, error) {
+	s, ok := k.keys[name]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", name)
+	}
+	return s, nil
+}
+
+// testEnv holds the fakes a demo runs against, shared by the demos of a
+// test so that a resumed run sees the state of the earlier one
+type testEnv struct {
+	cfg    Config
+	chain  *fakeChain
+	staker *fakeStaker
+	keys   *fakeKeys
+	store  *store.Store
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ContractWasmPath = filepath.Join(dir, "finality.wasm")
+	if err := os.WriteFile(cfg.ContractWasmPath, []byte("\x00asm"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.NumPubRand = 10
+	cfg.NumFinalitySigs = 3
+	cfg.ActivationPollInterval = time.Millisecond
+	cfg.VerifyRetryInterval = time.Millisecond
+	cfg.StateFile = filepath.Join(dir, "state.json")
+
+	st, err := store.Open(filepath.Join(dir, "home"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	chain := newFakeChain()
+	return &testEnv{
+		cfg:    cfg,
+		chain:  chain,
+		staker: &fakeStaker{chain: chain},
+		keys:   &fakeKeys{keys: map[string]signer.Signer{}},
+		store:  st,
+	}
+}
+
+func (e *testEnv) newDemo(t *testing.T) *Demo {
+	t.Helper()
+	d, err := New(e.cfg, e.chain, e.staker, e.keys, e.store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// statuses returns the status of every step of summary, with resumed steps
+// marked by a trailing *
+func statuses(summary *Summary) string {
+	var s []string
+	for _, result := range summary.Steps {
+		status := string(result.Status)
+		if result.Resumed {
+			status += "*"
+		}
+		s = append(s, fmt.Sprintf("%s=%s", result.Step, status))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestRun(t *testing.T) {
+	env := newTestEnv(t)
+	summary, err := env.newDemo(t).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Success || len(summary.Steps) != len(Steps) {
+		t.Fatalf("steps %s", statuses(summary))
+	}
+	for _, result := range summary.Steps {
+		if result.Status != StatusOK || result.Resumed {
+			t.Fatalf("steps %s", statuses(summary))
+		}
+	}
+	if fmt.Sprint(summary.Finality.SignedHeights) != "[1 2 3]" {
+		t.Errorf("signed heights %v", summary.Finality.SignedHeights)
+	}
+
+	saved, err := LoadState(env.cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Success || statuses(saved) != statuses(summary) {
+		t.Errorf("saved steps %s", statuses(saved))
+	}
+}
+
+func TestRunStepTimeout(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.StepTimeouts[StepStakeBTC] = 20 * time.Millisecond
+	env.chain.hang["Stake"] = true
+
+	summary, err := env.newDemo(t).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "step stake-btc failed: timed out after 20ms") {
+		t.Fatalf("err = %v, want the stake step to time out", err)
+	}
+	want := "deploy-contract=ok register-consumer=ok generate-keys=ok create-fps=ok stake-btc=failed wait-activation=skipped commit-finalize=skipped"
+	if got := statuses(summary); got != want {
+		t.Fatalf("steps %s, want %s", got, want)
+	}
+	if summary.Success || summary.Delegation != nil {
+		t.Fatal("failed run recorded a delegation")
+	}
+}
+
+func TestRunActivationTimeout(t *testing.T) {
+	for _, require := range []bool{false, true} {
+		t.Run(fmt.Sprintf("require=%v", require), func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.StepTimeouts[StepWaitActivation] = 20 * time.Millisecond
+			env.cfg.RequireActivation = require
+			env.staker.inactive = true
+
+			summary, err := env.newDemo(t).Run(context.Background())
+			result := summary.Steps[5]
+			if require {
+				if err == nil || result.Status != StatusFailed {
+					t.Fatalf("err = %v, steps %s, want the activation to fail", err, statuses(summary))
+				}
+				return
+			}
+			// Like the script, the run proceeds without the delegation
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != StatusWarning || summary.Activation.Activated {
+				t.Fatalf("steps %s, want a warning", statuses(summary))
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := map[string]struct {
+		// lose undoes on chain the result of a step of the earlier run
+		lose func(chain *fakeChain)
+		want string
+	}{
+		"nothing lost": {
+			lose: func(*fakeChain) {},
+			want: "deploy-contract=ok* register-consumer=ok* generate-keys=ok* create-fps=ok* stake-btc=ok* wait-activation=ok* commit-finalize=ok*",
+		},
+		// Activation builds on the delegation and runs again with it
+		"delegation lost": {
+			lose: func(chain *fakeChain) {
+				chain.delegations = map[string]*bstypes.BTCDelegationResponse{}
+			},
+			want: "deploy-contract=ok* register-consumer=ok* generate-keys=ok* create-fps=ok* stake-btc=ok wait-activation=ok commit-finalize=ok*",
+		},
+		// Every step but the keys builds on the contract, directly or not
+		"contract lost": {
+			lose: func(chain *fakeChain) {
+				chain.contractAddr = ""
+			},
+			want: "deploy-contract=ok register-consumer=ok generate-keys=ok* create-fps=ok stake-btc=ok wait-activation=ok commit-finalize=ok",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if _, err := env.newDemo(t).Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			prev, err := LoadState(env.cfg.StateFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.lose(env.chain)
+			stakes := env.chain.count("Stake")
+
+			summary, err := env.newDemo(t).Resume(context.Background(), prev)
+			if err != nil {
+				t.Fatalf("%v, steps %s", err, statuses(summary))
+			}
+			if got := statuses(summary); got != tc.want {
+				t.Fatalf("steps %s, want %s", got, tc.want)
+			}
+			restaked := env.chain.count("Stake") > stakes
+			if restaked != strings.Contains(tc.want, "stake-btc=ok ") {
+				t.Errorf("staked again: %v", restaked)
+			}
+		})
+	}
+}
+
+func TestResumeAfterFailure(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.StepTimeouts[StepStakeBTC] = 20 * time.Millisecond
+	env.chain.hang["Stake"] = true
+	if _, err := env.newDemo(t).Run(context.Background()); err == nil {
+		t.Fatal("stake step did not fail")
+	}
+	prev, err := LoadState(env.cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.chain.hang["Stake"] = false
+	summary, err := env.newDemo(t).Resume(context.Background(), prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "deploy-contract=ok* register-consumer=ok* generate-keys=ok* create-fps=ok* stake-btc=ok wait-activation=ok commit-finalize=ok"
+	if got := statuses(summary); got != want {
+		t.Fatalf("steps %s, want %s", got, want)
+	}
+	if n := env.chain.count("StoreCode"); n != 1 {
+		t.Errorf("contract stored %d times", n)
+	}
+}
+
+func TestResumeRefusesOtherConsumer(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.newDemo(t).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := LoadState(env.cfg.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.cfg.ConsumerID = "other-consumer"
+	if _, err := env.newDemo(t).Resume(context.Background(), prev); err == nil {
+		t.Fatal("resumed the run of another consumer")
+	}
+}
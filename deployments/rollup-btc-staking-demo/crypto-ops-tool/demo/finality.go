@@ -0,0 +1,85 @@
+package demo
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/randgen"
+)
+
+// commitAndFinalize has the consumer FP commit randomness for NumPubRand
+// heights from StartHeight, then sign a mock block at each of the first
+// NumFinalitySigs heights. Every signature must show up among the voters of
+// its block.
+func (d *Demo) commitAndFinalize(ctx context.Context) error {
+	if err := requireResult(d.summary.Contract != nil, StepDeployContract); err != nil {
+		return err
+	}
+	if err := requireResult(d.consumerFp != nil, StepGenerateKeys); err != nil {
+		return err
+	}
+
+	contract := opfinality.NewClient(d.chain, d.summary.Contract.ContractAddr)
+	randList, commitTxHash, err := CommitRandomness(ctx, contract, d.consumerFp, d.store, d.cfg.ConsumerID, d.cfg.StartHeight, d.cfg.NumPubRand)
+	if err != nil {
+		return err
+	}
+
+	result := &FinalityResult{
+		FpBtcPkHex:      d.consumerFp.PublicKey().MarshalHex(),
+		StartHeight:     d.cfg.StartHeight,
+		NumPubRand:      d.cfg.NumPubRand,
+		EndHeight:       d.cfg.StartHeight + d.cfg.NumPubRand - 1,
+		CommitmentHex:   hex.EncodeToString(randList.Commitment),
+		CommitTxHash:    commitTxHash,
+		SignedHeights:   []uint64{},
+		NumFinalitySigs: d.cfg.NumFinalitySigs,
+	}
+	d.summary.Finality = result
+
+	for i := uint64(0); i < d.cfg.NumFinalitySigs; i++ {
+		height := d.cfg.StartHeight + i
+		if err := d.finalizeBlock(ctx, contract, randList, height); err != nil {
+			return fmt.Errorf("%w (%d/%d blocks processed)", err, len(result.SignedHeights), d.cfg.NumFinalitySigs)
+		}
+		result.SignedHeights = append(result.SignedHeights, height)
+		log.Printf("[%d/%d] Block %d signed and verified", i+1, d.cfg.NumFinalitySigs, height)
+	}
+	return nil
+}
+
+// finalizeBlock signs a mock block at height, records and submits the
+// signature, and waits for the FP to be listed among the block voters. A
+// block signed by an earlier run whose vote reached the contract is skipped.
+func (d *Demo) finalizeBlock(ctx context.Context, contract *opfinality.Client, randList *randgen.RandList, height uint64) error {
+	fpPk := d.consumerFp.PublicKey()
+	blockHash, signed, err := MockBlockHash(d.store, fpPk, height)
+	if err != nil {
+		return err
+	}
+	if signed {
+		voted, err := HasVoted(ctx, contract, fpPk.MarshalHex(), height, blockHash)
+		if err != nil {
+			return err
+		}
+		if voted {
+			log.Printf("Height %d already signed and voted (hash %x)", height, blockHash)
+			return nil
+		}
+	}
+
+	msg, err := SignVote(ctx, d.consumerFp, d.store, d.cfg.ConsumerID, randList, height, blockHash, false)
+	if err != nil {
+		return err
+	}
+	res, err := contract.SubmitFinalitySignature(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to submit finality signature for height %d: %w", height, err)
+	}
+	log.Printf("Submitted finality signature for height %d (hash %x) in tx %s", height, blockHash, res.TxHash)
+
+	return WaitForVote(ctx, contract, fpPk.MarshalHex(), height, blockHash, d.cfg.VerifyRetries, d.cfg.VerifyRetryInterval)
+}
@@ -0,0 +1,296 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	ftypes "github.com/babylonlabs-io/babylon/v4/x/finality/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/randgen"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/store"
+)
+
+// The functions below are what an FP does during the demo, for any number
+// of FPs and consumers. The demo steps and the scenario runner build on them.
+
+// ParseCommission parses the commission parameters of an FP, given as
+// decimals
+func ParseCommission(rate, maxRate, maxChangeRate string) (bstypes.CommissionRates, error) {
+	var rates [3]sdkmath.LegacyDec
+	for i, r := range []string{rate, maxRate, maxChangeRate} {
+		dec, err := sdkmath.LegacyNewDecFromStr(r)
+		if err != nil {
+			return bstypes.CommissionRates{}, fmt.Errorf("invalid commission rate %q: %w", r, err)
+		}
+		rates[i] = dec
+	}
+	return bstypes.CommissionRates{Rate: rates[0], MaxRate: rates[1], MaxChangeRate: rates[2]}, nil
+}
+
+// CreateFinalityProvider registers the FP of s, for the consumer consumerID
+// or for Babylon when it is empty, with a proof of possession of its key by
+// the paying address. It returns the hash of the tx and the proof of
+// possession in hex.
+func CreateFinalityProvider(ctx context.Context, chain Chain, s signer.Signer, moniker, consumerID string, commission bstypes.CommissionRates) (string, string, error) {
+	addr, err := sdk.AccAddressFromBech32(chain.Address())
+	if err != nil {
+		return "", "", fmt.Errorf("invalid paying address: %w", err)
+	}
+	pop, err := s.SignPoP(ctx, addr)
+	if err != nil {
+		return "", "", err
+	}
+	popHex, err := pop.ToHexStr()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode proof of possession: %w", err)
+	}
+
+	res, err := chain.BroadcastAndWait(ctx, &bstypes.MsgCreateFinalityProvider{
+		Addr:        addr.String(),
+		Description: &stakingtypes.Description{Moniker: moniker},
+		Commission:  commission,
+		BtcPk:       s.PublicKey(),
+		Pop:         pop,
+		ConsumerId:  consumerID,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create %s %s: %w", moniker, s.PublicKey().MarshalHex(), err)
+	}
+	log.Printf("%s created in tx %s", moniker, res.TxHash)
+	return res.TxHash, popHex, nil
+}
+
+// CommitRandomness has the FP of s derive its randomness for numPubRand
+// heights from startHeight for the consumer consumerID, stores the
+// commitment and commits it, then checks that the contract holds it. It
+// returns the randomness list and the commit tx hash, which is empty when the
+// contract held the commitment already, as after an interrupted run.
+func CommitRandomness(ctx context.Context, contract *opfinality.Client, s signer.Signer, st *store.Store, consumerID string, startHeight, numPubRand uint64) (*randgen.RandList, string, error) {
+	fpPk := s.PublicKey()
+	chainID := []byte(consumerID)
+	endHeight := startHeight + numPubRand - 1
+
+	prList, err := s.PubRandList(ctx, chainID, startHeight, numPubRand)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to derive randomness: %w", err)
+	}
+	randList, err := randgen.NewPubRandList(startHeight, prList)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build commitment: %w", err)
+	}
+
+	err = st.SavePubRandCommit(&store.PubRandCommit{
+		FpBtcPk:     fpPk.MustMarshal(),
+		StartHeight: startHeight,
+		NumPubRand:  numPubRand,
+		Commitment:  randList.Commitment,
+		ChainID:     chainID,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to store commitment: %w", err)
+	}
+
+	held, err := HoldsCommitment(ctx, contract, fpPk.MarshalHex(), startHeight, numPubRand, randList.Commitment)
+	if err != nil {
+		return nil, "", err
+	}
+	if held {
+		log.Printf("Randomness of %s for heights %d to %d already committed", fpPk.MarshalHex(), startHeight, endHeight)
+		return randList, "", nil
+	}
+
+	commitMsg := &ftypes.MsgCommitPubRandList{
+		FpBtcPk:     fpPk,
+		StartHeight: startHeight,
+		NumPubRand:  numPubRand,
+		Commitment:  randList.Commitment,
+	}
+	hash, err := commitMsg.HashToSign()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash commitment: %w", err)
+	}
+	sig, err := s.SignSchnorr(ctx, hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sign commitment: %w", err)
+	}
+
+	res, err := contract.CommitPublicRandomness(ctx, &opfinality.CommitPublicRandomness{
+		FpPubkeyHex: fpPk.MarshalHex(),
+		StartHeight: startHeight,
+		NumPubRand:  numPubRand,
+		Commitment:  randList.Commitment,
+		Signature:   sig.Serialize(),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to commit public randomness: %w", err)
+	}
+	log.Printf("Committed randomness of %s for heights %d to %d in tx %s", fpPk.MarshalHex(), startHeight, endHeight, res.TxHash)
+
+	held, err = HoldsCommitment(ctx, contract, fpPk.MarshalHex(), startHeight, numPubRand, randList.Commitment)
+	if err != nil {
+		return nil, "", err
+	}
+	if !held {
+		return nil, "", fmt.Errorf("the contract does not hold the commitment of tx %s", res.TxHash)
+	}
+	return randList, res.TxHash, nil
+}
+
+// HoldsCommitment reports whether the contract holds a commitment of the FP
+// starting at startHeight with the given size and value. The contract only
+// answers for the first and last commitment of an FP, which covers the
+// commitment just made and a single one made by an interrupted run; a range
+// overlapping the commitments held otherwise is an error, since the contract
+// would refuse to commit it again.
+func HoldsCommitment(ctx context.Context, contract *opfinality.Client, fpPkHex string, startHeight, numPubRand uint64, commitment []byte) (bool, error) {
+	matches := func(c *opfinality.PubRandCommit) bool {
+		return c != nil && c.StartHeight == startHeight && c.NumPubRand == numPubRand && bytes.Equal(c.Commitment, commitment)
+	}
+	last, err := contract.QueryLastPubRandCommit(ctx, fpPkHex)
+	if err != nil {
+		return false, err
+	}
+	if last == nil {
+		return false, nil
+	}
+	if matches(last) {
+		return true, nil
+	}
+	first, err := contract.QueryFirstPubRandCommit(ctx, fpPkHex)
+	if err != nil {
+		return false, err
+	}
+	if matches(first) {
+		return true, nil
+	}
+	if first != nil && startHeight <= last.EndHeight() && startHeight+numPubRand-1 >= first.StartHeight {
+		return false, fmt.Errorf("heights %d to %d overlap the commitments of %s from height %d to %d without being the first or last of them",
+			startHeight, startHeight+numPubRand-1, fpPkHex, first.StartHeight, last.EndHeight())
+	}
+	return false, nil
+}
+
+// MockBlockHash returns a random block hash for the FP to sign at height, or
+// the hash it already signed at that height so that a second run never
+// equivocates. It reports whether the hash was signed already.
+func MockBlockHash(st *store.Store, fpPk *bbn.BIP340PubKey, height uint64) ([]byte, bool, error) {
+	record, err := st.GetSignRecord(fpPk.MustMarshal(), height)
+	if err == nil {
+		return record.BlockHash, true, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, false, fmt.Errorf("failed to load sign record of height %d: %w", height, err)
+	}
+	blockHash, err := RandomBlockHash()
+	if err != nil {
+		return nil, false, err
+	}
+	return blockHash, false, nil
+}
+
+// RandomBlockHash returns a random 32 byte block hash
+func RandomBlockHash() ([]byte, error) {
+	blockHash := make([]byte, 32)
+	if _, err := rand.Read(blockHash); err != nil {
+		return nil, fmt.Errorf("failed to generate block hash: %w", err)
+	}
+	return blockHash, nil
+}
+
+// SignVote has the FP of s sign the block at height for the consumer
+// consumerID with the randomness of randList, records the signature and
+// returns the message submitting it. A height already signed over another
+// block is refused, unless allowEquivocation is set.
+func SignVote(ctx context.Context, s signer.Signer, st *store.Store, consumerID string, randList *randgen.RandList, height uint64, blockHash []byte, allowEquivocation bool) (*opfinality.SubmitFinalitySignature, error) {
+	fpPk := s.PublicKey()
+	if height < randList.StartHeight || height-randList.StartHeight >= uint64(len(randList.PRList)) {
+		return nil, fmt.Errorf("height %d is not covered by the randomness committed from height %d", height, randList.StartHeight)
+	}
+	if !allowEquivocation {
+		if err := st.CheckDoubleSign(fpPk.MustMarshal(), height, blockHash); err != nil {
+			return nil, fmt.Errorf("slashing protection: %w", err)
+		}
+	}
+
+	msgToSign := append(sdk.Uint64ToBigEndian(height), blockHash...)
+	sig, err := s.SignEOTS(ctx, []byte(consumerID), height, msgToSign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign height %d: %w", height, err)
+	}
+	eotsSig := bbn.NewSchnorrEOTSSigFromModNScalar(sig).MustMarshal()
+
+	err = st.SaveSignRecord(&store.SignRecord{
+		FpBtcPk:   fpPk.MustMarshal(),
+		Height:    height,
+		BlockHash: blockHash,
+		Signature: eotsSig,
+	}, allowEquivocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record signature of height %d: %w", height, err)
+	}
+
+	randIndex := height - randList.StartHeight
+	return &opfinality.SubmitFinalitySignature{
+		FpPubkeyHex: fpPk.MarshalHex(),
+		Height:      height,
+		PubRand:     bbn.NewSchnorrPubRandFromFieldVal(randList.PRList[randIndex]).MustMarshal(),
+		Proof:       opfinality.NewProof(randList.ProofList[randIndex]),
+		BlockHash:   blockHash,
+		Signature:   eotsSig,
+	}, nil
+}
+
+// HasVoted reports whether the FP is among the voters of the block
+func HasVoted(ctx context.Context, contract *opfinality.Client, fpPkHex string, height uint64, blockHash []byte) (bool, error) {
+	voters, err := contract.QueryBlockVoters(ctx, height, blockHash)
+	if err != nil {
+		return false, err
+	}
+	for _, voter := range voters {
+		if voter == fpPkHex {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WaitForVote queries the voters of the block up to retries times, every
+// interval, until the FP is among them
+func WaitForVote(ctx context.Context, contract *opfinality.Client, fpPkHex string, height uint64, blockHash []byte, retries int, interval time.Duration) error {
+	var voters []string
+	var err error
+	for attempt := 1; attempt <= retries; attempt++ {
+		voters, err = contract.QueryBlockVoters(ctx, height, blockHash)
+		if err == nil {
+			for _, voter := range voters {
+				if voter == fpPkHex {
+					return nil
+				}
+			}
+		}
+		if attempt == retries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("vote of height %d not found: %w", height, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("vote of height %d not found after %d attempts: %w", height, retries, err)
+	}
+	return fmt.Errorf("vote of height %d not found after %d attempts, voters: %v", height, retries, voters)
+}
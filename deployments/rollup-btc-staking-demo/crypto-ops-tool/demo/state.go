@@ -0,0 +1,369 @@
+package demo
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/signer"
+)
+
+// stepDeps lists the steps whose results a step builds on. A step runs again
+// on resume when one of them did.
+var stepDeps = map[Step][]Step{
+	StepRegisterConsumer:  {StepDeployContract},
+	StepCreateFPs:         {StepRegisterConsumer, StepGenerateKeys},
+	StepStakeBTC:          {StepCreateFPs},
+	StepWaitActivation:    {StepStakeBTC},
+	StepCommitAndFinalize: {StepCreateFPs},
+}
+
+// dependsOnAny reports whether step builds on one of the steps in ran
+func dependsOnAny(step Step, ran map[Step]bool) bool {
+	for _, dep := range stepDeps[step] {
+		if ran[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadState reads the summary a run saved to its state file
+func LoadState(path string) (*Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read demo state: %w", err)
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode demo state %s: %w", path, err)
+	}
+	return &summary, nil
+}
+
+// saveState writes the summary to the state file, through a temporary file
+// so that an interrupted write leaves the previous state in place
+func (d *Demo) saveState() error {
+	if d.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(&d.summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode demo state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.cfg.StateFile), filepath.Base(d.cfg.StateFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save demo state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save demo state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save demo state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.cfg.StateFile); err != nil {
+		return fmt.Errorf("failed to save demo state: %w", err)
+	}
+	return nil
+}
+
+// completed reports whether the run completed step, with or without a
+// warning
+func (s *Summary) completed(step Step) bool {
+	done := false
+	for _, result := range s.Steps {
+		if result.Step == step {
+			done = result.Status == StatusOK || result.Status == StatusWarning
+		}
+	}
+	return done
+}
+
+// restore takes over the results of the steps prev completed, after checking
+// that prev is a run of the same consumer with the same keys
+func (d *Demo) restore(prev *Summary) error {
+	if prev.Consumer != nil && prev.Consumer.ConsumerID != d.cfg.ConsumerID {
+		return fmt.Errorf("cannot resume a run of consumer %s as consumer %s", prev.Consumer.ConsumerID, d.cfg.ConsumerID)
+	}
+	if prev.Keys != nil && (prev.Keys.BabylonFp.Name != d.cfg.BabylonFpKey || prev.Keys.ConsumerFp.Name != d.cfg.ConsumerFpKey) {
+		return fmt.Errorf("cannot resume a run with keys %s and %s using keys %s and %s",
+			prev.Keys.BabylonFp.Name, prev.Keys.ConsumerFp.Name, d.cfg.BabylonFpKey, d.cfg.ConsumerFpKey)
+	}
+
+	d.summary = Summary{
+		Contract:          prev.Contract,
+		Consumer:          prev.Consumer,
+		Keys:              prev.Keys,
+		FinalityProviders: prev.FinalityProviders,
+		Delegation:        prev.Delegation,
+		Activation:        prev.Activation,
+		Finality:          prev.Finality,
+	}
+	for _, step := range Steps {
+		if !prev.completed(step) {
+			d.clearResult(step)
+		}
+	}
+	return nil
+}
+
+// clearResult drops the result of step
+func (d *Demo) clearResult(step Step) {
+	switch step {
+	case StepDeployContract:
+		d.summary.Contract = nil
+	case StepRegisterConsumer:
+		d.summary.Consumer = nil
+	case StepGenerateKeys:
+		d.summary.Keys = nil
+		d.bbnFp, d.consumerFp = nil, nil
+	case StepCreateFPs:
+		d.summary.FinalityProviders = nil
+	case StepStakeBTC:
+		d.summary.Delegation = nil
+	case StepWaitActivation:
+		d.summary.Activation = nil
+	case StepCommitAndFinalize:
+		d.summary.Finality = nil
+	}
+}
+
+// resumeStep checks, within the timeout of step, that the result of step
+// recorded by an earlier run still holds on chain, and records the step as
+// resumed if so
+func (d *Demo) resumeStep(ctx context.Context, step Step) error {
+	var validate func(ctx context.Context) error
+	switch step {
+	case StepDeployContract:
+		validate = d.validateContract
+	case StepRegisterConsumer:
+		validate = d.validateConsumer
+	case StepGenerateKeys:
+		validate = d.validateKeys
+	case StepCreateFPs:
+		validate = d.validateFinalityProviders
+	case StepStakeBTC:
+		validate = d.validateDelegation
+	case StepWaitActivation:
+		validate = d.validateActivation
+	case StepCommitAndFinalize:
+		validate = d.validateFinality
+	default:
+		return fmt.Errorf("unknown step %q", step)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, d.cfg.stepTimeout(step))
+	defer cancel()
+
+	start := time.Now()
+	if err := validate(stepCtx); err != nil {
+		return err
+	}
+	log.Printf("Step %s completed by an earlier run", step)
+	d.summary.Steps = append(d.summary.Steps, StepResult{
+		Step:     step,
+		Status:   StatusOK,
+		Duration: Duration(time.Since(start)),
+		Resumed:  true,
+	})
+	return d.saveState()
+}
+
+// validateContract checks that the contract exists and serves the consumer
+func (d *Demo) validateContract(ctx context.Context) error {
+	if err := requireResult(d.summary.Contract != nil, StepDeployContract); err != nil {
+		return err
+	}
+	contract := opfinality.NewClient(d.chain, d.summary.Contract.ContractAddr)
+	config, err := contract.QueryConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if config.ConsumerID != d.cfg.ConsumerID {
+		return fmt.Errorf("contract %s serves consumer %s, not %s", contract.Address(), config.ConsumerID, d.cfg.ConsumerID)
+	}
+	return nil
+}
+
+// validateConsumer checks that the consumer is registered with the contract
+// as its finality contract
+func (d *Demo) validateConsumer(ctx context.Context) error {
+	if err := requireResult(d.summary.Contract != nil, StepDeployContract); err != nil {
+		return err
+	}
+	if err := requireResult(d.summary.Consumer != nil, StepRegisterConsumer); err != nil {
+		return err
+	}
+	consumer, err := d.chain.QueryConsumerRegistration(ctx, d.summary.Consumer.ConsumerID)
+	if err != nil {
+		return err
+	}
+	if consumer.RollupFinalityContractAddress != d.summary.Contract.ContractAddr {
+		return fmt.Errorf("consumer %s is registered with finality contract %s, not %s",
+			consumer.ConsumerId, consumer.RollupFinalityContractAddress, d.summary.Contract.ContractAddr)
+	}
+	return nil
+}
+
+// validateKeys opens both FP keys and checks their public keys
+func (d *Demo) validateKeys(ctx context.Context) error {
+	if err := requireResult(d.summary.Keys != nil, StepGenerateKeys); err != nil {
+		return err
+	}
+	bbnFp, err := d.openKey(ctx, d.summary.Keys.BabylonFp)
+	if err != nil {
+		return err
+	}
+	consumerFp, err := d.openKey(ctx, d.summary.Keys.ConsumerFp)
+	if err != nil {
+		return err
+	}
+	d.bbnFp, d.consumerFp = bbnFp, consumerFp
+	return nil
+}
+
+// openKey returns a signer of the FP key, which must have the recorded
+// public key
+func (d *Demo) openKey(ctx context.Context, key FPKey) (signer.Signer, error) {
+	s, err := d.keys.Key(ctx, key.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key %s: %w", key.Name, err)
+	}
+	if pkHex := s.PublicKey().MarshalHex(); pkHex != key.BtcPkHex {
+		return nil, fmt.Errorf("key %s has public key %s, not %s", key.Name, pkHex, key.BtcPkHex)
+	}
+	return s, nil
+}
+
+// validateFinalityProviders checks that both FPs are registered, the
+// consumer FP for the consumer
+func (d *Demo) validateFinalityProviders(ctx context.Context) error {
+	if err := requireResult(d.summary.FinalityProviders != nil, StepCreateFPs); err != nil {
+		return err
+	}
+	if err := requireResult(d.summary.Keys != nil, StepGenerateKeys); err != nil {
+		return err
+	}
+
+	bbnFps, err := d.chain.QueryFinalityProviders(ctx)
+	if err != nil {
+		return err
+	}
+	var bbnFpPks []*bbn.BIP340PubKey
+	for _, fp := range bbnFps {
+		bbnFpPks = append(bbnFpPks, fp.BtcPk)
+	}
+	if !containsPk(bbnFpPks, d.summary.Keys.BabylonFp.BtcPkHex) {
+		return fmt.Errorf("Babylon FP %s not found", d.summary.Keys.BabylonFp.BtcPkHex)
+	}
+
+	consumerFps, err := d.chain.QueryConsumerFinalityProviders(ctx, d.cfg.ConsumerID)
+	if err != nil {
+		return err
+	}
+	var consumerFpPks []*bbn.BIP340PubKey
+	for _, fp := range consumerFps {
+		consumerFpPks = append(consumerFpPks, fp.BtcPk)
+	}
+	if !containsPk(consumerFpPks, d.summary.Keys.ConsumerFp.BtcPkHex) {
+		return fmt.Errorf("consumer FP %s not found for consumer %s", d.summary.Keys.ConsumerFp.BtcPkHex, d.cfg.ConsumerID)
+	}
+	return nil
+}
+
+// containsPk reports whether pks holds the public key pkHex
+func containsPk(pks []*bbn.BIP340PubKey, pkHex string) bool {
+	for _, pk := range pks {
+		if pk != nil && pk.MarshalHex() == pkHex {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDelegation checks that Babylon knows the delegation
+func (d *Demo) validateDelegation(ctx context.Context) error {
+	if err := requireResult(d.summary.Delegation != nil, StepStakeBTC); err != nil {
+		return err
+	}
+	_, err := d.chain.QueryBTCDelegation(ctx, d.summary.Delegation.StakingTxHash)
+	return err
+}
+
+// validateActivation checks that the delegation is active. A delegation an
+// earlier run gave up waiting for fails this check until it is active.
+func (d *Demo) validateActivation(ctx context.Context) error {
+	if err := requireResult(d.summary.Delegation != nil, StepStakeBTC); err != nil {
+		return err
+	}
+	del, err := d.chain.QueryBTCDelegation(ctx, d.summary.Delegation.StakingTxHash)
+	if err != nil {
+		return err
+	}
+	if !del.Active {
+		return fmt.Errorf("delegation %s is %s", d.summary.Delegation.StakingTxHash, del.StatusDesc)
+	}
+	if d.summary.Activation == nil {
+		d.summary.Activation = &ActivationResult{}
+	}
+	d.summary.Activation.Activated = true
+	return nil
+}
+
+// validateFinality checks that the contract holds the commitment and that
+// the vote of every signed height is among the voters of its block
+func (d *Demo) validateFinality(ctx context.Context) error {
+	if err := requireResult(d.summary.Contract != nil, StepDeployContract); err != nil {
+		return err
+	}
+	if err := requireResult(d.summary.Finality != nil, StepCommitAndFinalize); err != nil {
+		return err
+	}
+	result := d.summary.Finality
+	if result.StartHeight != d.cfg.StartHeight || result.NumPubRand != d.cfg.NumPubRand ||
+		uint64(len(result.SignedHeights)) < d.cfg.NumFinalitySigs {
+		return fmt.Errorf("the earlier run committed %d values from height %d and signed %d heights", result.NumPubRand, result.StartHeight, len(result.SignedHeights))
+	}
+	commitment, err := hex.DecodeString(result.CommitmentHex)
+	if err != nil {
+		return fmt.Errorf("invalid commitment %q: %w", result.CommitmentHex, err)
+	}
+	fpPk, err := bbn.NewBIP340PubKeyFromHex(result.FpBtcPkHex)
+	if err != nil {
+		return fmt.Errorf("invalid FP public key %q: %w", result.FpBtcPkHex, err)
+	}
+
+	contract := opfinality.NewClient(d.chain, d.summary.Contract.ContractAddr)
+	held, err := HoldsCommitment(ctx, contract, result.FpBtcPkHex, result.StartHeight, result.NumPubRand, commitment)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return fmt.Errorf("the contract does not hold the commitment of heights %d to %d", result.StartHeight, result.EndHeight)
+	}
+
+	for _, height := range result.SignedHeights {
+		record, err := d.store.GetSignRecord(fpPk.MustMarshal(), height)
+		if err != nil {
+			return fmt.Errorf("failed to load sign record of height %d: %w", height, err)
+		}
+		voted, err := HasVoted(ctx, contract, result.FpBtcPkHex, height, record.BlockHash)
+		if err != nil {
+			return err
+		}
+		if !voted {
+			return fmt.Errorf("vote of height %d not found", height)
+		}
+	}
+	return nil
+}
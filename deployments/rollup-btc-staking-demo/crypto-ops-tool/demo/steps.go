@@ -0,0 +1,235 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+	"github.com/btcsuite/btcd/wire"
+
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/stakerclient"
+)
+
+// deployContract stores the finality contract and instantiates it with the
+// paying key as admin
+func (d *Demo) deployContract(ctx context.Context) error {
+	wasmByteCode, err := os.ReadFile(d.cfg.ContractWasmPath)
+	if err != nil {
+		return fmt.Errorf("failed to read contract: %w", err)
+	}
+
+	codeID, storeRes, err := d.chain.StoreCode(ctx, wasmByteCode)
+	if err != nil {
+		return fmt.Errorf("failed to store contract: %w", err)
+	}
+	log.Printf("Stored %s as code %d in tx %s", d.cfg.ContractWasmPath, codeID, storeRes.TxHash)
+
+	admin := d.chain.Address()
+	instantiateMsg, err := json.Marshal(&opfinality.InstantiateMsg{
+		Admin:      admin,
+		ConsumerID: d.cfg.ConsumerID,
+		IsEnabled:  true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal instantiate message: %w", err)
+	}
+	contractAddr, instantiateRes, err := d.chain.InstantiateContract(ctx, codeID, admin, d.cfg.ContractLabel, instantiateMsg)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate contract: %w", err)
+	}
+	log.Printf("Finality contract deployed at %s", contractAddr)
+
+	d.summary.Contract = &ContractResult{
+		CodeID:            codeID,
+		ContractAddr:      contractAddr,
+		Admin:             admin,
+		StoreTxHash:       storeRes.TxHash,
+		InstantiateTxHash: instantiateRes.TxHash,
+	}
+	return nil
+}
+
+// registerConsumer registers the consumer with the deployed contract as its
+// finality contract
+func (d *Demo) registerConsumer(ctx context.Context) error {
+	if err := requireResult(d.summary.Contract != nil, StepDeployContract); err != nil {
+		return err
+	}
+
+	res, err := d.chain.BroadcastAndWait(ctx, &bsctypes.MsgRegisterConsumer{
+		Signer:                        d.chain.Address(),
+		ConsumerId:                    d.cfg.ConsumerID,
+		ConsumerName:                  d.cfg.ConsumerName,
+		ConsumerDescription:           d.cfg.ConsumerDescription,
+		MaxMultiStakedFps:             d.cfg.MaxMultiStakedFps,
+		RollupFinalityContractAddress: d.summary.Contract.ContractAddr,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register consumer %s: %w", d.cfg.ConsumerID, err)
+	}
+	log.Printf("Consumer %s registered in tx %s", d.cfg.ConsumerID, res.TxHash)
+
+	d.summary.Consumer = &ConsumerResult{ConsumerID: d.cfg.ConsumerID, TxHash: res.TxHash}
+	return nil
+}
+
+// generateKeys creates the keys of the Babylon FP and of the consumer FP
+func (d *Demo) generateKeys(ctx context.Context) error {
+	bbnFp, err := d.keys.NewKey(ctx, d.cfg.BabylonFpKey, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create key %s: %w", d.cfg.BabylonFpKey, err)
+	}
+	consumerFp, err := d.keys.NewKey(ctx, d.cfg.ConsumerFpKey, 1)
+	if err != nil {
+		return fmt.Errorf("failed to create key %s: %w", d.cfg.ConsumerFpKey, err)
+	}
+	log.Printf("Babylon FP BTC PK: %s (key %s)", bbnFp.PublicKey().MarshalHex(), d.cfg.BabylonFpKey)
+	log.Printf("Consumer FP BTC PK: %s (key %s)", consumerFp.PublicKey().MarshalHex(), d.cfg.ConsumerFpKey)
+
+	d.bbnFp, d.consumerFp = bbnFp, consumerFp
+	d.summary.Keys = &KeysResult{
+		BabylonFp:  FPKey{Name: d.cfg.BabylonFpKey, BtcPkHex: bbnFp.PublicKey().MarshalHex()},
+		ConsumerFp: FPKey{Name: d.cfg.ConsumerFpKey, BtcPkHex: consumerFp.PublicKey().MarshalHex()},
+	}
+	return nil
+}
+
+// createFinalityProviders registers the Babylon FP and the consumer FP
+func (d *Demo) createFinalityProviders(ctx context.Context) error {
+	if err := requireResult(d.summary.Consumer != nil, StepRegisterConsumer); err != nil {
+		return err
+	}
+	if err := requireResult(d.bbnFp != nil && d.consumerFp != nil, StepGenerateKeys); err != nil {
+		return err
+	}
+
+	commission, err := d.cfg.commission()
+	if err != nil {
+		return err
+	}
+	bbnTxHash, bbnPopHex, err := CreateFinalityProvider(ctx, d.chain, d.bbnFp, "Babylon FP", "", commission)
+	if err != nil {
+		return err
+	}
+	consumerTxHash, consumerPopHex, err := CreateFinalityProvider(ctx, d.chain, d.consumerFp, "Consumer FP", d.cfg.ConsumerID, commission)
+	if err != nil {
+		return err
+	}
+
+	bbnFps, err := d.chain.QueryFinalityProviders(ctx)
+	if err != nil {
+		return err
+	}
+	consumerFps, err := d.chain.QueryConsumerFinalityProviders(ctx, d.cfg.ConsumerID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Babylon finality providers: %d, consumer finality providers: %d", len(bbnFps), len(consumerFps))
+
+	d.summary.FinalityProviders = &FinalityProvidersResult{
+		BabylonFpTxHash:  bbnTxHash,
+		ConsumerFpTxHash: consumerTxHash,
+		BabylonFpPopHex:  bbnPopHex,
+		ConsumerFpPopHex: consumerPopHex,
+		BabylonFpCount:   len(bbnFps),
+		ConsumerFpCount:  len(consumerFps),
+	}
+	return nil
+}
+
+// stakeBTC delegates from the first address of the staker's wallet to both
+// FPs
+func (d *Demo) stakeBTC(ctx context.Context) error {
+	if err := requireResult(d.summary.FinalityProviders != nil, StepCreateFPs); err != nil {
+		return err
+	}
+
+	addrs, err := d.staker.StakerAddresses(ctx)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("the staker wallet has no funded address")
+	}
+
+	req := stakerclient.StakeRequest{
+		StakerAddress:     addrs[0],
+		StakingAmount:     d.cfg.StakingAmount,
+		FpBtcPksHex:       []string{d.summary.Keys.BabylonFp.BtcPkHex, d.summary.Keys.ConsumerFp.BtcPkHex},
+		StakingTimeBlocks: d.cfg.StakingTimeBlocks,
+	}
+	log.Printf("Delegating %d satoshis for %d blocks from %s", req.StakingAmount, req.StakingTimeBlocks, req.StakerAddress)
+	txHash, err := d.staker.Stake(ctx, req)
+	if err != nil {
+		return err
+	}
+	log.Printf("BTC delegation created: %s", txHash)
+
+	d.summary.Delegation = &DelegationResult{
+		StakerAddress:     req.StakerAddress,
+		StakingTxHash:     txHash,
+		StakingAmount:     req.StakingAmount,
+		StakingTimeBlocks: req.StakingTimeBlocks,
+	}
+	return nil
+}
+
+// waitForActivation polls the active delegations until the delegation is
+// active or the step times out. Like the script, a delegation that does not
+// become active only produces a warning, unless RequireActivation is set.
+func (d *Demo) waitForActivation(ctx context.Context) error {
+	if err := requireResult(d.summary.Delegation != nil, StepStakeBTC); err != nil {
+		return err
+	}
+
+	result := &ActivationResult{}
+	d.summary.Activation = result
+	for attempt := 1; ; attempt++ {
+		active, err := d.chain.QueryBTCDelegations(ctx, bstypes.BTCDelegationStatus_ACTIVE)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil {
+			result.ActiveDelegations = len(active)
+			for _, del := range active {
+				if stakingTxHashMatches(del.StakingTxHex, d.summary.Delegation.StakingTxHash) {
+					result.Activated = true
+					log.Printf("Delegation %s activated", d.summary.Delegation.StakingTxHash)
+					return nil
+				}
+			}
+			log.Printf("Attempt %d: %d active delegations, waiting...", attempt, len(active))
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded && !d.cfg.RequireActivation {
+				return &stepWarning{msg: fmt.Sprintf("delegation %s not active before the step timed out, proceeding anyway", d.summary.Delegation.StakingTxHash)}
+			}
+			return fmt.Errorf("delegation %s not active: %w", d.summary.Delegation.StakingTxHash, ctx.Err())
+		case <-time.After(d.cfg.ActivationPollInterval):
+		}
+	}
+}
+
+// stakingTxHashMatches reports whether the serialized staking tx
+// stakingTxHex has the hash txHash
+func stakingTxHashMatches(stakingTxHex, txHash string) bool {
+	txBytes, err := hex.DecodeString(stakingTxHex)
+	if err != nil {
+		return false
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return false
+	}
+	return tx.TxHash().String() == txHash
+}
@@ -0,0 +1,145 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StepStatus is the outcome of a step
+type StepStatus string
+
+const (
+	// StatusOK is a step that reached its goal
+	StatusOK StepStatus = "ok"
+	// StatusWarning is a step that did not reach its goal, after which the
+	// demo proceeded anyway
+	StatusWarning StepStatus = "warning"
+	// StatusFailed is a step that failed and stopped the run
+	StatusFailed StepStatus = "failed"
+	// StatusSkipped is a step that did not run because an earlier one
+	// failed
+	StatusSkipped StepStatus = "skipped"
+)
+
+// Duration is a time.Duration printed as a string such as "1.5s" in JSON
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Round(time.Millisecond).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// StepResult is the outcome of a step
+type StepResult struct {
+	Step     Step       `json:"step"`
+	Status   StepStatus `json:"status"`
+	Duration Duration   `json:"duration,omitempty"`
+	// Resumed is set when the step completed in an earlier run and its
+	// result was found to still hold on chain
+	Resumed bool   `json:"resumed,omitempty"`
+	Warning string `json:"warning,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ContractResult is the result of StepDeployContract
+type ContractResult struct {
+	CodeID            uint64 `json:"code_id"`
+	ContractAddr      string `json:"contract_address"`
+	Admin             string `json:"admin"`
+	StoreTxHash       string `json:"store_tx_hash"`
+	InstantiateTxHash string `json:"instantiate_tx_hash"`
+}
+
+// ConsumerResult is the result of StepRegisterConsumer
+type ConsumerResult struct {
+	ConsumerID string `json:"consumer_id"`
+	TxHash     string `json:"tx_hash"`
+}
+
+// FPKey is an FP key of the demo
+type FPKey struct {
+	Name     string `json:"name"`
+	BtcPkHex string `json:"btc_pk_hex"`
+}
+
+// KeysResult is the result of StepGenerateKeys
+type KeysResult struct {
+	BabylonFp  FPKey `json:"babylon_fp"`
+	ConsumerFp FPKey `json:"consumer_fp"`
+}
+
+// FinalityProvidersResult is the result of StepCreateFPs
+type FinalityProvidersResult struct {
+	BabylonFpTxHash  string `json:"babylon_fp_tx_hash"`
+	ConsumerFpTxHash string `json:"consumer_fp_tx_hash"`
+	// BabylonFpPopHex and ConsumerFpPopHex are the proofs of possession the
+	// FPs were created with
+	BabylonFpPopHex  string `json:"babylon_fp_pop_hex"`
+	ConsumerFpPopHex string `json:"consumer_fp_pop_hex"`
+	// BabylonFpCount and ConsumerFpCount are the number of FPs registered
+	// on Babylon and for the consumer after the FPs were created
+	BabylonFpCount  int `json:"babylon_fp_count"`
+	ConsumerFpCount int `json:"consumer_fp_count"`
+}
+
+// DelegationResult is the result of StepStakeBTC
+type DelegationResult struct {
+	StakerAddress     string `json:"staker_address"`
+	StakingTxHash     string `json:"staking_tx_hash"`
+	StakingAmount     int64  `json:"staking_amount"`
+	StakingTimeBlocks int64  `json:"staking_time_blocks"`
+}
+
+// ActivationResult is the result of StepWaitActivation
+type ActivationResult struct {
+	Activated         bool `json:"activated"`
+	ActiveDelegations int  `json:"active_delegations"`
+}
+
+// FinalityResult is the result of StepCommitAndFinalize
+type FinalityResult struct {
+	FpBtcPkHex string `json:"fp_btc_pk_hex"`
+	// StartHeight to EndHeight are the NumPubRand heights covered by the
+	// committed randomness
+	StartHeight   uint64 `json:"start_height"`
+	NumPubRand    uint64 `json:"num_pub_rand"`
+	EndHeight     uint64 `json:"end_height"`
+	CommitmentHex string `json:"commitment_hex"`
+	// CommitTxHash is empty when the contract already held the commitment
+	CommitTxHash string `json:"commit_tx_hash,omitempty"`
+	// SignedHeights are the heights whose signature was submitted and
+	// found among the block voters
+	SignedHeights   []uint64 `json:"signed_heights"`
+	NumFinalitySigs uint64   `json:"num_finality_sigs"`
+}
+
+// Summary is the outcome of a demo run, the counterpart of the "Demo
+// Summary" printed by the script. The result of a step is nil until the
+// step completed. It is also the state a run persists to resume from.
+type Summary struct {
+	Success           bool                     `json:"success"`
+	Contract          *ContractResult          `json:"contract,omitempty"`
+	Consumer          *ConsumerResult          `json:"consumer,omitempty"`
+	Keys              *KeysResult              `json:"keys,omitempty"`
+	FinalityProviders *FinalityProvidersResult `json:"finality_providers,omitempty"`
+	Delegation        *DelegationResult        `json:"delegation,omitempty"`
+	Activation        *ActivationResult        `json:"activation,omitempty"`
+	Finality          *FinalityResult          `json:"finality,omitempty"`
+	Steps             []StepResult             `json:"steps"`
+	Duration          Duration                 `json:"duration"`
+}
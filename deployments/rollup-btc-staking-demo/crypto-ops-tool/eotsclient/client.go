@@ -0,0 +1,147 @@
+// Package eotsclient delegates FP signing to a running EOTS manager (eotsd)
+// over its gRPC API, so that the FP secret key never enters the process
+// building and submitting the signatures. The EOTS manager derives the
+// randomness of each height from the key and the chain id, exactly like
+// package randgen, and refuses to sign two messages at the same height.
+package eotsclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Scheme is the URL scheme of EOTS manager addresses, as in
+// eotsd://consumer-eotsmanager:15813
+const Scheme = "eotsd"
+
+// Client is a client of the EOTS manager gRPC service
+type Client struct {
+	conn    *grpc.ClientConn
+	timeout time.Duration
+}
+
+// ParseTarget returns the host:port of an eotsd://host:port address
+func ParseTarget(target string) (string, error) {
+	addr, ok := strings.CutPrefix(target, Scheme+"://")
+	if !ok {
+		return "", fmt.Errorf("invalid EOTS manager address %q: must be %s://host:port", target, Scheme)
+	}
+	if addr == "" || strings.ContainsAny(addr, "/?#") {
+		return "", fmt.Errorf("invalid EOTS manager address %q: must be %s://host:port", target, Scheme)
+	}
+	return addr, nil
+}
+
+// Dial connects to the EOTS manager at target (eotsd://host:port) and pings
+// it. timeout bounds the ping and every later call.
+func Dial(target string, timeout time.Duration) (*Client, error) {
+	addr, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	// eotsd serves plaintext gRPC, it is meant to be reachable from the FP
+	// host only
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to EOTS manager at %s: %w", addr, err)
+	}
+	c := &Client{conn: conn, timeout: timeout}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("EOTS manager at %s is not reachable: %w", addr, err)
+	}
+	return c, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Ping checks that the EOTS manager is serving
+func (c *Client) Ping(ctx context.Context) error {
+	return c.invoke(ctx, "Ping", &PingRequest{}, &PingResponse{})
+}
+
+// CreateRandomnessPairList returns the public randomness of num
+// consecutive heights starting at startHeight for the key fpPk (BIP340)
+func (c *Client) CreateRandomnessPairList(ctx context.Context, fpPk, chainID []byte, startHeight uint64, num uint32) ([]*btcec.FieldVal, error) {
+	res := &CreateRandomnessPairListResponse{}
+	err := c.invoke(ctx, "CreateRandomnessPairList", &CreateRandomnessPairListRequest{
+		Uid:         fpPk,
+		ChainId:     chainID,
+		StartHeight: startHeight,
+		Num:         num,
+	}, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create randomness: %w", err)
+	}
+	if len(res.PubRandList) != int(num) {
+		return nil, fmt.Errorf("EOTS manager returned %d public randomness values, expected %d", len(res.PubRandList), num)
+	}
+
+	prList := make([]*btcec.FieldVal, len(res.PubRandList))
+	for i, prBytes := range res.PubRandList {
+		if len(prBytes) != 32 {
+			return nil, fmt.Errorf("public randomness %d has %d bytes, expected 32", i, len(prBytes))
+		}
+		prList[i] = &btcec.FieldVal{}
+		if overflow := prList[i].SetByteSlice(prBytes); overflow {
+			return nil, fmt.Errorf("public randomness %d overflows", i)
+		}
+	}
+	return prList, nil
+}
+
+// SignEOTS returns the EOTS signature of msg at height by the key fpPk
+func (c *Client) SignEOTS(ctx context.Context, fpPk, chainID, msg []byte, height uint64) (*btcec.ModNScalar, error) {
+	res := &SignEOTSResponse{}
+	err := c.invoke(ctx, "SignEOTS", &SignEOTSRequest{
+		Uid:     fpPk,
+		ChainId: chainID,
+		Msg:     msg,
+		Height:  height,
+	}, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign EOTS at height %d: %w", height, err)
+	}
+	if len(res.Sig) != 32 {
+		return nil, fmt.Errorf("EOTS signature has %d bytes, expected 32", len(res.Sig))
+	}
+	var sig btcec.ModNScalar
+	if overflow := sig.SetByteSlice(res.Sig); overflow {
+		return nil, fmt.Errorf("EOTS signature overflows")
+	}
+	return &sig, nil
+}
+
+// SignSchnorrSig returns the BIP340 Schnorr signature of the 32-byte hash
+// msg by the key fpPk
+func (c *Client) SignSchnorrSig(ctx context.Context, fpPk, msg []byte) (*schnorr.Signature, error) {
+	res := &SignSchnorrSigResponse{}
+	err := c.invoke(ctx, "SignSchnorrSig", &SignSchnorrSigRequest{Uid: fpPk, Msg: msg}, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign Schnorr signature: %w", err)
+	}
+	sig, err := schnorr.ParseSignature(res.Sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Schnorr signature: %w", err)
+	}
+	return sig, nil
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, res any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.conn.Invoke(ctx, fullMethod(method), req, res)
+}
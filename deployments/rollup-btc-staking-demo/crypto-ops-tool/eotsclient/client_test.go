@@ -0,0 +1,71 @@
+package eotsclient
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func dialFake(t *testing.T, keys ...*btcec.PrivateKey) *Client {
+	t.Helper()
+	fake := NewFakeServer(keys...)
+	target, err := fake.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Stop)
+
+	c, err := Dial(target, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestPing(t *testing.T) {
+	c := dialFake(t)
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	addr, err := ParseTarget("eotsd://127.0.0.1:12582")
+	if err != nil || addr != "127.0.0.1:12582" {
+		t.Fatalf("ParseTarget = %q, %v", addr, err)
+	}
+	for _, target := range []string{"127.0.0.1:12582", "http://127.0.0.1:12582", "eotsd://"} {
+		if _, err := ParseTarget(target); err == nil {
+			t.Errorf("%q accepted", target)
+		}
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	c := dialFake(t, sk)
+	ctx := context.Background()
+	fpPk := schnorr.SerializePubKey(sk.PubKey())
+	chainID := []byte("consumer-id")
+
+	other, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	_, err := c.SignSchnorrSig(ctx, schnorr.SerializePubKey(other.PubKey()), bytes.Repeat([]byte{0x01}, 32))
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown key: err = %v, want NotFound", err)
+	}
+
+	if _, err := c.SignEOTS(ctx, fpPk, chainID, []byte("block a"), 42); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SignEOTS(ctx, fpPk, chainID, []byte("block b"), 42)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("double sign: err = %v, want FailedPrecondition", err)
+	}
+}
@@ -0,0 +1,128 @@
+package eotsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"crypto-ops-tool/randgen"
+)
+
+// FakeServer is an in-process EOTS manager holding its keys in memory, for
+// integration tests of the remote signer that do not run eotsd. Like
+// eotsd, it derives randomness with randgen and refuses to sign a second
+// message at a height it already signed.
+type FakeServer struct {
+	mu     sync.Mutex
+	keys   map[string]*btcec.PrivateKey
+	signed map[string][]byte
+	server *grpc.Server
+}
+
+// NewFakeServer returns a fake EOTS manager holding keys
+func NewFakeServer(keys ...*btcec.PrivateKey) *FakeServer {
+	s := &FakeServer{
+		keys:   make(map[string]*btcec.PrivateKey, len(keys)),
+		signed: make(map[string][]byte),
+	}
+	for _, sk := range keys {
+		s.keys[bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MarshalHex()] = sk
+	}
+	return s
+}
+
+// Start serves the fake on addr (e.g. 127.0.0.1:0) in the background and
+// returns its eotsd://host:port target
+func (s *FakeServer) Start(addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.server = grpc.NewServer()
+	RegisterEOTSManagerServer(s.server, s)
+	go s.server.Serve(lis)
+	return Scheme + "://" + lis.Addr().String(), nil
+}
+
+// Stop stops serving and closes open connections
+func (s *FakeServer) Stop() {
+	if s.server != nil {
+		s.server.Stop()
+	}
+}
+
+// Ping implements EOTSManagerServer
+func (s *FakeServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return &PingResponse{}, nil
+}
+
+// CreateRandomnessPairList implements EOTSManagerServer
+func (s *FakeServer) CreateRandomnessPairList(_ context.Context, req *CreateRandomnessPairListRequest) (*CreateRandomnessPairListResponse, error) {
+	sk, err := s.key(req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	res := &CreateRandomnessPairListResponse{PubRandList: make([][]byte, req.Num)}
+	for i := range res.PubRandList {
+		_, pr := randgen.GenerateRandomness(sk.Serialize(), req.ChainId, req.StartHeight+uint64(i))
+		prBytes := pr.Bytes()
+		res.PubRandList[i] = prBytes[:]
+	}
+	return res, nil
+}
+
+// SignEOTS implements EOTSManagerServer
+func (s *FakeServer) SignEOTS(_ context.Context, req *SignEOTSRequest) (*SignEOTSResponse, error) {
+	sk, err := s.key(req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	signedKey := fmt.Sprintf("%x/%x/%d", req.Uid, req.ChainId, req.Height)
+	if msg, ok := s.signed[signedKey]; ok && !bytes.Equal(msg, req.Msg) {
+		return nil, status.Errorf(codes.FailedPrecondition, "double sign: height %d was already signed over a different message", req.Height)
+	}
+
+	sr, _ := randgen.GenerateRandomness(sk.Serialize(), req.ChainId, req.Height)
+	sig, err := eots.Sign(sk, sr, req.Msg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign: %v", err)
+	}
+	s.signed[signedKey] = bytes.Clone(req.Msg)
+	sigBytes := sig.Bytes()
+	return &SignEOTSResponse{Sig: sigBytes[:]}, nil
+}
+
+// SignSchnorrSig implements EOTSManagerServer
+func (s *FakeServer) SignSchnorrSig(_ context.Context, req *SignSchnorrSigRequest) (*SignSchnorrSigResponse, error) {
+	sk, err := s.key(req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := schnorr.Sign(sk, req.Msg)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to sign: %v", err)
+	}
+	return &SignSchnorrSigResponse{Sig: sig.Serialize()}, nil
+}
+
+func (s *FakeServer) key(uid []byte) (*btcec.PrivateKey, error) {
+	sk, ok := s.keys[hex.EncodeToString(uid)]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no EOTS key with public key %x", uid)
+	}
+	return sk, nil
+}
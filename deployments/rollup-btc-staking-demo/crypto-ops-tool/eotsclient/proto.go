@@ -0,0 +1,174 @@
+package eotsclient
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// The messages below mirror the subset of eotsmanager/proto/eotsmanager.proto
+// of the finality-provider repository used by crypto-ops. They are declared
+// by hand, with the field numbers of the upstream schema in their struct
+// tags, so that the tool does not depend on the finality-provider module and
+// its pinned Babylon version. The protobuf runtime encodes such tagged
+// structs like generated messages.
+
+// serviceName is the full name of the EOTS manager gRPC service
+const serviceName = "proto.EOTSManager"
+
+// PingRequest checks that the EOTS manager is serving
+type PingRequest struct{}
+
+// PingResponse is the answer to PingRequest
+type PingResponse struct{}
+
+// CreateRandomnessPairListRequest asks for the public randomness of num
+// consecutive heights starting at start_height
+type CreateRandomnessPairListRequest struct {
+	// Uid is the BIP340 public key of the EOTS key
+	Uid []byte `protobuf:"bytes,1,opt,name=uid,proto3"`
+	// ChainId is the id of the chain the randomness is committed to
+	ChainId     []byte `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3"`
+	StartHeight uint64 `protobuf:"varint,3,opt,name=start_height,json=startHeight,proto3"`
+	Num         uint32 `protobuf:"varint,4,opt,name=num,proto3"`
+}
+
+// CreateRandomnessPairListResponse holds 32-byte public randomness values
+type CreateRandomnessPairListResponse struct {
+	PubRandList [][]byte `protobuf:"bytes,1,rep,name=pub_rand_list,json=pubRandList,proto3"`
+}
+
+// SignEOTSRequest asks for the EOTS signature of msg at height, signed with
+// the randomness of that height
+type SignEOTSRequest struct {
+	Uid     []byte `protobuf:"bytes,1,opt,name=uid,proto3"`
+	ChainId []byte `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3"`
+	Msg     []byte `protobuf:"bytes,3,opt,name=msg,proto3"`
+	Height  uint64 `protobuf:"varint,4,opt,name=height,proto3"`
+}
+
+// SignEOTSResponse holds a 32-byte EOTS signature
+type SignEOTSResponse struct {
+	Sig []byte `protobuf:"bytes,1,opt,name=sig,proto3"`
+}
+
+// SignSchnorrSigRequest asks for the BIP340 Schnorr signature of the 32-byte
+// msg
+type SignSchnorrSigRequest struct {
+	Uid []byte `protobuf:"bytes,1,opt,name=uid,proto3"`
+	Msg []byte `protobuf:"bytes,2,opt,name=msg,proto3"`
+}
+
+// SignSchnorrSigResponse holds a 64-byte Schnorr signature
+type SignSchnorrSigResponse struct {
+	Sig []byte `protobuf:"bytes,1,opt,name=sig,proto3"`
+}
+
+func (m *PingRequest) Reset()         { *m = PingRequest{} }
+func (m *PingRequest) String() string { return messageString(m) }
+func (*PingRequest) ProtoMessage()    {}
+
+func (m *PingResponse) Reset()         { *m = PingResponse{} }
+func (m *PingResponse) String() string { return messageString(m) }
+func (*PingResponse) ProtoMessage()    {}
+
+func (m *CreateRandomnessPairListRequest) Reset()         { *m = CreateRandomnessPairListRequest{} }
+func (m *CreateRandomnessPairListRequest) String() string { return messageString(m) }
+func (*CreateRandomnessPairListRequest) ProtoMessage()    {}
+
+func (m *CreateRandomnessPairListResponse) Reset()         { *m = CreateRandomnessPairListResponse{} }
+func (m *CreateRandomnessPairListResponse) String() string { return messageString(m) }
+func (*CreateRandomnessPairListResponse) ProtoMessage()    {}
+
+func (m *SignEOTSRequest) Reset()         { *m = SignEOTSRequest{} }
+func (m *SignEOTSRequest) String() string { return messageString(m) }
+func (*SignEOTSRequest) ProtoMessage()    {}
+
+func (m *SignEOTSResponse) Reset()         { *m = SignEOTSResponse{} }
+func (m *SignEOTSResponse) String() string { return messageString(m) }
+func (*SignEOTSResponse) ProtoMessage()    {}
+
+func (m *SignSchnorrSigRequest) Reset()         { *m = SignSchnorrSigRequest{} }
+func (m *SignSchnorrSigRequest) String() string { return messageString(m) }
+func (*SignSchnorrSigRequest) ProtoMessage()    {}
+
+func (m *SignSchnorrSigResponse) Reset()         { *m = SignSchnorrSigResponse{} }
+func (m *SignSchnorrSigResponse) String() string { return messageString(m) }
+func (*SignSchnorrSigResponse) ProtoMessage()    {}
+
+// messageString formats a message in the protobuf text format
+func messageString(m protoadapt.MessageV1) string {
+	return prototext.Format(protoadapt.MessageV2Of(m))
+}
+
+// EOTSManagerServer is the subset of the EOTS manager service implemented
+// by FakeServer
+type EOTSManagerServer interface {
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	CreateRandomnessPairList(context.Context, *CreateRandomnessPairListRequest) (*CreateRandomnessPairListResponse, error)
+	SignEOTS(context.Context, *SignEOTSRequest) (*SignEOTSResponse, error)
+	SignSchnorrSig(context.Context, *SignSchnorrSigRequest) (*SignSchnorrSigResponse, error)
+}
+
+// RegisterEOTSManagerServer registers srv on s under the upstream service
+// name
+func RegisterEOTSManagerServer(s grpc.ServiceRegistrar, srv EOTSManagerServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*EOTSManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler: unaryHandler("Ping", func(srv EOTSManagerServer, ctx context.Context, req *PingRequest) (any, error) {
+				return srv.Ping(ctx, req)
+			}),
+		},
+		{
+			MethodName: "CreateRandomnessPairList",
+			Handler: unaryHandler("CreateRandomnessPairList", func(srv EOTSManagerServer, ctx context.Context, req *CreateRandomnessPairListRequest) (any, error) {
+				return srv.CreateRandomnessPairList(ctx, req)
+			}),
+		},
+		{
+			MethodName: "SignEOTS",
+			Handler: unaryHandler("SignEOTS", func(srv EOTSManagerServer, ctx context.Context, req *SignEOTSRequest) (any, error) {
+				return srv.SignEOTS(ctx, req)
+			}),
+		},
+		{
+			MethodName: "SignSchnorrSig",
+			Handler: unaryHandler("SignSchnorrSig", func(srv EOTSManagerServer, ctx context.Context, req *SignSchnorrSigRequest) (any, error) {
+				return srv.SignSchnorrSig(ctx, req)
+			}),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "eotsmanager.proto",
+}
+
+// unaryHandler adapts a typed server method to a grpc.MethodDesc handler
+func unaryHandler[Req any](method string, call func(EOTSManagerServer, context.Context, *Req) (any, error)) grpc.MethodHandler {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := new(Req)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(EOTSManagerServer), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(method)}
+		return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return call(srv.(EOTSManagerServer), ctx, req.(*Req))
+		})
+	}
+}
+
+// fullMethod returns the gRPC path of a method of the service
+func fullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
@@ -0,0 +1,114 @@
+// Package hdkey derives finality provider BTC keys from a BIP39 mnemonic
+// along BIP32 paths, so that test environments can be rebuilt with the same
+// FP identities instead of throwaway random keys.
+package hdkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/cosmos/go-bip39"
+)
+
+const (
+	// DefaultPath is the BIP86 (taproot) path of testnet keys, with i the
+	// index of the key
+	DefaultPath = "m/86'/1'/0'/0/i"
+	// IndexPlaceholder is the path element replaced by the key index
+	IndexPlaceholder = "i"
+
+	// mnemonicEntropyBits gives 24-word mnemonics
+	mnemonicEntropyBits = 256
+)
+
+// NewMnemonic returns a new random 24-word BIP39 mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
+
+// ExpandPath replaces the index placeholder of path with index
+func ExpandPath(path string, index uint32) string {
+	elems := strings.Split(path, "/")
+	for i, elem := range elems {
+		switch elem {
+		case IndexPlaceholder:
+			elems[i] = strconv.FormatUint(uint64(index), 10)
+		case IndexPlaceholder + "'", IndexPlaceholder + "h":
+			elems[i] = strconv.FormatUint(uint64(index), 10) + "'"
+		}
+	}
+	return strings.Join(elems, "/")
+}
+
+// ParsePath parses a BIP32 path such as m/86'/1'/0'/0/5 into child
+// indexes. Hardened elements are marked with ' or h.
+func ParsePath(path string) ([]uint32, error) {
+	elems := strings.Split(strings.TrimSpace(path), "/")
+	if len(elems) == 0 || elems[0] != "m" {
+		return nil, fmt.Errorf("invalid path %q: must start with m/", path)
+	}
+	indexes := make([]uint32, 0, len(elems)-1)
+	for _, elem := range elems[1:] {
+		hardened := strings.HasSuffix(elem, "'") || strings.HasSuffix(elem, "h")
+		if hardened {
+			elem = elem[:len(elem)-1]
+		}
+		index, err := strconv.ParseUint(elem, 10, 32)
+		if err != nil || index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("invalid path %q: bad element %q", path, elem)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// DeriveKey derives the key at path from mnemonic and its optional BIP39
+// passphrase
+func DeriveKey(mnemonic, passphrase, path string) (*btcec.PrivateKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, fmt.Errorf("invalid BIP39 mnemonic")
+	}
+
+	return deriveSeedKey(bip39.NewSeed(mnemonic, passphrase), path, indexes)
+}
+
+// deriveSeedKey derives the key at the child indexes of path from a BIP32
+// seed
+func deriveSeedKey(seed []byte, path string, indexes []uint32) (*btcec.PrivateKey, error) {
+	// The network only affects the serialization of extended keys
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive master key: %w", err)
+	}
+	for _, index := range indexes {
+		key, err = key.Derive(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+		}
+	}
+	sk, err := key.ECPrivKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+	}
+	return sk, nil
+}
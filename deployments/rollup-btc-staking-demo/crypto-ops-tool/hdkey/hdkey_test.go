@@ -0,0 +1,131 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/cosmos/go-bip39"
+)
+
+// testMnemonic is the mnemonic of the BIP39 and BIP86 test vectors
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestSeedVector(t *testing.T) {
+	// BIP39 test vector of entropy 0x00 * 16 with passphrase TREZOR
+	want := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if got := hex.EncodeToString(bip39.NewSeed(testMnemonic, "TREZOR")); got != want {
+		t.Fatalf("seed = %s, want %s", got, want)
+	}
+}
+
+func TestDeriveSeedKeyVectors(t *testing.T) {
+	// BIP32 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	vectors := map[string]string{
+		"m":                      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":                   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":                 "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0h/1/2h":              "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+		"m/0'/1/2'/2":            "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+		"m/0'/1/2'/2/1000000000": "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	}
+	for path, want := range vectors {
+		indexes, err := ParsePath(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		sk, err := deriveSeedKey(seed, path, indexes)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if got := hex.EncodeToString(sk.Serialize()); got != want {
+			t.Errorf("%s: key = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// BIP86 test vector of the first receiving key on mainnet
+	sk, err := DeriveKey(testMnemonic, "", "m/86'/0'/0'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(sk.Serialize()); got != "41f41d69260df4cf277826a9b65a3717e4eeddbeedf637f212ca096576479361" {
+		t.Errorf("BIP86 key = %s", got)
+	}
+	if got := hex.EncodeToString(schnorr.SerializePubKey(sk.PubKey())); got != "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115" {
+		t.Errorf("BIP86 internal key = %s", got)
+	}
+
+	// The FP keys of the default path must stay the same across releases
+	keys := []string{
+		"dff1c8c2c016a572914b4c5adb8791d62b4768ae9d0a61be8ab94cf5038d7d90",
+		"1ede31b0e7e47c2afc65ffd158b1b1b9d3b752bba8fd117dc8b9e944a390e8d9",
+		"1fb777f1a6fb9b76724551f8bc8ad91b77f33b8c456d65d746035391d724922a",
+	}
+	for i, want := range keys {
+		sk, err := DeriveKey(testMnemonic, "", ExpandPath(DefaultPath, uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sk.Serialize()); got != want {
+			t.Errorf("key %d = %s, want %s", i, got, want)
+		}
+	}
+
+	// Extra whitespace in the mnemonic is ignored
+	sk, err = DeriveKey("  abandon abandon abandon abandon abandon abandon\n abandon abandon abandon abandon abandon  about ", "", "m/86'/1'/0'/0/0")
+	if err != nil || hex.EncodeToString(sk.Serialize()) != keys[0] {
+		t.Errorf("mnemonic with extra whitespace: %v", err)
+	}
+
+	if _, err := DeriveKey("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", DefaultPath); err == nil {
+		t.Error("mnemonic with a bad checksum accepted")
+	}
+	if _, err := DeriveKey(testMnemonic, "", "86'/1'"); err == nil {
+		t.Error("path without m/ accepted")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	const h = 1 << 31
+	valid := map[string][]uint32{
+		"m":                 {},
+		"m/0":               {0},
+		"m/86'/1'/0'/0/5":   {h + 86, h + 1, h, 0, 5},
+		"m/86h/1h/0h/0/5":   {h + 86, h + 1, h, 0, 5},
+		" m/2147483647'/1 ": {h + 2147483647, 1},
+	}
+	for path, want := range valid {
+		got, err := ParsePath(path)
+		if err != nil {
+			t.Errorf("%q: %v", path, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%q = %v, want %v", path, got, want)
+		}
+	}
+
+	for _, path := range []string{"", "86'/1'", "m/", "m/x", "m/-1", "m/2147483648", "m/1''", "m//1", "M/1"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Errorf("%q accepted", path)
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	tests := map[string]string{
+		DefaultPath:       "m/86'/1'/0'/0/7",
+		"m/86'/1'/i'/0/0": "m/86'/1'/7'/0/0",
+		"m/86'/1'/ih":     "m/86'/1'/7'",
+		"m/86'/1'/0'/0/1": "m/86'/1'/0'/0/1",
+	}
+	for path, want := range tests {
+		if got := ExpandPath(path, 7); got != want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptocodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	"github.com/cosmos/cosmos-sdk/crypto/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keyring"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// Keyring gives access to the secp256k1 keys of a Cosmos SDK keyring, where
+// babylond, fpd and eotsd keep their keys. A BTC key is the raw secp256k1
+// key of a record, so a key added with `eotsd keys add` is usable as is.
+type Keyring struct {
+	kr      keyring.Keyring
+	dir     string
+	backend string
+}
+
+// OpenKeyring opens the keyring of the given backend (test, file, os) in
+// dir. dir may also be the backend directory itself, e.g.
+// .testnets/eotsmanager/keyring-test. input answers the passphrase prompts of
+// the file backend.
+func OpenKeyring(dir, backend string, input io.Reader) (*Keyring, error) {
+	if filepath.Base(dir) == "keyring-"+backend {
+		dir = filepath.Dir(dir)
+	}
+
+	ir := codectypes.NewInterfaceRegistry()
+	cryptocodec.RegisterInterfaces(ir)
+	cdc := codec.NewProtoCodec(ir)
+
+	kr, err := keyring.New(sdk.KeyringServiceName(), backend, dir, input, cdc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s keyring at %s: %w", backend, dir, err)
+	}
+	return &Keyring{kr: kr, dir: dir, backend: backend}, nil
+}
+
+// Dir returns a description of where the keys are stored
+func (k *Keyring) Dir() string {
+	return fmt.Sprintf("%s keyring at %s", k.backend, k.dir)
+}
+
+// Add stores sk under name as a secp256k1 record
+func (k *Keyring) Add(name string, sk *btcec.PrivateKey) (*KeyInfo, error) {
+	if _, err := k.kr.Key(name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyExists, name)
+	}
+	if err := k.kr.ImportPrivKeyHex(name, hex.EncodeToString(sk.Serialize()), string(hd.Secp256k1Type)); err != nil {
+		return nil, fmt.Errorf("failed to import key %s: %w", name, err)
+	}
+	return k.Info(name)
+}
+
+// Get returns the BTC key of the record stored under name
+func (k *Keyring) Get(name string) (*btcec.PrivateKey, error) {
+	record, err := k.record(name)
+	if err != nil {
+		return nil, err
+	}
+	local := record.GetLocal()
+	if local == nil || local.PrivKey == nil {
+		return nil, fmt.Errorf("key %s has no local private key", name)
+	}
+	privKey, ok := local.PrivKey.GetCachedValue().(cryptotypes.PrivKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to decode private key of %s", name)
+	}
+	if privKey.Type() != string(hd.Secp256k1Type) {
+		return nil, fmt.Errorf("key %s is a %s key, BTC keys are secp256k1", name, privKey.Type())
+	}
+	sk, _ := btcec.PrivKeyFromBytes(privKey.Bytes())
+	return sk, nil
+}
+
+// Info returns the description of the key stored under name
+func (k *Keyring) Info(name string) (*KeyInfo, error) {
+	record, err := k.record(name)
+	if err != nil {
+		return nil, err
+	}
+	return newRecordInfo(record)
+}
+
+// List returns the description of every secp256k1 key of the keyring,
+// sorted by name
+func (k *Keyring) List() ([]*KeyInfo, error) {
+	records, err := k.kr.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	var infos []*KeyInfo
+	for _, record := range records {
+		info, err := newRecordInfo(record)
+		if err != nil {
+			// Keys of other algorithms are not BTC keys
+			continue
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (k *Keyring) record(name string) (*keyring.Record, error) {
+	record, err := k.kr.Key(name)
+	if errors.Is(err, sdkerrors.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", name, err)
+	}
+	return record, nil
+}
+
+// newRecordInfo describes a secp256k1 keyring record by its BIP340 key
+func newRecordInfo(record *keyring.Record) (*KeyInfo, error) {
+	pubKey, err := record.GetPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key of %s: %w", record.Name, err)
+	}
+	if pubKey.Type() != string(hd.Secp256k1Type) {
+		return nil, fmt.Errorf("key %s is a %s key, BTC keys are secp256k1", record.Name, pubKey.Type())
+	}
+	btcPk, err := btcec.ParsePubKey(pubKey.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of %s: %w", record.Name, err)
+	}
+	return &KeyInfo{
+		Name:      record.Name,
+		PublicKey: bbn.NewBIP340PubKeyFromBTCPK(btcPk).MarshalHex(),
+	}, nil
+}
@@ -0,0 +1,287 @@
+// Package keystore keeps named finality provider BTC keys under the
+// crypto-ops home directory, each encrypted at rest with a passphrase:
+// the passphrase is stretched with scrypt into an AES-256-GCM key that seals
+// the private key. Only the public key is stored in the clear. Keys may
+// also be kept in a Cosmos SDK keyring shared with eotsd.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// keysDirName is the directory under the home directory holding the keys
+	keysDirName = "keys"
+	// keyFileExt is the extension of key files
+	keyFileExt = ".json"
+
+	// kdfScrypt and cipherAESGCM are the only algorithms of version 1
+	kdfScrypt    = "scrypt"
+	cipherAESGCM = "aes-256-gcm"
+	version      = 1
+
+	// Scrypt parameters of new keys, the "standard" ones of go-ethereum's
+	// keystore with scryptN (about a second and 256MB per decryption)
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 32
+)
+
+// scryptN is the scrypt cost of new keys. Each key file records the cost
+// it was sealed with, so tests lower it without affecting existing keys.
+var scryptN = 1 << 18
+
+var (
+	// ErrKeyNotFound is returned when no key has the requested name
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists is returned when adding a key under a name already in use
+	ErrKeyExists = errors.New("key already exists")
+	// ErrWrongPassphrase is returned when a key cannot be decrypted
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// KeyInfo describes a stored key without its secret. CreatedAt and
+// FileVersion are only known for keys of the encrypted keystore.
+type KeyInfo struct {
+	Name        string     `json:"name"`
+	PublicKey   string     `json:"public_key"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	FileVersion int        `json:"version,omitempty"`
+}
+
+// keyFile is the on-disk encoding of a key
+type keyFile struct {
+	KeyInfo
+	Crypto cryptoParams `json:"crypto"`
+}
+
+// cryptoParams are the parameters needed to decrypt a key
+type cryptoParams struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Keystore is a directory of encrypted keys
+type Keystore struct {
+	dir string
+}
+
+// Open returns the keystore under homeDir, creating its directory if it
+// does not exist
+func Open(homeDir string) (*Keystore, error) {
+	if homeDir == "" {
+		return nil, fmt.Errorf("home directory must be set")
+	}
+	dir := filepath.Join(homeDir, keysDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory %s: %w", dir, err)
+	}
+	return &Keystore{dir: dir}, nil
+}
+
+// Dir returns the directory of the key files
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+// Add encrypts sk with passphrase and stores it under name
+func (ks *Keystore) Add(name string, sk *btcec.PrivateKey, passphrase []byte) (*KeyInfo, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := cryptoParams{
+		KDF:    kdfScrypt,
+		N:      scryptN,
+		R:      scryptR,
+		P:      scryptP,
+		Salt:   hex.EncodeToString(salt),
+		Cipher: cipherAESGCM,
+	}
+	aead, err := params.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	createdAt := time.Now().UTC()
+	info := KeyInfo{
+		Name:        name,
+		PublicKey:   bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MarshalHex(),
+		CreatedAt:   &createdAt,
+		FileVersion: version,
+	}
+	// The public key is authenticated with the secret so that a swapped
+	// public key is detected on decryption
+	params.Nonce = hex.EncodeToString(nonce)
+	params.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, sk.Serialize(), []byte(info.PublicKey)))
+
+	data, err := json.MarshalIndent(&keyFile{KeyInfo: info, Crypto: params}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	// O_EXCL makes a concurrent add of the same name fail instead of
+	// overwriting a key
+	f, err := os.OpenFile(ks.path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyExists, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(ks.path(name))
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return &info, nil
+}
+
+// Get decrypts the key stored under name
+func (ks *Keystore) Get(name string, passphrase []byte) (*btcec.PrivateKey, error) {
+	kf, err := ks.read(name)
+	if err != nil {
+		return nil, err
+	}
+	if kf.Crypto.KDF != kdfScrypt || kf.Crypto.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("key %s uses unsupported kdf %q or cipher %q", name, kf.Crypto.KDF, kf.Crypto.Cipher)
+	}
+	aead, err := kf.Crypto.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(kf.Crypto.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("key %s has an invalid nonce", name)
+	}
+	ciphertext, err := hex.DecodeString(kf.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("key %s has an invalid ciphertext: %w", name, err)
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(kf.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w for key %s", ErrWrongPassphrase, name)
+	}
+
+	sk, _ := btcec.PrivKeyFromBytes(plaintext)
+	if bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MarshalHex() != kf.PublicKey {
+		return nil, fmt.Errorf("key %s does not match its public key %s", name, kf.PublicKey)
+	}
+	return sk, nil
+}
+
+// Info returns the description of the key stored under name
+func (ks *Keystore) Info(name string) (*KeyInfo, error) {
+	kf, err := ks.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return &kf.KeyInfo, nil
+}
+
+// List returns the description of every stored key, sorted by name
+func (ks *Keystore) List() ([]*KeyInfo, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys directory: %w", err)
+	}
+	var infos []*KeyInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyFileExt) {
+			continue
+		}
+		info, err := ks.Info(strings.TrimSuffix(entry.Name(), keyFileExt))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.dir, name+keyFileExt)
+}
+
+func (ks *Keystore) read(name string) (*keyFile, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q", name)
+	}
+	data, err := os.ReadFile(ks.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", name, err)
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("failed to decode key %s: %w", name, err)
+	}
+	if kf.FileVersion != version {
+		return nil, fmt.Errorf("key %s has unsupported version %d", name, kf.FileVersion)
+	}
+	return &kf, nil
+}
+
+// aead derives the AES-256-GCM cipher of passphrase with the scrypt
+// parameters of p
+func (p *cryptoParams) aead(passphrase []byte) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	key, err := scrypt.Key(passphrase, salt, p.N, p.R, p.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key from passphrase: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,158 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the key derivation of the tests fast
+	scryptN = 1 << 10
+	os.Exit(m.Run())
+}
+
+func newTestKeystore(t *testing.T) (*Keystore, *btcec.PrivateKey) {
+	t.Helper()
+	ks, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	if _, err := ks.Add("fp", sk, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	return ks, sk
+}
+
+// rewrite applies edit to the stored file of the key name
+func rewrite(t *testing.T, ks *Keystore, name string, edit func(kf *keyFile)) {
+	t.Helper()
+	data, err := os.ReadFile(ks.path(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		t.Fatal(err)
+	}
+	edit(&kf)
+	if data, err = json.Marshal(&kf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ks.path(name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ks, sk := newTestKeystore(t)
+
+	got, err := ks.Get("fp", []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Serialize(), sk.Serialize()) {
+		t.Fatal("decrypted key differs from the added one")
+	}
+
+	info, err := ks.Info("fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PublicKey != bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()).MarshalHex() {
+		t.Errorf("public key = %s", info.PublicKey)
+	}
+
+	// Only the public key is in the clear
+	data, err := os.ReadFile(ks.path("fp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(hex.EncodeToString(sk.Serialize()))) {
+		t.Fatal("key file holds the private key in the clear")
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	ks, _ := newTestKeystore(t)
+
+	if _, err := ks.Get("fp", []byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestTamperedKeyFile(t *testing.T) {
+	other, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	for name, edit := range map[string]func(kf *keyFile){
+		"ciphertext": func(kf *keyFile) {
+			ct, _ := hex.DecodeString(kf.Crypto.Ciphertext)
+			ct[0] ^= 0x01
+			kf.Crypto.Ciphertext = hex.EncodeToString(ct)
+		},
+		// The public key is authenticated with the secret
+		"public key": func(kf *keyFile) {
+			kf.PublicKey = bbn.NewBIP340PubKeyFromBTCPK(other.PubKey()).MarshalHex()
+		},
+		"nonce": func(kf *keyFile) {
+			kf.Crypto.Nonce = kf.Crypto.Nonce[:len(kf.Crypto.Nonce)-2]
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ks, _ := newTestKeystore(t)
+			rewrite(t, ks, "fp", edit)
+			if _, err := ks.Get("fp", []byte("passphrase")); err == nil {
+				t.Fatal("tampered key decrypted")
+			}
+		})
+	}
+}
+
+func TestKeyExists(t *testing.T) {
+	ks, sk := newTestKeystore(t)
+
+	if _, err := ks.Add("fp", sk, []byte("other")); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("err = %v, want ErrKeyExists", err)
+	}
+	// The first key is left untouched
+	if _, err := ks.Get("fp", []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	ks, sk := newTestKeystore(t)
+
+	for _, name := range []string{"../x", "a/b", ".hidden", "", "-flag", "x y"} {
+		if _, err := ks.Add(name, sk, []byte("passphrase")); err == nil {
+			t.Errorf("key name %q accepted", name)
+		}
+		if _, err := ks.Get(name, []byte("passphrase")); err == nil || errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("key name %q not rejected: %v", name, err)
+		}
+	}
+	if _, err := ks.Get("missing", []byte("passphrase")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("err = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ks, sk := newTestKeystore(t)
+	if _, err := ks.Add("another", sk, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := ks.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Name != "another" || infos[1].Name != "fp" {
+		t.Fatalf("list = %+v", infos)
+	}
+}
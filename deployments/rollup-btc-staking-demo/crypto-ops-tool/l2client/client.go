@@ -0,0 +1,205 @@
+// Package l2client is a minimal Ethereum JSON-RPC client fetching the blocks
+// of an OP-stack L2 that finality providers vote on, like the [opstackl2]
+// OPStackL2RPCAddress endpoint of the consumer finality provider.
+package l2client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBlockNotFound is returned when the L2 has no block at the requested height
+var ErrBlockNotFound = errors.New("block not found")
+
+// Block is the part of an L2 block header a finality signature commits to
+type Block struct {
+	Number     uint64
+	Hash       []byte
+	ParentHash []byte
+	Timestamp  uint64
+}
+
+// Client talks to an Ethereum JSON-RPC endpoint over HTTP
+type Client struct {
+	addr       string
+	httpClient *http.Client
+	nextID     atomic.Uint64
+}
+
+// New returns a client of the JSON-RPC endpoint at addr; each request is
+// bounded by timeout
+func New(addr string, timeout time.Duration) (*Client, error) {
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		return nil, fmt.Errorf("L2 RPC address must be an http(s) URL, got %q", addr)
+	}
+	return &Client{
+		addr:       addr,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// BlockByNumber returns the block at height
+func (c *Client) BlockByNumber(ctx context.Context, height uint64) (*Block, error) {
+	block, err := c.getBlock(ctx, "0x"+strconv.FormatUint(height, 16))
+	if err != nil {
+		return nil, err
+	}
+	if block.Number != height {
+		return nil, fmt.Errorf("L2 returned block %d for height %d", block.Number, height)
+	}
+	return block, nil
+}
+
+// BlockByTag returns the block of a tag such as latest, safe or finalized
+func (c *Client) BlockByTag(ctx context.Context, tag string) (*Block, error) {
+	return c.getBlock(ctx, tag)
+}
+
+// LatestBlockNumber returns the height of the L2 tip
+func (c *Client) LatestBlockNumber(ctx context.Context) (uint64, error) {
+	var result string
+	if err := c.call(ctx, "eth_blockNumber", []interface{}{}, &result); err != nil {
+		return 0, err
+	}
+	return parseQuantity(result)
+}
+
+func (c *Client) getBlock(ctx context.Context, blockParam string) (*Block, error) {
+	// Only the header is needed, so transactions are not requested in full
+	var result *struct {
+		Number     string `json:"number"`
+		Hash       string `json:"hash"`
+		ParentHash string `json:"parentHash"`
+		Timestamp  string `json:"timestamp"`
+	}
+	if err := c.call(ctx, "eth_getBlockByNumber", []interface{}{blockParam, false}, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, blockParam)
+	}
+
+	number, err := parseQuantity(result.Number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number: %w", err)
+	}
+	hash, err := parseHash(result.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block hash: %w", err)
+	}
+	parentHash, err := parseHash(result.ParentHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent hash: %w", err)
+	}
+	timestamp, err := parseQuantity(result.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block timestamp: %w", err)
+	}
+
+	return &Block{
+		Number:     number,
+		Hash:       hash,
+		ParentHash: parentHash,
+		Timestamp:  timestamp,
+	}, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// call runs a JSON-RPC method and decodes its result into result
+func (c *Client) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	reqBody, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request to %s failed: %w", method, c.addr, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s request to %s returned %s: %s", method, c.addr, resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s failed: %w", method, rpcResp.Error)
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// parseQuantity decodes a 0x-prefixed hex quantity
+func parseQuantity(s string) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return 0, fmt.Errorf("quantity %q is not 0x-prefixed", s)
+	}
+	return strconv.ParseUint(s[2:], 16, 64)
+}
+
+// parseHash decodes a 0x-prefixed 32-byte hash
+func parseHash(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("hash %q is not 0x-prefixed", s)
+	}
+	hash, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash %q is %d bytes, expected 32", s, len(hash))
+	}
+	return hash, nil
+}
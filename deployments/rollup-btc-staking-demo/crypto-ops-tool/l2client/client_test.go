@@ -0,0 +1,211 @@
+package l2client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubServer is a JSON-RPC server answering every request with the result
+// or error reply returns for its method and params
+func stubServer(t *testing.T, reply func(method string, params []interface{}) (result interface{}, rpcErr *rpcError)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			JSONRPC string        `json:"jsonrpc"`
+			ID      uint64        `json:"id"`
+			Method  string        `json:"method"`
+			Params  []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.JSONRPC != "2.0" {
+			http.Error(w, "not a JSON-RPC 2.0 request", http.StatusBadRequest)
+			return
+		}
+		result, rpcErr := reply(req.Method, req.Params)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	c, err := New(srv.URL, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// hash returns the 0x-prefixed hex of a 32-byte hash filled with b
+func hash(b byte) string {
+	return "0x" + hex.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func blockJSON(number string, hashByte byte) map[string]interface{} {
+	return map[string]interface{}{
+		"number":       number,
+		"hash":         hash(hashByte),
+		"parentHash":   hash(hashByte - 1),
+		"timestamp":    "0x6553f100",
+		"transactions": []string{},
+	}
+}
+
+func TestBlockByNumber(t *testing.T) {
+	var gotParams []interface{}
+	srv := stubServer(t, func(method string, params []interface{}) (interface{}, *rpcError) {
+		if method != "eth_getBlockByNumber" {
+			return nil, &rpcError{Code: -32601, Message: "method not found"}
+		}
+		gotParams = params
+		return blockJSON("0x2a", 0xab), nil
+	})
+
+	block, err := newTestClient(t, srv).BlockByNumber(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The height is sent as a hex quantity, without full transactions
+	if len(gotParams) != 2 || gotParams[0] != "0x2a" || gotParams[1] != false {
+		t.Fatalf("params = %v, want [0x2a false]", gotParams)
+	}
+	if block.Number != 42 {
+		t.Errorf("number = %d, want 42", block.Number)
+	}
+	if !bytes.Equal(block.Hash, bytes.Repeat([]byte{0xab}, 32)) {
+		t.Errorf("hash = %x", block.Hash)
+	}
+	if !bytes.Equal(block.ParentHash, bytes.Repeat([]byte{0xaa}, 32)) {
+		t.Errorf("parent hash = %x", block.ParentHash)
+	}
+	if block.Timestamp != 0x6553f100 {
+		t.Errorf("timestamp = %d", block.Timestamp)
+	}
+}
+
+func TestBlockByNumberNotFound(t *testing.T) {
+	srv := stubServer(t, func(string, []interface{}) (interface{}, *rpcError) {
+		return nil, nil
+	})
+
+	_, err := newTestClient(t, srv).BlockByNumber(context.Background(), 1000)
+	if !errors.Is(err, ErrBlockNotFound) {
+		t.Fatalf("err = %v, want ErrBlockNotFound", err)
+	}
+}
+
+func TestBlockByNumberMismatch(t *testing.T) {
+	srv := stubServer(t, func(string, []interface{}) (interface{}, *rpcError) {
+		return blockJSON("0x2b", 0xab), nil
+	})
+
+	_, err := newTestClient(t, srv).BlockByNumber(context.Background(), 42)
+	if err == nil || !strings.Contains(err.Error(), "returned block 43 for height 42") {
+		t.Fatalf("err = %v, want a number mismatch", err)
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	srv := stubServer(t, func(string, []interface{}) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -32000, Message: "header not found"}
+	})
+
+	_, err := newTestClient(t, srv).BlockByNumber(context.Background(), 42)
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("err = %v, want a JSON-RPC error", err)
+	}
+	if rpcErr.Code != -32000 || rpcErr.Message != "header not found" {
+		t.Errorf("rpc error = %+v", rpcErr)
+	}
+	if errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("a JSON-RPC error is not ErrBlockNotFound")
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := newTestClient(t, srv).BlockByNumber(context.Background(), 42)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("err = %v, want the HTTP status", err)
+	}
+}
+
+func TestInvalidBlock(t *testing.T) {
+	for name, block := range map[string]map[string]interface{}{
+		"decimal number": {"number": "42", "hash": hash(1), "parentHash": hash(0), "timestamp": "0x1"},
+		"short hash":     {"number": "0x2a", "hash": "0xabcd", "parentHash": hash(0), "timestamp": "0x1"},
+		"bad timestamp":  {"number": "0x2a", "hash": hash(1), "parentHash": hash(0), "timestamp": "0xzz"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := stubServer(t, func(string, []interface{}) (interface{}, *rpcError) {
+				return block, nil
+			})
+			if _, err := newTestClient(t, srv).BlockByNumber(context.Background(), 42); err == nil {
+				t.Fatal("invalid block accepted")
+			}
+		})
+	}
+}
+
+func TestLatestBlockNumberAndTag(t *testing.T) {
+	srv := stubServer(t, func(method string, params []interface{}) (interface{}, *rpcError) {
+		switch method {
+		case "eth_blockNumber":
+			return "0x3e8", nil
+		case "eth_getBlockByNumber":
+			if params[0] != "finalized" {
+				return nil, &rpcError{Code: -32602, Message: "unexpected block parameter"}
+			}
+			return blockJSON("0x3e0", 0x10), nil
+		}
+		return nil, &rpcError{Code: -32601, Message: "method not found"}
+	})
+	c := newTestClient(t, srv)
+
+	tip, err := c.LatestBlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip != 1000 {
+		t.Errorf("tip = %d, want 1000", tip)
+	}
+
+	block, err := c.BlockByTag(context.Background(), "finalized")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Number != 992 {
+		t.Errorf("finalized = %d, want 992", block.Number)
+	}
+}
+
+func TestNewRejectsNonHTTPAddress(t *testing.T) {
+	if _, err := New("localhost:8545", time.Second); err == nil {
+		t.Fatal("address without scheme accepted")
+	}
+}
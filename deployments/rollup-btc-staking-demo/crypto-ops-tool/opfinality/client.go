@@ -0,0 +1,183 @@
+package opfinality
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Executor broadcasts JSON execute messages to a contract, one per
+// transaction or many in a single transaction. Those may be sent by any
+// account, except with ExecuteContractFromKey, which sends from the key the
+// contract admin was set to.
+type Executor interface {
+	ExecuteContract(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error)
+	ExecuteContractBatch(ctx context.Context, contractAddr string, msgs [][]byte) (*sdk.TxResponse, error)
+	ExecuteContractFromKey(ctx context.Context, contractAddr string, msg []byte) (*sdk.TxResponse, error)
+}
+
+// Querier runs a JSON smart query against a contract
+type Querier interface {
+	QuerySmart(ctx context.Context, contractAddr string, query []byte) ([]byte, error)
+}
+
+// ExecuteQuerier is satisfied by bbnclient.Client
+type ExecuteQuerier interface {
+	Executor
+	Querier
+}
+
+// Client is a typed client of a deployed op-finality-gadget contract
+type Client struct {
+	chain        ExecuteQuerier
+	contractAddr string
+}
+
+// NewClient returns a client of the contract at contractAddr
+func NewClient(chain ExecuteQuerier, contractAddr string) *Client {
+	return &Client{
+		chain:        chain,
+		contractAddr: contractAddr,
+	}
+}
+
+// Address returns the contract address
+func (c *Client) Address() string {
+	return c.contractAddr
+}
+
+// CommitPublicRandomness executes commit_public_randomness
+func (c *Client) CommitPublicRandomness(ctx context.Context, msg *CommitPublicRandomness) (*sdk.TxResponse, error) {
+	return c.execute(ctx, &ExecuteMsg{CommitPublicRandomness: msg})
+}
+
+// SubmitFinalitySignature executes submit_finality_signature
+func (c *Client) SubmitFinalitySignature(ctx context.Context, msg *SubmitFinalitySignature) (*sdk.TxResponse, error) {
+	return c.execute(ctx, &ExecuteMsg{SubmitFinalitySignature: msg})
+}
+
+// SubmitFinalitySignatures executes one submit_finality_signature per
+// message in a single transaction. The transaction is atomic, so either all
+// signatures are accepted or none is.
+func (c *Client) SubmitFinalitySignatures(ctx context.Context, msgs []*SubmitFinalitySignature) (*sdk.TxResponse, error) {
+	execMsgs := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		msgBytes, err := json.Marshal(&ExecuteMsg{SubmitFinalitySignature: msg})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal execute message %d: %w", i, err)
+		}
+		execMsgs[i] = msgBytes
+	}
+	return c.chain.ExecuteContractBatch(ctx, c.contractAddr, execMsgs)
+}
+
+// SetEnabled executes set_enabled
+func (c *Client) SetEnabled(ctx context.Context, enabled bool) (*sdk.TxResponse, error) {
+	return c.executeAsAdmin(ctx, &ExecuteMsg{SetEnabled: &SetEnabled{Enabled: enabled}})
+}
+
+// Slashing executes slashing
+func (c *Client) Slashing(ctx context.Context, evidence *Evidence) (*sdk.TxResponse, error) {
+	return c.execute(ctx, &ExecuteMsg{Slashing: &Slashing{Evidence: *evidence}})
+}
+
+// QueryConfig returns the contract configuration
+func (c *Client) QueryConfig(ctx context.Context) (*Config, error) {
+	var res Config
+	if err := c.query(ctx, &QueryMsg{Config: &ConfigQuery{}}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// QueryAdmin returns the contract admin, or nil if there is none
+func (c *Client) QueryAdmin(ctx context.Context) (*AdminResponse, error) {
+	var res AdminResponse
+	if err := c.query(ctx, &QueryMsg{Admin: &AdminQuery{}}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// QueryIsEnabled returns whether the contract accepts finality signatures
+func (c *Client) QueryIsEnabled(ctx context.Context) (bool, error) {
+	var res bool
+	if err := c.query(ctx, &QueryMsg{IsEnabled: &IsEnabledQuery{}}, &res); err != nil {
+		return false, err
+	}
+	return res, nil
+}
+
+// QueryBlockVoters returns the hex BTC public keys of the FPs that voted
+// for the given block. The result is empty if nobody voted.
+func (c *Client) QueryBlockVoters(ctx context.Context, height uint64, blockHash []byte) ([]string, error) {
+	var res []string
+	q := &QueryMsg{BlockVoters: &BlockVotersQuery{
+		Height: height,
+		Hash:   hex.EncodeToString(blockHash),
+	}}
+	if err := c.query(ctx, q, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// QueryFirstPubRandCommit returns the first randomness commitment of an FP,
+// or nil if it has none
+func (c *Client) QueryFirstPubRandCommit(ctx context.Context, btcPkHex string) (*PubRandCommit, error) {
+	var res *PubRandCommit
+	q := &QueryMsg{FirstPubRandCommit: &FirstPubRandCommitQuery{BtcPkHex: btcPkHex}}
+	if err := c.query(ctx, q, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// QueryLastPubRandCommit returns the last randomness commitment of an FP, or
+// nil if it has none
+func (c *Client) QueryLastPubRandCommit(ctx context.Context, btcPkHex string) (*PubRandCommit, error) {
+	var res *PubRandCommit
+	q := &QueryMsg{LastPubRandCommit: &LastPubRandCommitQuery{BtcPkHex: btcPkHex}}
+	if err := c.query(ctx, q, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) execute(ctx context.Context, msg *ExecuteMsg) (*sdk.TxResponse, error) {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal execute message: %w", err)
+	}
+	return c.chain.ExecuteContract(ctx, c.contractAddr, msgBytes)
+}
+
+func (c *Client) executeAsAdmin(ctx context.Context, msg *ExecuteMsg) (*sdk.TxResponse, error) {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal execute message: %w", err)
+	}
+	return c.chain.ExecuteContractFromKey(ctx, c.contractAddr, msgBytes)
+}
+
+func (c *Client) query(ctx context.Context, q *QueryMsg, res interface{}) error {
+	queryBytes, err := json.Marshal(q)
+	if err != nil {
+		return fmt.Errorf("failed to marshal query message: %w", err)
+	}
+	data, err := c.chain.QuerySmart(ctx, c.contractAddr, queryBytes)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("failed to parse query response %s: %w", data, err)
+	}
+	return nil
+}
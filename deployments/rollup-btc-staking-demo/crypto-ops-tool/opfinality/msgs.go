@@ -0,0 +1,192 @@
+// Package opfinality provides typed messages and a client for the
+// op-finality-gadget contract shipped in artifacts/contracts.
+//
+// Binary fields are []byte and therefore travel as base64 strings, which is
+// how cosmwasm_std::Binary is serialised by the contract. The Vec<u8> fields
+// of the slashing evidence are ByteArray and travel as arrays of numbers.
+package opfinality
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cometbft/cometbft/crypto/merkle"
+)
+
+// InstantiateMsg instantiates the finality contract
+type InstantiateMsg struct {
+	Admin      string `json:"admin"`
+	ConsumerID string `json:"consumer_id"`
+	IsEnabled  bool   `json:"is_enabled"`
+}
+
+// ExecuteMsg is the envelope of every execute message. Exactly one field
+// must be set.
+type ExecuteMsg struct {
+	CommitPublicRandomness  *CommitPublicRandomness  `json:"commit_public_randomness,omitempty"`
+	SubmitFinalitySignature *SubmitFinalitySignature `json:"submit_finality_signature,omitempty"`
+	Slashing                *Slashing                `json:"slashing,omitempty"`
+	SetEnabled              *SetEnabled              `json:"set_enabled,omitempty"`
+}
+
+// CommitPublicRandomness commits a merkle root over NumPubRand public
+// randomness values starting at StartHeight
+type CommitPublicRandomness struct {
+	FpPubkeyHex string `json:"fp_pubkey_hex"`
+	StartHeight uint64 `json:"start_height"`
+	NumPubRand  uint64 `json:"num_pub_rand"`
+	Commitment  []byte `json:"commitment"`
+	Signature   []byte `json:"signature"`
+}
+
+// SubmitFinalitySignature submits an EOTS signature over height || block_hash
+type SubmitFinalitySignature struct {
+	FpPubkeyHex string `json:"fp_pubkey_hex"`
+	Height      uint64 `json:"height"`
+	PubRand     []byte `json:"pub_rand"`
+	Proof       Proof  `json:"proof"`
+	BlockHash   []byte `json:"block_hash"`
+	Signature   []byte `json:"signature"`
+}
+
+// Proof is the merkle inclusion proof of a public randomness value in a
+// commitment
+type Proof struct {
+	Total    uint64   `json:"total"`
+	Index    uint64   `json:"index"`
+	LeafHash []byte   `json:"leaf_hash"`
+	Aunts    [][]byte `json:"aunts"`
+}
+
+// NewProof converts a merkle proof into the contract format
+func NewProof(proof *merkle.Proof) Proof {
+	protoProof := proof.ToProto()
+	return Proof{
+		Total:    uint64(protoProof.Total),
+		Index:    uint64(protoProof.Index),
+		LeafHash: protoProof.LeafHash,
+		Aunts:    protoProof.Aunts,
+	}
+}
+
+// SetEnabled enables or disables finality signature processing (admin only)
+type SetEnabled struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Slashing submits the evidence that an FP signed two conflicting blocks at
+// the same height
+type Slashing struct {
+	Evidence Evidence `json:"evidence"`
+}
+
+// Evidence is babylon_apis::finality_api::Evidence: the two EOTS signatures
+// of an FP at BlockHeight, both made with PubRand
+type Evidence struct {
+	FpBtcPk              ByteArray `json:"fp_btc_pk"`
+	BlockHeight          uint64    `json:"block_height"`
+	PubRand              ByteArray `json:"pub_rand"`
+	CanonicalAppHash     ByteArray `json:"canonical_app_hash"`
+	ForkAppHash          ByteArray `json:"fork_app_hash"`
+	CanonicalFinalitySig ByteArray `json:"canonical_finality_sig"`
+	ForkFinalitySig      ByteArray `json:"fork_finality_sig"`
+}
+
+// ByteArray is a byte slice serialised as a JSON array of numbers, the way
+// serde serialises a Vec<u8>
+type ByteArray []byte
+
+// MarshalJSON implements json.Marshaler
+func (b ByteArray) MarshalJSON() ([]byte, error) {
+	values := make([]uint16, len(b))
+	for i, v := range b {
+		values[i] = uint16(v)
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *ByteArray) UnmarshalJSON(data []byte) error {
+	var values []uint16
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	bs := make(ByteArray, len(values))
+	for i, v := range values {
+		if v > 0xff {
+			return fmt.Errorf("byte %d out of range: %d", i, v)
+		}
+		bs[i] = byte(v)
+	}
+	*b = bs
+	return nil
+}
+
+// QueryMsg is the envelope of every query message. Exactly one field must be
+// set.
+type QueryMsg struct {
+	Config             *ConfigQuery             `json:"config,omitempty"`
+	Admin              *AdminQuery              `json:"admin,omitempty"`
+	IsEnabled          *IsEnabledQuery          `json:"is_enabled,omitempty"`
+	BlockVoters        *BlockVotersQuery        `json:"block_voters,omitempty"`
+	FirstPubRandCommit *FirstPubRandCommitQuery `json:"first_pub_rand_commit,omitempty"`
+	LastPubRandCommit  *LastPubRandCommitQuery  `json:"last_pub_rand_commit,omitempty"`
+}
+
+// ConfigQuery returns the contract Config
+type ConfigQuery struct{}
+
+// AdminQuery returns the contract AdminResponse
+type AdminQuery struct{}
+
+// IsEnabledQuery returns whether the contract accepts finality signatures
+type IsEnabledQuery struct{}
+
+// BlockVotersQuery returns the hex BTC public keys of the FPs that voted for
+// the block with the given height and hex encoded hash
+type BlockVotersQuery struct {
+	Height uint64 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// FirstPubRandCommitQuery returns the first randomness commitment of an FP
+type FirstPubRandCommitQuery struct {
+	BtcPkHex string `json:"btc_pk_hex"`
+}
+
+// LastPubRandCommitQuery returns the last randomness commitment of an FP
+type LastPubRandCommitQuery struct {
+	BtcPkHex string `json:"btc_pk_hex"`
+}
+
+// Config is the response of the config query
+type Config struct {
+	ConsumerID string `json:"consumer_id"`
+}
+
+// AdminResponse is the response of the admin query
+type AdminResponse struct {
+	Admin *string `json:"admin"`
+}
+
+// PubRandCommit is a public randomness commitment stored by the contract
+type PubRandCommit struct {
+	StartHeight uint64 `json:"start_height"`
+	NumPubRand  uint64 `json:"num_pub_rand"`
+	// Height is the Babylon height at which the commitment was made
+	Height     uint64 `json:"height"`
+	Commitment []byte `json:"commitment"`
+}
+
+// EndHeight returns the last height covered by the commitment
+func (c *PubRandCommit) EndHeight() uint64 {
+	return c.StartHeight + c.NumPubRand - 1
+}
+
+// EventSlashedFinalityProvider is the event the contract emits when it
+// slashes an equivocating FP, prefixed with "wasm-" by the wasm module, and
+// AttributeKeySecretKey the attribute carrying the extracted FP key in hex
+const (
+	EventSlashedFinalityProvider = "slashed_finality_provider"
+	AttributeKeySecretKey        = "secret_key"
+)
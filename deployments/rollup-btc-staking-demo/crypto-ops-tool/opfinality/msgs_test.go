@@ -0,0 +1,185 @@
+package opfinality
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// contractWasm is the contract the messages must match
+const contractWasm = "../../artifacts/contracts/op_finality_gadget.wasm"
+
+func fill(b byte, n int) []byte {
+	return bytes.Repeat([]byte{b}, n)
+}
+
+// goldenMsgs holds one message of every variant, named after its golden file
+var goldenMsgs = []struct {
+	name string
+	msg  interface{}
+}{
+	{"instantiate", &InstantiateMsg{
+		Admin:      "bbn1qg5ega6dykkxc307y25pecuufrjkxkaggkkxh7",
+		ConsumerID: "op-stack-l2-706114",
+		IsEnabled:  true,
+	}},
+	{"execute_commit_public_randomness", &ExecuteMsg{CommitPublicRandomness: &CommitPublicRandomness{
+		FpPubkeyHex: strings.Repeat("0a", 32),
+		StartHeight: 1,
+		NumPubRand:  100,
+		Commitment:  fill(0x01, 32),
+		Signature:   fill(0x02, 64),
+	}}},
+	{"execute_submit_finality_signature", &ExecuteMsg{SubmitFinalitySignature: &SubmitFinalitySignature{
+		FpPubkeyHex: strings.Repeat("0a", 32),
+		Height:      42,
+		PubRand:     fill(0x03, 32),
+		Proof: Proof{
+			Total:    100,
+			Index:    41,
+			LeafHash: fill(0x04, 32),
+			Aunts:    [][]byte{fill(0x05, 32), fill(0x06, 32)},
+		},
+		BlockHash: fill(0x07, 32),
+		Signature: fill(0x08, 32),
+	}}},
+	{"execute_slashing", &ExecuteMsg{Slashing: &Slashing{Evidence: Evidence{
+		FpBtcPk:              ByteArray{0x0a, 0x0b},
+		BlockHeight:          42,
+		PubRand:              ByteArray{0x03},
+		CanonicalAppHash:     ByteArray{0x07, 0xff},
+		ForkAppHash:          ByteArray{0x09},
+		CanonicalFinalitySig: ByteArray{0x08},
+		ForkFinalitySig:      ByteArray{0x00, 0x80},
+	}}}},
+	{"execute_set_enabled", &ExecuteMsg{SetEnabled: &SetEnabled{Enabled: false}}},
+	{"query_config", &QueryMsg{Config: &ConfigQuery{}}},
+	{"query_admin", &QueryMsg{Admin: &AdminQuery{}}},
+	{"query_is_enabled", &QueryMsg{IsEnabled: &IsEnabledQuery{}}},
+	{"query_block_voters", &QueryMsg{BlockVoters: &BlockVotersQuery{
+		Height: 42,
+		Hash:   strings.Repeat("07", 32),
+	}}},
+	{"query_first_pub_rand_commit", &QueryMsg{FirstPubRandCommit: &FirstPubRandCommitQuery{BtcPkHex: strings.Repeat("0a", 32)}}},
+	{"query_last_pub_rand_commit", &QueryMsg{LastPubRandCommit: &LastPubRandCommitQuery{BtcPkHex: strings.Repeat("0a", 32)}}},
+}
+
+func goldenPath(name string) string {
+	return filepath.Join("testdata", name+".json")
+}
+
+func TestMsgsGolden(t *testing.T) {
+	for _, tc := range goldenMsgs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.MarshalIndent(tc.msg, "", "  ")
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			got = append(got, '\n')
+			if *update {
+				if err := os.WriteFile(goldenPath(tc.name), got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(goldenPath(tc.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("wire format changed\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			// The golden message decodes back into the same value
+			decoded := reflect.New(reflect.TypeOf(tc.msg).Elem()).Interface()
+			if err := json.Unmarshal(want, decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tc.msg) {
+				t.Fatalf("round trip mismatch: got %+v, want %+v", decoded, tc.msg)
+			}
+		})
+	}
+}
+
+// TestMsgsCoverEveryVariant checks that every execute and query variant has
+// a golden message
+func TestMsgsCoverEveryVariant(t *testing.T) {
+	names := map[string]bool{}
+	for _, tc := range goldenMsgs {
+		names[tc.name] = true
+	}
+	for prefix, typ := range map[string]reflect.Type{
+		"execute_": reflect.TypeOf(ExecuteMsg{}),
+		"query_":   reflect.TypeOf(QueryMsg{}),
+	} {
+		for i := 0; i < typ.NumField(); i++ {
+			variant := prefix + jsonName(typ.Field(i))
+			if !names[variant] {
+				t.Errorf("no golden message for %s", variant)
+			}
+		}
+	}
+}
+
+// TestMsgsMatchContract checks every field name of the golden messages
+// against the names the contract deserialises, which serde keeps as strings
+// in the WASM byte code
+func TestMsgsMatchContract(t *testing.T) {
+	wasm, err := os.ReadFile(contractWasm)
+	if err != nil {
+		t.Fatalf("failed to read contract: %v", err)
+	}
+	for _, tc := range goldenMsgs {
+		data, err := os.ReadFile(goldenPath(tc.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for _, key := range objectKeys(v) {
+			if !bytes.Contains(wasm, []byte(key)) {
+				t.Errorf("%s: the contract has no field or variant %q", tc.name, key)
+			}
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
+// objectKeys returns the keys of every object nested in v, sorted
+func objectKeys(v interface{}) []string {
+	seen := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				seen[k] = true
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(v)
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
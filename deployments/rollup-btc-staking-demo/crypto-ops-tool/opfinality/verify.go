@@ -0,0 +1,159 @@
+package opfinality
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	ftypes "github.com/babylonlabs-io/babylon/v4/x/finality/types"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/cometbft/cometbft/crypto/merkle"
+	tmproto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Reasons for which VerifyFinalitySignature rejects a finality signature
+var (
+	ErrInvalidFpPubKey  = errors.New("invalid FP public key")
+	ErrInvalidPubRand   = errors.New("invalid public randomness")
+	ErrInvalidSignature = errors.New("invalid signature encoding")
+	ErrEmptyBlockHash   = errors.New("empty block hash")
+	ErrHeightNotCovered = errors.New("height not covered by the commitment")
+	ErrWrongProofIndex  = errors.New("wrong proof index")
+	ErrWrongProofTotal  = errors.New("wrong proof total")
+	ErrBadProof         = errors.New("bad merkle proof")
+	ErrBadSignature     = errors.New("bad EOTS signature")
+)
+
+// VerifyFinalitySignature runs the checks the contract applies to
+// submit_finality_signature against the commitment covering msg.Height: the
+// public randomness must be included in the commitment at index
+// height - start_height, and the EOTS signature must verify over
+// height || block_hash under the FP key and that randomness. The returned
+// error wraps one of the Err* reasons above.
+func VerifyFinalitySignature(msg *SubmitFinalitySignature, commit *PubRandCommit) error {
+	fpPk, err := bbn.NewBIP340PubKeyFromHex(msg.FpPubkeyHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFpPubKey, err)
+	}
+	btcPk, err := fpPk.ToBTCPK()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFpPubKey, err)
+	}
+	pubRand, err := bbn.NewSchnorrPubRand(msg.PubRand)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPubRand, err)
+	}
+	sig, err := bbn.NewSchnorrEOTSSig(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if len(msg.BlockHash) == 0 {
+		return ErrEmptyBlockHash
+	}
+
+	if commit.NumPubRand == 0 || msg.Height < commit.StartHeight || msg.Height > commit.EndHeight() {
+		return fmt.Errorf("%w: height %d, commitment covers [%d, %d]",
+			ErrHeightNotCovered, msg.Height, commit.StartHeight, commit.EndHeight())
+	}
+	expectedIndex := msg.Height - commit.StartHeight
+	if msg.Proof.Index != expectedIndex {
+		return fmt.Errorf("%w: proof index %d, expected %d for height %d in the commitment starting at %d",
+			ErrWrongProofIndex, msg.Proof.Index, expectedIndex, msg.Height, commit.StartHeight)
+	}
+	if msg.Proof.Total != commit.NumPubRand {
+		return fmt.Errorf("%w: proof total %d, the commitment holds %d values",
+			ErrWrongProofTotal, msg.Proof.Total, commit.NumPubRand)
+	}
+
+	proof, err := merkle.ProofFromProto(&tmproto.Proof{
+		Total:    int64(msg.Proof.Total),
+		Index:    int64(msg.Proof.Index),
+		LeafHash: msg.Proof.LeafHash,
+		Aunts:    msg.Proof.Aunts,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadProof, err)
+	}
+	if err := proof.Verify(commit.Commitment, msg.PubRand); err != nil {
+		return fmt.Errorf("%w: public randomness %x is not included in commitment %x: %v",
+			ErrBadProof, msg.PubRand, commit.Commitment, err)
+	}
+
+	msgToSign := append(sdk.Uint64ToBigEndian(msg.Height), msg.BlockHash...)
+	if err := eots.Verify(btcPk, pubRand.ToFieldVal(), msgToSign, sig.ToModNScalar()); err != nil {
+		return fmt.Errorf("%w: signature over height %d and block %x does not verify: %v",
+			ErrBadSignature, msg.Height, msg.BlockHash, err)
+	}
+
+	return nil
+}
+
+// Reasons for which VerifyPubRandCommitment and VerifyPubRandList reject a
+// randomness commitment
+var (
+	ErrEmptyCommitment      = errors.New("empty commitment")
+	ErrBadCommitSignature   = errors.New("bad commitment signature")
+	ErrPubRandCountMismatch = errors.New("public randomness count mismatch")
+	ErrCommitmentMismatch   = errors.New("commitment mismatch")
+)
+
+// VerifyPubRandCommitment checks the BIP340 signature of a
+// commit_public_randomness message under fpPubkeyHex. The signed message is
+// sha256(start_height || num_pub_rand || commitment), as built by
+// MsgCommitPubRandList.HashToSign.
+func VerifyPubRandCommitment(msg *CommitPublicRandomness, fpPubkeyHex string) error {
+	fpPk, err := bbn.NewBIP340PubKeyFromHex(fpPubkeyHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFpPubKey, err)
+	}
+	btcPk, err := fpPk.ToBTCPK()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFpPubKey, err)
+	}
+	if len(msg.Commitment) == 0 {
+		return ErrEmptyCommitment
+	}
+	sig, err := schnorr.ParseSignature(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	commitMsg := &ftypes.MsgCommitPubRandList{
+		FpBtcPk:     fpPk,
+		StartHeight: msg.StartHeight,
+		NumPubRand:  msg.NumPubRand,
+		Commitment:  msg.Commitment,
+	}
+	hash, err := commitMsg.HashToSign()
+	if err != nil {
+		return fmt.Errorf("failed to hash commitment: %w", err)
+	}
+	if !sig.Verify(hash, btcPk) {
+		return fmt.Errorf("%w: signature does not verify over start_height %d, num_pub_rand %d and commitment %x under FP key %s",
+			ErrBadCommitSignature, msg.StartHeight, msg.NumPubRand, msg.Commitment, fpPubkeyHex)
+	}
+	return nil
+}
+
+// VerifyPubRandList recomputes the merkle root over prList and checks that
+// it is the commitment of msg and that the list holds num_pub_rand values
+func VerifyPubRandList(msg *CommitPublicRandomness, prList [][]byte) error {
+	if uint64(len(prList)) != msg.NumPubRand {
+		return fmt.Errorf("%w: %d public randomness values, num_pub_rand is %d",
+			ErrPubRandCountMismatch, len(prList), msg.NumPubRand)
+	}
+	for i, pr := range prList {
+		if _, err := bbn.NewSchnorrPubRand(pr); err != nil {
+			return fmt.Errorf("%w: value %d: %v", ErrInvalidPubRand, i, err)
+		}
+	}
+	root := merkle.HashFromByteSlices(prList)
+	if !bytes.Equal(root, msg.Commitment) {
+		return fmt.Errorf("%w: merkle root of the public randomness is %x, commitment is %x",
+			ErrCommitmentMismatch, root, msg.Commitment)
+	}
+	return nil
+}
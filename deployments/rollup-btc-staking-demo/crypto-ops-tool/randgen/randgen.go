@@ -0,0 +1,120 @@
+// Package randgen derives EOTS randomness deterministically from the
+// finality provider's secret key, so that it can be regenerated on demand
+// instead of being stored or piped between commands.
+//
+// The derivation matches the finality provider's EOTS manager: the secret
+// randomness for a height is HMAC-SHA256(sk, height || chain_id) reduced
+// modulo the curve order, and the public randomness is the x coordinate of
+// the corresponding point.
+package randgen
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/cometbft/cometbft/crypto/merkle"
+)
+
+// GenerateRandomness derives the secret randomness and the public
+// randomness of the given height
+func GenerateRandomness(key []byte, chainID []byte, height uint64) (*btcec.ModNScalar, *btcec.FieldVal) {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, height)
+
+	hasher := hmac.New(sha256.New, key)
+	hasher.Write(heightBytes)
+	hasher.Write(chainID)
+	randPre := hasher.Sum(nil)
+
+	var randScalar btcec.ModNScalar
+	randScalar.SetByteSlice(randPre)
+
+	return &randScalar, PubRand(&randScalar)
+}
+
+// RandList is a list of randomness for consecutive heights together with
+// the merkle commitment over the public randomness
+type RandList struct {
+	StartHeight uint64
+	SRList      []*btcec.ModNScalar
+	PRList      []*btcec.FieldVal
+	Commitment  []byte
+	ProofList   []*merkle.Proof
+}
+
+// GenerateRandList derives numPubRand randomness values starting at
+// startHeight and commits to them
+func GenerateRandList(key []byte, chainID []byte, startHeight, numPubRand uint64) (*RandList, error) {
+	if numPubRand == 0 {
+		return nil, fmt.Errorf("num pub rand must be positive")
+	}
+	if startHeight+numPubRand < startHeight {
+		return nil, fmt.Errorf("height range starting at %d with %d values overflows", startHeight, numPubRand)
+	}
+
+	srList := make([]*btcec.ModNScalar, 0, numPubRand)
+	for i := uint64(0); i < numPubRand; i++ {
+		sr, _ := GenerateRandomness(key, chainID, startHeight+i)
+		srList = append(srList, sr)
+	}
+
+	return NewRandList(startHeight, srList)
+}
+
+// NewRandList rebuilds the public randomness and the commitment of a list of
+// secret randomness for consecutive heights starting at startHeight
+func NewRandList(startHeight uint64, srList []*btcec.ModNScalar) (*RandList, error) {
+	if len(srList) == 0 {
+		return nil, fmt.Errorf("randomness list is empty")
+	}
+
+	rl := &RandList{
+		StartHeight: startHeight,
+		SRList:      srList,
+		PRList:      make([]*btcec.FieldVal, 0, len(srList)),
+	}
+	prBytesList := make([][]byte, 0, len(srList))
+	for _, sr := range srList {
+		pr := PubRand(sr)
+		rl.PRList = append(rl.PRList, pr)
+		prBytes := pr.Bytes()
+		prBytesList = append(prBytesList, prBytes[:])
+	}
+	rl.Commitment, rl.ProofList = merkle.ProofsFromByteSlices(prBytesList)
+
+	return rl, nil
+}
+
+// NewPubRandList rebuilds the commitment of the public randomness of
+// consecutive heights starting at startHeight, when the secret randomness is
+// held elsewhere, e.g. by a remote EOTS manager. SRList is left empty.
+func NewPubRandList(startHeight uint64, prList []*btcec.FieldVal) (*RandList, error) {
+	if len(prList) == 0 {
+		return nil, fmt.Errorf("randomness list is empty")
+	}
+
+	rl := &RandList{
+		StartHeight: startHeight,
+		PRList:      prList,
+	}
+	prBytesList := make([][]byte, 0, len(prList))
+	for _, pr := range prList {
+		prBytes := pr.Bytes()
+		prBytesList = append(prBytesList, prBytes[:])
+	}
+	rl.Commitment, rl.ProofList = merkle.ProofsFromByteSlices(prBytesList)
+
+	return rl, nil
+}
+
+// PubRand returns the public randomness of a secret randomness, i.e. the x
+// coordinate of sr*G
+func PubRand(sr *btcec.ModNScalar) *btcec.FieldVal {
+	var j btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(sr, &j)
+	j.ToAffine()
+	return &j.X
+}
@@ -0,0 +1,88 @@
+package randgen
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The fpd EOTS manager (eotsmanager/randgenerator.GenerateRandomness)
+// derives the secret randomness of a height as HMAC-SHA256(sk, height ||
+// chain_id) reduced modulo the curve order, and the public randomness as the
+// x coordinate of r*G. These vectors were computed with an implementation of
+// that derivation independent of this package.
+var randomnessVectors = []struct {
+	chainID string
+	height  uint64
+	sr      string
+	pr      string
+}{
+	{"op-stack-l2-706114", 1,
+		"6f729831d589f15776a609887c5f2c7675c6b992f030e7b5b35f4d2c6908ec4e",
+		"b5fb879b78dd0d734e7832c8c1dd2b1cf06fa6ef75c3c714e82f9bbf5c3fafed"},
+	{"op-stack-l2-706114", 42,
+		"27f404418eabece16a9bbc1af36466da24ad101ebf1667dacdbf6e8ea7da1733",
+		"2a45d4c4c772b35755cec5ecef45827866a68220f3c445501cce6686899bca7f"},
+	{"consumer-id", 1000000,
+		"9dd3aa6bbd9a5b35030c7e7f5d3e428136427419fe2bfe12ab1a922a8e80969f",
+		"a4ae0e4ee9687cc94a3321abe9433f0715dcdb97c58a718780314ea6fbd31ff9"},
+}
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x01}, 32)
+}
+
+func TestGenerateRandomnessKnownAnswers(t *testing.T) {
+	for _, v := range randomnessVectors {
+		sr, pr := GenerateRandomness(testKey(), []byte(v.chainID), v.height)
+		srBytes := sr.Bytes()
+		if got := hex.EncodeToString(srBytes[:]); got != v.sr {
+			t.Errorf("%s/%d: secret randomness = %s, want %s", v.chainID, v.height, got, v.sr)
+		}
+		prBytes := pr.Bytes()
+		if got := hex.EncodeToString(prBytes[:]); got != v.pr {
+			t.Errorf("%s/%d: public randomness = %s, want %s", v.chainID, v.height, got, v.pr)
+		}
+	}
+}
+
+func TestGenerateRandList(t *testing.T) {
+	const startHeight, num = 40, 5
+	chainID := []byte("op-stack-l2-706114")
+
+	rl, err := GenerateRandList(testKey(), chainID, startHeight, num)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rl.SRList) != num || len(rl.PRList) != num || len(rl.ProofList) != num {
+		t.Fatalf("list sizes %d/%d/%d, want %d", len(rl.SRList), len(rl.PRList), len(rl.ProofList), num)
+	}
+
+	// Height 42 of the list is the known answer above
+	prBytes := rl.PRList[2].Bytes()
+	if got := hex.EncodeToString(prBytes[:]); got != randomnessVectors[1].pr {
+		t.Errorf("public randomness of height 42 = %s", got)
+	}
+
+	// A signer holding only the public randomness commits to the same root
+	pubList, err := NewPubRandList(startHeight, rl.PRList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pubList.Commitment, rl.Commitment) {
+		t.Fatalf("commitment over the public randomness %x, want %x", pubList.Commitment, rl.Commitment)
+	}
+	for i, proof := range rl.ProofList {
+		pr := rl.PRList[i].Bytes()
+		if err := proof.Verify(rl.Commitment, pr[:]); err != nil {
+			t.Errorf("proof %d: %v", i, err)
+		}
+	}
+
+	if _, err := GenerateRandList(testKey(), chainID, startHeight, 0); err == nil {
+		t.Error("empty list accepted")
+	}
+	if _, err := GenerateRandList(testKey(), chainID, ^uint64(0), 2); err == nil {
+		t.Error("overflowing height range accepted")
+	}
+}
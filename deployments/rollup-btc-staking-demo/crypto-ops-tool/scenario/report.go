@@ -0,0 +1,52 @@
+package scenario
+
+import (
+	"fmt"
+
+	"crypto-ops-tool/demo"
+)
+
+// Assertion is the outcome of one expectation of a scenario
+type Assertion struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Scenario string `json:"scenario"`
+	// Passed is set when the scenario ran to the end and every assertion
+	// passed
+	Passed bool `json:"passed"`
+	// Contracts maps each consumer to its finality contract
+	Contracts map[string]string `json:"contracts"`
+	// FinalityProviders maps each FP key to its BTC public key
+	FinalityProviders map[string]string `json:"finality_providers"`
+	// Delegations are the staking tx hashes of the delegations, in order
+	Delegations []string    `json:"delegations"`
+	Assertions  []Assertion `json:"assertions"`
+	// Error is why the scenario could not run to the end
+	Error    string        `json:"error,omitempty"`
+	Duration demo.Duration `json:"duration"`
+}
+
+// assert records an assertion, with a detail formatted like fmt.Sprintf
+func (r *Report) assert(name string, passed bool, detail string, args ...interface{}) {
+	r.Assertions = append(r.Assertions, Assertion{
+		Name:   name,
+		Passed: passed,
+		Detail: fmt.Sprintf(detail, args...),
+	})
+}
+
+// Failed returns the assertions that did not pass
+func (r *Report) Failed() []Assertion {
+	var failed []Assertion
+	for _, a := range r.Assertions {
+		if !a.Passed {
+			failed = append(failed, a)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,782 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	bsctypes "github.com/babylonlabs-io/babylon/v4/x/btcstkconsumer/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/demo"
+	"crypto-ops-tool/opfinality"
+	"crypto-ops-tool/randgen"
+	"crypto-ops-tool/signer"
+	"crypto-ops-tool/stakerclient"
+	"crypto-ops-tool/store"
+)
+
+// Chain is the Babylon node the runner transacts with, paying with a single
+// key. It is satisfied by bbnclient.Client.
+type Chain interface {
+	demo.Chain
+	QueryFinalityProvider(ctx context.Context, fpBtcPkHex string) (*bstypes.FinalityProviderResponse, error)
+}
+
+// Config holds the settings of the runner that do not depend on the
+// scenario
+type Config struct {
+	// ContractWasmPath is the op-finality-gadget WASM byte code deployed
+	// for every consumer
+	ContractWasmPath string
+	// ActivationPollInterval is the interval between each check of the
+	// delegations
+	ActivationPollInterval time.Duration
+	// VoteRetries is the number of block voter queries made to find a
+	// submitted vote, and VoteRetryInterval the interval between them
+	VoteRetries       int
+	VoteRetryInterval time.Duration
+	// SlashingPollInterval is the interval between each check of the FPs
+	// expected to be slashed
+	SlashingPollInterval time.Duration
+	// Concurrency is the number of consumer FPs committing randomness and
+	// signing at once
+	Concurrency int
+}
+
+// DefaultConfig returns the settings of the demo
+func DefaultConfig() Config {
+	defaults := demo.DefaultConfig()
+	return Config{
+		ContractWasmPath:       defaults.ContractWasmPath,
+		ActivationPollInterval: defaults.ActivationPollInterval,
+		VoteRetries:            defaults.VerifyRetries,
+		VoteRetryInterval:      defaults.VerifyRetryInterval,
+		SlashingPollInterval:   2 * time.Second,
+		Concurrency:            16,
+	}
+}
+
+// Validate checks that the configuration is usable
+func (cfg *Config) Validate() error {
+	if cfg.ContractWasmPath == "" {
+		return fmt.Errorf("contract wasm path must be set")
+	}
+	if cfg.ActivationPollInterval <= 0 || cfg.SlashingPollInterval <= 0 {
+		return fmt.Errorf("poll intervals must be positive")
+	}
+	if cfg.VoteRetries <= 0 {
+		return fmt.Errorf("vote retries must be positive")
+	}
+	if cfg.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive")
+	}
+	return nil
+}
+
+// Runner executes scenarios against a deployment
+type Runner struct {
+	cfg    Config
+	chain  Chain
+	staker demo.Staker
+	keys   demo.Keys
+	store  *store.Store
+}
+
+// New creates a runner transacting on chain and staking with staker. The
+// FP keys of a scenario are created with keys, and their randomness and
+// signatures kept in the store.
+func New(cfg Config, chain Chain, staker demo.Staker, keys demo.Keys, st *store.Store) (*Runner, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario runner config: %w", err)
+	}
+	if st == nil {
+		return nil, fmt.Errorf("the scenario runner requires a store")
+	}
+	return &Runner{
+		cfg:    cfg,
+		chain:  chain,
+		staker: staker,
+		keys:   keys,
+		store:  st,
+	}, nil
+}
+
+// fp is an FP of a running scenario. While the consumer FPs commit and
+// sign concurrently, each is only written by its own goroutine.
+type fp struct {
+	spec *FinalityProvider
+	// consumerID is empty for the FPs of Babylon
+	consumerID string
+	signer     signer.Signer
+	pkHex      string
+	randLists  []*randgen.RandList
+	// voteErrs holds why the vote at a height could not be submitted, and
+	// forkErrs the same for the conflicting votes
+	voteErrs map[uint64]error
+	forkErrs map[uint64]error
+	// slashingEvents holds the heights whose conflicting vote made the
+	// contract emit the slashing event
+	slashingEvents map[uint64]bool
+}
+
+// randList returns the committed randomness covering height, or nil
+func (f *fp) randList(height uint64) *randgen.RandList {
+	for _, randList := range f.randLists {
+		if height >= randList.StartHeight && height < randList.StartHeight+uint64(len(randList.PRList)) {
+			return randList
+		}
+	}
+	return nil
+}
+
+// run is the state of a scenario run
+type run struct {
+	*Runner
+	sc     *Scenario
+	report *Report
+
+	// fps are the Babylon FPs followed by those of each consumer
+	fps       []*fp
+	contracts map[string]*opfinality.Client
+	// blocks holds the hash of the block the FPs of each consumer sign at
+	// each height
+	blocks map[string]map[uint64][]byte
+}
+
+// Run executes sc and returns its report. The error is set when the
+// scenario could not run to the end, in which case the report holds the
+// assertions made until then; failed assertions alone are no error.
+func (r *Runner) Run(ctx context.Context, sc *Scenario) (*Report, error) {
+	start := time.Now()
+	ru := &run{
+		Runner: r,
+		sc:     sc,
+		report: &Report{
+			Scenario:          sc.Name,
+			Contracts:         map[string]string{},
+			FinalityProviders: map[string]string{},
+			Delegations:       []string{},
+			Assertions:        []Assertion{},
+		},
+		contracts: map[string]*opfinality.Client{},
+		blocks:    map[string]map[uint64][]byte{},
+	}
+	for i := range sc.BabylonFPs {
+		ru.fps = append(ru.fps, newFp(&sc.BabylonFPs[i], ""))
+	}
+	for _, c := range sc.Consumers {
+		for i := range c.FinalityProviders {
+			ru.fps = append(ru.fps, newFp(&c.FinalityProviders[i], c.ID))
+		}
+	}
+
+	err := ru.execute(ctx)
+	if err != nil {
+		ru.report.Error = err.Error()
+	}
+	ru.report.Passed = err == nil && len(ru.report.Failed()) == 0
+	ru.report.Duration = demo.Duration(time.Since(start))
+	return ru.report, err
+}
+
+func newFp(spec *FinalityProvider, consumerID string) *fp {
+	return &fp{
+		spec:           spec,
+		consumerID:     consumerID,
+		voteErrs:       map[uint64]error{},
+		forkErrs:       map[uint64]error{},
+		slashingEvents: map[uint64]bool{},
+	}
+}
+
+// execute runs the phases of the scenario in order
+func (ru *run) execute(ctx context.Context) error {
+	phases := []struct {
+		name string
+		run  func(ctx context.Context) error
+	}{
+		{"deploy contracts", ru.deployContracts},
+		{"create keys", ru.createKeys},
+		{"create finality providers", ru.createFinalityProviders},
+		{"delegate", ru.delegate},
+		{"commit randomness", ru.commitRandomness},
+		{"sign", ru.sign},
+		{"check votes", ru.checkVotes},
+		{"check slashing", ru.checkSlashing},
+	}
+	for _, phase := range phases {
+		log.Printf("Scenario %s: %s", ru.sc.Name, phase.name)
+		if err := phase.run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", phase.name, err)
+		}
+	}
+	return nil
+}
+
+// deployContracts stores the finality contract once, then instantiates it
+// and registers a consumer with it for every consumer
+func (ru *run) deployContracts(ctx context.Context) error {
+	wasmByteCode, err := os.ReadFile(ru.cfg.ContractWasmPath)
+	if err != nil {
+		return fmt.Errorf("failed to read contract: %w", err)
+	}
+	codeID, _, err := ru.chain.StoreCode(ctx, wasmByteCode)
+	if err != nil {
+		return fmt.Errorf("failed to store contract: %w", err)
+	}
+
+	admin := ru.chain.Address()
+	for _, c := range ru.sc.Consumers {
+		instantiateMsg, err := json.Marshal(&opfinality.InstantiateMsg{Admin: admin, ConsumerID: c.ID, IsEnabled: true})
+		if err != nil {
+			return fmt.Errorf("failed to marshal instantiate message: %w", err)
+		}
+		contractAddr, _, err := ru.chain.InstantiateContract(ctx, codeID, admin, "finality-"+c.ID, instantiateMsg)
+		if err != nil {
+			return fmt.Errorf("failed to instantiate contract of consumer %s: %w", c.ID, err)
+		}
+		_, err = ru.chain.BroadcastAndWait(ctx, &bsctypes.MsgRegisterConsumer{
+			Signer:                        admin,
+			ConsumerId:                    c.ID,
+			ConsumerName:                  c.Name,
+			ConsumerDescription:           c.Description,
+			MaxMultiStakedFps:             c.MaxMultiStakedFps,
+			RollupFinalityContractAddress: contractAddr,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register consumer %s: %w", c.ID, err)
+		}
+		log.Printf("Consumer %s registered with finality contract %s", c.ID, contractAddr)
+		ru.contracts[c.ID] = opfinality.NewClient(ru.chain, contractAddr)
+		ru.report.Contracts[c.ID] = contractAddr
+
+		registration, err := ru.chain.QueryConsumerRegistration(ctx, c.ID)
+		if err != nil {
+			ru.report.assert("consumer "+c.ID+" registered", false, "%v", err)
+			continue
+		}
+		ru.report.assert("consumer "+c.ID+" registered", registration.RollupFinalityContractAddress == contractAddr,
+			"finality contract %s", registration.RollupFinalityContractAddress)
+	}
+	return nil
+}
+
+// createKeys creates the key of every FP, the i-th FP of the scenario at
+// index i when the keys are derived from a mnemonic
+func (ru *run) createKeys(ctx context.Context) error {
+	for i, f := range ru.fps {
+		s, err := ru.keys.NewKey(ctx, f.spec.Key, uint32(i))
+		if err != nil {
+			return fmt.Errorf("failed to create key %s: %w", f.spec.Key, err)
+		}
+		f.signer = s
+		f.pkHex = s.PublicKey().MarshalHex()
+		ru.report.FinalityProviders[f.spec.Key] = f.pkHex
+	}
+	return nil
+}
+
+// createFinalityProviders registers every FP, then checks that each is
+// listed with its commission
+func (ru *run) createFinalityProviders(ctx context.Context) error {
+	for _, f := range ru.fps {
+		commission, err := ru.commission(f)
+		if err != nil {
+			return err
+		}
+		if _, _, err := demo.CreateFinalityProvider(ctx, ru.chain, f.signer, f.spec.Moniker, f.consumerID, commission); err != nil {
+			return err
+		}
+	}
+
+	registered := map[string]*sdkmath.LegacyDec{}
+	bbnFps, err := ru.chain.QueryFinalityProviders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, resp := range bbnFps {
+		registered[pkHex(resp.BtcPk)] = resp.Commission
+	}
+	for _, c := range ru.sc.Consumers {
+		consumerFps, err := ru.chain.QueryConsumerFinalityProviders(ctx, c.ID)
+		if err != nil {
+			return err
+		}
+		for _, resp := range consumerFps {
+			registered[pkHex(resp.BtcPk)] = resp.Commission
+		}
+	}
+
+	for _, f := range ru.fps {
+		name := "finality provider " + f.spec.Key + " registered"
+		commission, _ := ru.commission(f)
+		rate, ok := registered[f.pkHex]
+		switch {
+		case !ok:
+			ru.report.assert(name, false, "%s not found", f.pkHex)
+		case rate == nil || !rate.Equal(commission.Rate):
+			ru.report.assert(name, false, "commission %v, expected %v", rate, commission.Rate)
+		default:
+			ru.report.assert(name, true, "commission %v", rate)
+		}
+	}
+	return nil
+}
+
+// commission parses the commission of the FP
+func (ru *run) commission(f *fp) (bstypes.CommissionRates, error) {
+	c := f.spec.Commission
+	return demo.ParseCommission(c.Rate, c.MaxRate, c.MaxChangeRate)
+}
+
+func pkHex(pk *bbn.BIP340PubKey) string {
+	if pk == nil {
+		return ""
+	}
+	return pk.MarshalHex()
+}
+
+// delegate creates every delegation from the first address of the staker's
+// wallet, then waits for those expected to become active
+func (ru *run) delegate(ctx context.Context) error {
+	if len(ru.sc.Delegations) == 0 {
+		return nil
+	}
+	addrs, err := ru.staker.StakerAddresses(ctx)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("the staker wallet has no funded address")
+	}
+
+	pkByKey := map[string]string{}
+	for _, f := range ru.fps {
+		pkByKey[f.spec.Key] = f.pkHex
+	}
+	for i, del := range ru.sc.Delegations {
+		var fpPks []string
+		for _, key := range del.FinalityProviders {
+			fpPks = append(fpPks, pkByKey[key])
+		}
+		txHash, err := ru.staker.Stake(ctx, stakerclient.StakeRequest{
+			StakerAddress:     addrs[0],
+			StakingAmount:     del.Amount,
+			FpBtcPksHex:       fpPks,
+			StakingTimeBlocks: del.StakingTime,
+		})
+		if err != nil {
+			return fmt.Errorf("delegation %d: %w", i, err)
+		}
+		log.Printf("Delegation %d to %s created: %s", i, strings.Join(del.FinalityProviders, ", "), txHash)
+		ru.report.Delegations = append(ru.report.Delegations, txHash)
+	}
+
+	pending := map[int]string{}
+	for i, del := range ru.sc.Delegations {
+		if del.expectActive() {
+			pending[i] = "not found"
+		}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(ru.sc.ActivationTimeout))
+	defer cancel()
+poll:
+	for len(pending) > 0 {
+		for i := range pending {
+			del, err := ru.chain.QueryBTCDelegation(waitCtx, ru.report.Delegations[i])
+			switch {
+			case err != nil:
+				pending[i] = err.Error()
+			case del.Active:
+				delete(pending, i)
+				ru.report.assert(fmt.Sprintf("delegation %d active", i), true, "%s", ru.report.Delegations[i])
+			default:
+				pending[i] = del.StatusDesc
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			break poll
+		case <-time.After(ru.cfg.ActivationPollInterval):
+		}
+	}
+	for i := range ru.sc.Delegations {
+		if status, ok := pending[i]; ok {
+			ru.report.assert(fmt.Sprintf("delegation %d active", i), false, "%s is %s after %v",
+				ru.report.Delegations[i], status, time.Duration(ru.sc.ActivationTimeout))
+		}
+	}
+	return nil
+}
+
+// forEachFp runs fn for every consumer FP, up to Concurrency of them at
+// once, and returns the errors of all of them
+func (ru *run) forEachFp(ctx context.Context, fn func(ctx context.Context, f *fp) error) error {
+	sem := make(chan struct{}, ru.cfg.Concurrency)
+	errs := make([]error, len(ru.fps))
+	var wg sync.WaitGroup
+	for i, f := range ru.fps {
+		if f.consumerID == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			if err := fn(ctx, f); err != nil {
+				errs[i] = fmt.Errorf("FP %s: %w", f.spec.Key, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// commitRandomness has the consumer FPs commit their windows of randomness
+// concurrently
+func (ru *run) commitRandomness(ctx context.Context) error {
+	return ru.forEachFp(ctx, func(ctx context.Context, f *fp) error {
+		for _, w := range f.spec.CommitWindows {
+			randList, _, err := demo.CommitRandomness(ctx, ru.contracts[f.consumerID], f.signer, ru.store, f.consumerID, w.StartHeight, w.NumPubRand)
+			if err != nil {
+				return err
+			}
+			f.randLists = append(f.randLists, randList)
+		}
+		return nil
+	})
+}
+
+// mockBlocks picks the hash of the block the FPs of each consumer sign at
+// every height one of them has a schedule for
+func (ru *run) mockBlocks() error {
+	for _, f := range ru.fps {
+		s := f.spec.Signing
+		if s == nil {
+			continue
+		}
+		blocks, ok := ru.blocks[f.consumerID]
+		if !ok {
+			blocks = map[uint64][]byte{}
+			ru.blocks[f.consumerID] = blocks
+		}
+		for h := s.FromHeight; h <= s.ToHeight; h++ {
+			if _, ok := blocks[h]; ok {
+				continue
+			}
+			blockHash, err := demo.RandomBlockHash()
+			if err != nil {
+				return err
+			}
+			blocks[h] = blockHash
+		}
+	}
+	return nil
+}
+
+// heights returns the heights the FPs of the consumer have a block to sign
+// at, in ascending order
+func (ru *run) heights(consumerID string) []uint64 {
+	heights := make([]uint64, 0, len(ru.blocks[consumerID]))
+	for h := range ru.blocks[consumerID] {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// sign has the consumer FPs go through their schedules concurrently
+func (ru *run) sign(ctx context.Context) error {
+	if err := ru.mockBlocks(); err != nil {
+		return err
+	}
+	return ru.forEachFp(ctx, ru.signSchedule)
+}
+
+// signSchedule has the FP vote for the block of its consumer at every height
+// of its schedule it does not miss, in ascending order. At an equivocating
+// height it then votes for a second block.
+func (ru *run) signSchedule(ctx context.Context, f *fp) error {
+	s := f.spec.Signing
+	if s == nil {
+		return nil
+	}
+	for h := s.FromHeight; h <= s.ToHeight; h++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if s.misses(h) {
+			continue
+		}
+		f.voteErrs[h] = ru.vote(ctx, f, h, ru.blocks[f.consumerID][h], false)
+		if !s.equivocates(h) {
+			continue
+		}
+		forkHash, err := demo.RandomBlockHash()
+		if err != nil {
+			return err
+		}
+		f.forkErrs[h] = ru.vote(ctx, f, h, forkHash, true)
+	}
+	return nil
+}
+
+// vote signs and submits the vote of the FP for the block. A conflicting
+// vote bypasses the slashing protection, and whether the contract slashed
+// the FP for it is recorded.
+func (ru *run) vote(ctx context.Context, f *fp, height uint64, blockHash []byte, conflicting bool) error {
+	msg, err := demo.SignVote(ctx, f.signer, ru.store, f.consumerID, f.randList(height), height, blockHash, conflicting)
+	if err != nil {
+		return err
+	}
+	res, err := ru.contracts[f.consumerID].SubmitFinalitySignature(ctx, msg)
+	if conflicting && res != nil && hasSlashingEvent(res) {
+		f.slashingEvents[height] = true
+	}
+	if err != nil {
+		log.Printf("Vote of %s for block %x at height %d rejected: %v", f.spec.Key, blockHash, height, err)
+		return err
+	}
+	log.Printf("Vote of %s for block %x at height %d submitted in tx %s", f.spec.Key, blockHash, height, res.TxHash)
+	return nil
+}
+
+// hasSlashingEvent reports whether the tx emitted the slashing event of the
+// contract
+func hasSlashingEvent(res *sdk.TxResponse) bool {
+	for _, event := range res.Events {
+		if strings.HasSuffix(event.Type, opfinality.EventSlashedFinalityProvider) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVotes asserts for every consumer and every height its FPs have a
+// block to sign at that the voters of the block are exactly the FPs that
+// signed it, and that each conflicting vote got the FP slashed by the
+// contract
+func (ru *run) checkVotes(ctx context.Context) error {
+	for _, c := range ru.sc.Consumers {
+		for _, h := range ru.heights(c.ID) {
+			if err := ru.checkVoters(ctx, c.ID, h); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range ru.fps {
+		if f.spec.Signing == nil {
+			continue
+		}
+		for _, h := range f.spec.Signing.Equivocate {
+			name := fmt.Sprintf("equivocation of %s at height %d slashed by the contract", f.spec.Key, h)
+			switch {
+			case f.slashingEvents[h]:
+				ru.report.assert(name, true, "%s emitted", opfinality.EventSlashedFinalityProvider)
+			case f.forkErrs[h] != nil:
+				ru.report.assert(name, false, "conflicting vote rejected: %v", f.forkErrs[h])
+			default:
+				ru.report.assert(name, false, "no %s event", opfinality.EventSlashedFinalityProvider)
+			}
+		}
+	}
+	return nil
+}
+
+// checkVoters queries the voters of the block of the consumer at height, up
+// to VoteRetries times until every FP that submitted its vote is among
+// them, then asserts that they are exactly the FPs that signed the block
+func (ru *run) checkVoters(ctx context.Context, consumerID string, height uint64) error {
+	var signers, submitted []*fp
+	keys := map[string]string{}
+	for _, f := range ru.fps {
+		if f.consumerID != consumerID {
+			continue
+		}
+		keys[f.pkHex] = f.spec.Key
+		s := f.spec.Signing
+		if s == nil || height < s.FromHeight || height > s.ToHeight || s.misses(height) {
+			continue
+		}
+		signers = append(signers, f)
+		if f.voteErrs[height] == nil {
+			submitted = append(submitted, f)
+		}
+	}
+
+	var voters map[string]bool
+	var err error
+	for attempt := 1; attempt <= ru.cfg.VoteRetries; attempt++ {
+		voters, err = ru.queryVoters(ctx, consumerID, height)
+		if err == nil && allVoted(submitted, voters) {
+			break
+		}
+		if attempt == ru.cfg.VoteRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ru.cfg.VoteRetryInterval):
+		}
+	}
+
+	name := fmt.Sprintf("voters of %s at height %d", consumerID, height)
+	if err != nil {
+		ru.report.assert(name, false, "%v", err)
+		return nil
+	}
+	var missing, unexpected, voted []string
+	for _, f := range signers {
+		switch {
+		case voters[f.pkHex]:
+		case f.voteErrs[height] != nil:
+			missing = append(missing, fmt.Sprintf("%s (%v)", f.spec.Key, f.voteErrs[height]))
+		default:
+			missing = append(missing, f.spec.Key)
+		}
+	}
+	for pk := range voters {
+		key, ok := keys[pk]
+		if !ok {
+			key = pk
+		}
+		voted = append(voted, key)
+		if !containsFp(signers, pk) {
+			unexpected = append(unexpected, key)
+		}
+	}
+	sort.Strings(voted)
+	sort.Strings(unexpected)
+
+	detail := fmt.Sprintf("%d of %d signers voted: %s", len(signers)-len(missing), len(signers), strings.Join(voted, ", "))
+	if len(missing) > 0 {
+		detail += "; missing: " + strings.Join(missing, ", ")
+	}
+	if len(unexpected) > 0 {
+		detail += "; unexpected: " + strings.Join(unexpected, ", ")
+	}
+	ru.report.assert(name, len(missing) == 0 && len(unexpected) == 0, "%s", detail)
+	return nil
+}
+
+// queryVoters returns the set of FPs that voted for the block of the
+// consumer at height
+func (ru *run) queryVoters(ctx context.Context, consumerID string, height uint64) (map[string]bool, error) {
+	voters, err := ru.contracts[consumerID].QueryBlockVoters(ctx, height, ru.blocks[consumerID][height])
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(voters))
+	for _, voter := range voters {
+		set[voter] = true
+	}
+	return set, nil
+}
+
+// allVoted reports whether every FP is among the voters
+func allVoted(fps []*fp, voters map[string]bool) bool {
+	for _, f := range fps {
+		if !voters[f.pkHex] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFp(fps []*fp, pkHex string) bool {
+	for _, f := range fps {
+		if f.pkHex == pkHex {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSlashing waits for the FPs expected to be slashed to show up as
+// slashed or jailed on Babylon, then asserts that the others are neither
+func (ru *run) checkSlashing(ctx context.Context) error {
+	pending := map[*fp]string{}
+	for _, f := range ru.fps {
+		if f.spec.expectSlashed() {
+			pending[f] = "not slashed"
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(ru.sc.SlashingTimeout))
+	defer cancel()
+poll:
+	for len(pending) > 0 {
+		for f := range pending {
+			resp, err := ru.chain.QueryFinalityProvider(waitCtx, f.pkHex)
+			switch {
+			case err != nil:
+				pending[f] = err.Error()
+			case isSlashed(resp):
+				delete(pending, f)
+				ru.report.assert(f.spec.Key+" slashed", true, "%s", slashingState(resp))
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			break poll
+		case <-time.After(ru.cfg.SlashingPollInterval):
+		}
+	}
+
+	for _, f := range ru.fps {
+		if f.spec.expectSlashed() {
+			if status, ok := pending[f]; ok {
+				ru.report.assert(f.spec.Key+" slashed", false, "%s after %v", status, time.Duration(ru.sc.SlashingTimeout))
+			}
+			continue
+		}
+		resp, err := ru.chain.QueryFinalityProvider(ctx, f.pkHex)
+		if err != nil {
+			ru.report.assert(f.spec.Key+" not slashed", false, "%v", err)
+			continue
+		}
+		ru.report.assert(f.spec.Key+" not slashed", !isSlashed(resp), "%s", slashingState(resp))
+	}
+	return nil
+}
+
+// isSlashed reports whether Babylon slashed or jailed the FP
+func isSlashed(resp *bstypes.FinalityProviderResponse) bool {
+	return resp.SlashedBabylonHeight > 0 || resp.SlashedBtcHeight > 0 || resp.Jailed
+}
+
+func slashingState(resp *bstypes.FinalityProviderResponse) string {
+	return fmt.Sprintf("slashed_babylon_height %d, slashed_btc_height %d, jailed %t",
+		resp.SlashedBabylonHeight, resp.SlashedBtcHeight, resp.Jailed)
+}
@@ -0,0 +1,336 @@
+// Package scenario runs declarative integration scenarios against a running
+// deployment. A scenario file lists the consumers to register, the finality
+// providers of Babylon and of each consumer with their commission, the BTC
+// delegations, and for every consumer FP the windows of randomness it
+// commits and the heights it signs, misses or equivocates at. The Runner
+// executes a scenario, with the consumer FPs committing and signing
+// concurrently, and asserts the outcomes it implies: every FP is registered
+// with its commission, every delegation becomes active, the voters of every
+// block are exactly the FPs that signed it, and exactly the equivocating FPs
+// end up slashed. Generate writes the scenario of many FPs across many
+// consumers.
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"crypto-ops-tool/demo"
+)
+
+// Scenario is a declarative integration run, loaded from a YAML file
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// BabylonFPs are the FPs securing Babylon, which every delegation
+	// needs one of
+	BabylonFPs  []FinalityProvider `json:"babylon_finality_providers,omitempty"`
+	Consumers   []Consumer         `json:"consumers"`
+	Delegations []Delegation       `json:"delegations,omitempty"`
+	// ActivationTimeout bounds the wait for the delegations to become
+	// active, and SlashingTimeout the wait for the equivocating FPs to be
+	// slashed on Babylon
+	ActivationTimeout demo.Duration `json:"activation_timeout,omitempty"`
+	SlashingTimeout   demo.Duration `json:"slashing_timeout,omitempty"`
+}
+
+// Consumer is a consumer registered with its own finality contract
+type Consumer struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// MaxMultiStakedFps is the maximum number of FPs of the consumer a
+	// delegation may stake to
+	MaxMultiStakedFps uint32             `json:"max_multi_staked_fps,omitempty"`
+	FinalityProviders []FinalityProvider `json:"finality_providers"`
+}
+
+// FinalityProvider is an FP of Babylon or of a consumer
+type FinalityProvider struct {
+	// Key is the keystore name of the FP key, by which delegations refer to
+	// the FP
+	Key        string      `json:"key"`
+	Moniker    string      `json:"moniker,omitempty"`
+	Commission *Commission `json:"commission,omitempty"`
+	// CommitWindows are the ranges of heights the FP commits randomness
+	// for, in order. Consumer FPs only.
+	CommitWindows []CommitWindow `json:"commit_windows,omitempty"`
+	// Signing is the signing schedule of the FP. Consumer FPs only.
+	Signing *Signing `json:"signing,omitempty"`
+	// ExpectSlashed is whether the FP must end up slashed or jailed on
+	// Babylon. It defaults to whether the FP equivocates.
+	ExpectSlashed *bool `json:"expect_slashed,omitempty"`
+}
+
+// Commission holds the commission parameters of an FP, as decimals
+type Commission struct {
+	Rate          string `json:"rate,omitempty"`
+	MaxRate       string `json:"max_rate,omitempty"`
+	MaxChangeRate string `json:"max_change_rate,omitempty"`
+}
+
+// CommitWindow is a range of heights an FP commits randomness for
+type CommitWindow struct {
+	StartHeight uint64 `json:"start_height"`
+	NumPubRand  uint64 `json:"num_pub_rand"`
+}
+
+// EndHeight returns the last height of the window
+func (w CommitWindow) EndHeight() uint64 {
+	return w.StartHeight + w.NumPubRand - 1
+}
+
+// Signing is the signing schedule of an FP: it signs every height from
+// FromHeight to ToHeight except the Missed ones, and at the Equivocate ones
+// also signs a conflicting block
+type Signing struct {
+	FromHeight uint64   `json:"from_height"`
+	ToHeight   uint64   `json:"to_height"`
+	Missed     []uint64 `json:"missed,omitempty"`
+	Equivocate []uint64 `json:"equivocate,omitempty"`
+}
+
+// misses reports whether the schedule skips height
+func (s *Signing) misses(height uint64) bool {
+	return containsHeight(s.Missed, height)
+}
+
+// equivocates reports whether the schedule equivocates at height
+func (s *Signing) equivocates(height uint64) bool {
+	return containsHeight(s.Equivocate, height)
+}
+
+func containsHeight(heights []uint64, height uint64) bool {
+	for _, h := range heights {
+		if h == height {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegation is a BTC delegation to one or more FPs
+type Delegation struct {
+	// Amount is the delegated amount in satoshis
+	Amount int64 `json:"amount"`
+	// StakingTime is the staking time lock in BTC blocks
+	StakingTime int64 `json:"staking_time"`
+	// FinalityProviders are the keys of the FPs delegated to
+	FinalityProviders []string `json:"finality_providers"`
+	// ExpectActive is whether the delegation must become active within
+	// the activation timeout. It defaults to true.
+	ExpectActive *bool `json:"expect_active,omitempty"`
+}
+
+// expectActive reports whether the delegation must become active
+func (d *Delegation) expectActive() bool {
+	return d.ExpectActive == nil || *d.ExpectActive
+}
+
+// expectSlashed reports whether the FP must end up slashed or jailed
+func (fp *FinalityProvider) expectSlashed() bool {
+	if fp.ExpectSlashed != nil {
+		return *fp.ExpectSlashed
+	}
+	return fp.Signing != nil && len(fp.Signing.Equivocate) > 0
+}
+
+// Load reads a scenario file, fills in the defaults and validates it.
+// Unknown fields are rejected so that a typo does not silently change what
+// a scenario tests.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	sc, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return sc, nil
+}
+
+func parse(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.UnmarshalStrict(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	sc.setDefaults()
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid: %w", err)
+	}
+	return &sc, nil
+}
+
+// setDefaults fills in the settings a scenario leaves out with those of the
+// demo
+func (sc *Scenario) setDefaults() {
+	defaults := demo.DefaultConfig()
+	if sc.ActivationTimeout == 0 {
+		sc.ActivationTimeout = demo.Duration(defaults.StepTimeouts[demo.StepWaitActivation])
+	}
+	if sc.SlashingTimeout == 0 {
+		sc.SlashingTimeout = demo.Duration(2 * time.Minute)
+	}
+	setCommissionDefaults := func(fps []FinalityProvider) {
+		for i := range fps {
+			if fps[i].Commission == nil {
+				fps[i].Commission = &Commission{}
+			}
+			c := fps[i].Commission
+			if c.Rate == "" {
+				c.Rate = defaults.CommissionRate
+			}
+			if c.MaxRate == "" {
+				c.MaxRate = defaults.CommissionMaxRate
+			}
+			if c.MaxChangeRate == "" {
+				c.MaxChangeRate = defaults.CommissionMaxChangeRate
+			}
+			if fps[i].Moniker == "" {
+				fps[i].Moniker = fps[i].Key
+			}
+		}
+	}
+	setCommissionDefaults(sc.BabylonFPs)
+	for i := range sc.Consumers {
+		c := &sc.Consumers[i]
+		if c.Name == "" {
+			c.Name = c.ID
+		}
+		if c.MaxMultiStakedFps == 0 {
+			c.MaxMultiStakedFps = defaults.MaxMultiStakedFps
+		}
+		setCommissionDefaults(c.FinalityProviders)
+	}
+}
+
+// Validate checks that the scenario can be run
+func (sc *Scenario) Validate() error {
+	if sc.Name == "" {
+		return fmt.Errorf("name must be set")
+	}
+	if len(sc.Consumers) == 0 {
+		return fmt.Errorf("at least one consumer is required")
+	}
+	if sc.ActivationTimeout <= 0 || sc.SlashingTimeout <= 0 {
+		return fmt.Errorf("timeouts must be positive")
+	}
+
+	keys := map[string]bool{}
+	for _, fp := range sc.BabylonFPs {
+		if err := fp.validate(keys); err != nil {
+			return err
+		}
+		if len(fp.CommitWindows) > 0 || fp.Signing != nil {
+			return fmt.Errorf("Babylon FP %s cannot commit randomness or sign consumer blocks", fp.Key)
+		}
+	}
+	consumerIDs := map[string]bool{}
+	for _, c := range sc.Consumers {
+		if c.ID == "" {
+			return fmt.Errorf("consumer id must be set")
+		}
+		if consumerIDs[c.ID] {
+			return fmt.Errorf("consumer %s is listed twice", c.ID)
+		}
+		consumerIDs[c.ID] = true
+		for _, fp := range c.FinalityProviders {
+			if err := fp.validate(keys); err != nil {
+				return fmt.Errorf("consumer %s: %w", c.ID, err)
+			}
+			if err := fp.validateSchedule(); err != nil {
+				return fmt.Errorf("consumer %s: FP %s: %w", c.ID, fp.Key, err)
+			}
+		}
+	}
+
+	for i, del := range sc.Delegations {
+		if del.Amount <= 0 || del.StakingTime <= 0 {
+			return fmt.Errorf("delegation %d: amount and staking time must be positive", i)
+		}
+		if len(del.FinalityProviders) == 0 {
+			return fmt.Errorf("delegation %d: at least one FP is required", i)
+		}
+		for _, key := range del.FinalityProviders {
+			if !keys[key] {
+				return fmt.Errorf("delegation %d: unknown FP %s", i, key)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the FP settings and that its key is not used by another
+// FP of keys
+func (fp *FinalityProvider) validate(keys map[string]bool) error {
+	if fp.Key == "" {
+		return fmt.Errorf("FP key must be set")
+	}
+	if keys[fp.Key] {
+		return fmt.Errorf("FP key %s is used twice", fp.Key)
+	}
+	keys[fp.Key] = true
+	if fp.Commission == nil {
+		return fmt.Errorf("FP %s: commission must be set", fp.Key)
+	}
+	if _, err := demo.ParseCommission(fp.Commission.Rate, fp.Commission.MaxRate, fp.Commission.MaxChangeRate); err != nil {
+		return fmt.Errorf("FP %s: %w", fp.Key, err)
+	}
+	return nil
+}
+
+// validateSchedule checks that the commit windows are ordered and disjoint
+// and cover every height of the signing schedule
+func (fp *FinalityProvider) validateSchedule() error {
+	windows := append([]CommitWindow(nil), fp.CommitWindows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i].StartHeight < windows[j].StartHeight })
+	for i, w := range windows {
+		if w.NumPubRand == 0 {
+			return fmt.Errorf("commit window from height %d is empty", w.StartHeight)
+		}
+		if i > 0 && w.StartHeight <= windows[i-1].EndHeight() {
+			return fmt.Errorf("commit windows from heights %d and %d overlap", windows[i-1].StartHeight, w.StartHeight)
+		}
+	}
+
+	s := fp.Signing
+	if s == nil {
+		return nil
+	}
+	if s.FromHeight == 0 || s.ToHeight < s.FromHeight {
+		return fmt.Errorf("signing heights %d to %d are not a valid range", s.FromHeight, s.ToHeight)
+	}
+	for _, heights := range [][]uint64{s.Missed, s.Equivocate} {
+		for _, h := range heights {
+			if h < s.FromHeight || h > s.ToHeight {
+				return fmt.Errorf("height %d is outside of the signing range %d to %d", h, s.FromHeight, s.ToHeight)
+			}
+		}
+	}
+	for _, h := range s.Equivocate {
+		if s.misses(h) {
+			return fmt.Errorf("height %d is both missed and equivocated", h)
+		}
+	}
+	for h := s.FromHeight; h <= s.ToHeight; h++ {
+		if !s.misses(h) && fp.commitWindow(h) == nil {
+			return fmt.Errorf("height %d is signed but not covered by a commit window", h)
+		}
+	}
+	return nil
+}
+
+// commitWindow returns the commit window covering height, or nil
+func (fp *FinalityProvider) commitWindow(height uint64) *CommitWindow {
+	for i, w := range fp.CommitWindows {
+		if height >= w.StartHeight && height <= w.EndHeight() {
+			return &fp.CommitWindows[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package scenario
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadScenarios loads every scenario shipped with the deployment
+func TestLoadScenarios(t *testing.T) {
+	paths, err := filepath.Glob("../../scenarios/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scenarios found")
+	}
+	for _, path := range paths {
+		if _, err := Load(path); err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+}
+
+// validScenario returns a scenario with a Babylon FP, a consumer FP signing
+// heights 1 to 10 from one commit window and a delegation to both
+func validScenario() *Scenario {
+	sc := &Scenario{
+		Name:       "test",
+		BabylonFPs: []FinalityProvider{{Key: "bbn-fp"}},
+		Consumers: []Consumer{{
+			ID: "consumer-a",
+			FinalityProviders: []FinalityProvider{{
+				Key:           "consumer-fp",
+				CommitWindows: []CommitWindow{{StartHeight: 1, NumPubRand: 10}},
+				Signing:       &Signing{FromHeight: 1, ToHeight: 10},
+			}},
+		}},
+		Delegations: []Delegation{{
+			Amount:            1000000,
+			StakingTime:       10000,
+			FinalityProviders: []string{"bbn-fp", "consumer-fp"},
+		}},
+	}
+	sc.setDefaults()
+	return sc
+}
+
+func TestValidate(t *testing.T) {
+	if err := validScenario().Validate(); err != nil {
+		t.Fatalf("valid scenario: %v", err)
+	}
+
+	tests := map[string]struct {
+		modify func(sc *Scenario)
+		err    string
+	}{
+		"duplicate consumer": {
+			modify: func(sc *Scenario) {
+				sc.Consumers = append(sc.Consumers, Consumer{ID: "consumer-a"})
+			},
+			err: "consumer consumer-a is listed twice",
+		},
+		"duplicate FP key": {
+			modify: func(sc *Scenario) {
+				sc.BabylonFPs[0].Key = "consumer-fp"
+			},
+			err: "FP key consumer-fp is used twice",
+		},
+		"unknown delegation FP": {
+			modify: func(sc *Scenario) {
+				sc.Delegations[0].FinalityProviders = []string{"bbn-fp", "other-fp"}
+			},
+			err: "delegation 0: unknown FP other-fp",
+		},
+		"signing before the commit windows": {
+			modify: func(sc *Scenario) {
+				sc.Consumers[0].FinalityProviders[0].CommitWindows[0].StartHeight = 2
+			},
+			err: "height 1 is signed but not covered by a commit window",
+		},
+		"signing after the commit windows": {
+			modify: func(sc *Scenario) {
+				sc.Consumers[0].FinalityProviders[0].Signing.ToHeight = 11
+			},
+			err: "height 11 is signed but not covered by a commit window",
+		},
+		"signing between the commit windows": {
+			modify: func(sc *Scenario) {
+				sc.Consumers[0].FinalityProviders[0].CommitWindows = []CommitWindow{
+					{StartHeight: 1, NumPubRand: 4},
+					{StartHeight: 6, NumPubRand: 5},
+				}
+			},
+			err: "height 5 is signed but not covered by a commit window",
+		},
+		"overlapping commit windows": {
+			modify: func(sc *Scenario) {
+				fp := &sc.Consumers[0].FinalityProviders[0]
+				fp.CommitWindows = append(fp.CommitWindows, CommitWindow{StartHeight: 10, NumPubRand: 5})
+			},
+			err: "commit windows from heights 1 and 10 overlap",
+		},
+		"equivocating at a missed height": {
+			modify: func(sc *Scenario) {
+				s := sc.Consumers[0].FinalityProviders[0].Signing
+				s.Missed, s.Equivocate = []uint64{5}, []uint64{5}
+			},
+			err: "height 5 is both missed and equivocated",
+		},
+		"Babylon FP signing": {
+			modify: func(sc *Scenario) {
+				sc.BabylonFPs[0].Signing = &Signing{FromHeight: 1, ToHeight: 1}
+			},
+			err: "Babylon FP bbn-fp cannot commit randomness",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc := validScenario()
+			tc.modify(sc)
+			err := sc.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("err = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+// TestValidateMissedOutsideCommitWindows checks that missed heights need no
+// randomness
+func TestValidateMissedOutsideCommitWindows(t *testing.T) {
+	sc := validScenario()
+	s := sc.Consumers[0].FinalityProviders[0].Signing
+	s.ToHeight, s.Missed = 11, []uint64{11}
+	if err := sc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	_, err := parse([]byte("name: test\nconsumers:\n  - id: a\n    finality_provider: []\n"))
+	if err == nil {
+		t.Fatal("unknown field accepted")
+	}
+}
@@ -0,0 +1,98 @@
+package scenario
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	"crypto-ops-tool/demo"
+)
+
+// Topology describes a scenario of many consumer FPs spread across several
+// consumers, all signing the same heights, to generate with Generate
+type Topology struct {
+	Name string
+	// Consumers is the number of consumers, each with its own finality
+	// contract
+	Consumers int
+	// FinalityProviders is the number of consumer FPs, spread evenly across
+	// the consumers
+	FinalityProviders int
+	// FromHeight and ToHeight are the heights every consumer FP signs, in a
+	// single commit window starting at FromHeight
+	FromHeight uint64
+	ToHeight   uint64
+	// StakingAmount and StakingTime are those of the delegation each
+	// consumer FP gets together with the Babylon FP
+	StakingAmount int64
+	StakingTime   int64
+}
+
+// DefaultTopology returns a topology of 2 consumers with 3 FPs each,
+// delegated to like in the demo
+func DefaultTopology() Topology {
+	defaults := demo.DefaultConfig()
+	return Topology{
+		Name:              "multi-fp",
+		Consumers:         2,
+		FinalityProviders: 6,
+		FromHeight:        1,
+		ToHeight:          defaults.NumFinalitySigs,
+		StakingAmount:     defaults.StakingAmount,
+		StakingTime:       defaults.StakingTimeBlocks,
+	}
+}
+
+// Generate returns the scenario of the topology. The i-th consumer FP
+// belongs to consumer i modulo Consumers, and every FP has one delegation to
+// it and the Babylon FP, so that all of them have voting power.
+func Generate(t Topology) (*Scenario, error) {
+	if t.Name == "" {
+		return nil, fmt.Errorf("name must be set")
+	}
+	if t.Consumers <= 0 || t.FinalityProviders < t.Consumers {
+		return nil, fmt.Errorf("every one of the %d consumers needs an FP, got %d", t.Consumers, t.FinalityProviders)
+	}
+	if t.FromHeight == 0 || t.ToHeight < t.FromHeight {
+		return nil, fmt.Errorf("heights %d to %d are not a valid range", t.FromHeight, t.ToHeight)
+	}
+
+	bbnFpKey := t.Name + "-bbn-fp"
+	sc := &Scenario{
+		Name: t.Name,
+		Description: fmt.Sprintf("%d consumer FPs across %d consumers signing heights %d to %d",
+			t.FinalityProviders, t.Consumers, t.FromHeight, t.ToHeight),
+		BabylonFPs: []FinalityProvider{{Key: bbnFpKey}},
+	}
+	for i := 0; i < t.Consumers; i++ {
+		sc.Consumers = append(sc.Consumers, Consumer{ID: fmt.Sprintf("%s-consumer-%d", t.Name, i+1)})
+	}
+	for i := 0; i < t.FinalityProviders; i++ {
+		c := &sc.Consumers[i%t.Consumers]
+		key := fmt.Sprintf("%s-fp-%d", c.ID, len(c.FinalityProviders)+1)
+		c.FinalityProviders = append(c.FinalityProviders, FinalityProvider{
+			Key: key,
+			CommitWindows: []CommitWindow{{
+				StartHeight: t.FromHeight,
+				NumPubRand:  t.ToHeight - t.FromHeight + 1,
+			}},
+			Signing: &Signing{FromHeight: t.FromHeight, ToHeight: t.ToHeight},
+		})
+		sc.Delegations = append(sc.Delegations, Delegation{
+			Amount:            t.StakingAmount,
+			StakingTime:       t.StakingTime,
+			FinalityProviders: []string{bbnFpKey, key},
+		})
+	}
+
+	// Check the scenario as Load would, but leave the defaults out so that
+	// it is written out as compact as a hand-written one
+	data, err := yaml.Marshal(sc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scenario: %w", err)
+	}
+	if _, err := parse(data); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
@@ -0,0 +1,100 @@
+package signer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Kinds of the built-in signers
+const (
+	KindLocal    = "local"
+	KindKeystore = "keystore"
+	KindEOTSD    = "eotsd"
+)
+
+// Config selects a signer and holds what it needs. Each kind reads only
+// its own fields.
+type Config struct {
+	// Kind is the registered kind of the signer
+	Kind string
+	// PrivateKey is the FP key of a local signer
+	PrivateKey *btcec.PrivateKey
+	// Keys holds the key KeyName of a keystore signer
+	Keys    KeySource
+	KeyName string
+	// Target is the eotsd://host:port address of an eotsd signer
+	Target string
+	// FpPk is the public key of the FP key held by a remote signer
+	FpPk *bbn.BIP340PubKey
+	// Timeout bounds each call to a remote signer
+	Timeout time.Duration
+}
+
+// Factory creates a signer from its config
+type Factory func(cfg Config) (Signer, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+func init() {
+	Register(KindLocal, func(cfg Config) (Signer, error) {
+		if cfg.PrivateKey == nil {
+			return nil, fmt.Errorf("a local signer needs a private key")
+		}
+		return NewLocal(cfg.PrivateKey), nil
+	})
+	Register(KindKeystore, func(cfg Config) (Signer, error) {
+		if cfg.Keys == nil || cfg.KeyName == "" {
+			return nil, fmt.Errorf("a keystore signer needs a key name")
+		}
+		return NewKeystore(cfg.Keys, cfg.KeyName)
+	})
+	Register(KindEOTSD, func(cfg Config) (Signer, error) {
+		if cfg.Target == "" || cfg.FpPk == nil {
+			return nil, fmt.Errorf("an eotsd signer needs a target and the public key of the FP")
+		}
+		return DialRemote(cfg.Target, cfg.FpPk, cfg.Timeout)
+	})
+}
+
+// Register makes a kind of signer available to New. It panics when kind is
+// already registered.
+func Register(kind string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, ok := factories[kind]; ok {
+		panic(fmt.Sprintf("signer kind %q registered twice", kind))
+	}
+	factories[kind] = factory
+}
+
+// Kinds returns the registered kinds of signers, sorted
+func Kinds() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New creates the signer of kind cfg.Kind
+func New(cfg Config) (Signer, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.Kind]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signer %q (available: %s)", cfg.Kind, strings.Join(Kinds(), ", "))
+	}
+	return factory(cfg)
+}
@@ -0,0 +1,124 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/keystore"
+)
+
+// KeySource holds named FP keys: the encrypted keystore or a Cosmos SDK
+// keyring
+type KeySource interface {
+	Get(name string) (*btcec.PrivateKey, error)
+	Info(name string) (*keystore.KeyInfo, error)
+}
+
+// KeystoreSigner signs with a named key of a KeySource. The public key is
+// read from the key description, and the key is only decrypted, once, when
+// the first signature is made.
+type KeystoreSigner struct {
+	keys KeySource
+	name string
+	pk   *bbn.BIP340PubKey
+
+	mu    sync.Mutex
+	local *LocalSigner
+}
+
+var _ SecretRandSigner = (*KeystoreSigner)(nil)
+
+// NewKeystore returns a signer using the key stored under name
+func NewKeystore(keys KeySource, name string) (*KeystoreSigner, error) {
+	info, err := keys.Info(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key: %w", err)
+	}
+	pk, err := bbn.NewBIP340PubKeyFromHex(info.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of key %s: %w", name, err)
+	}
+	return &KeystoreSigner{keys: keys, name: name, pk: pk}, nil
+}
+
+// Name returns the name of the key
+func (s *KeystoreSigner) Name() string {
+	return s.name
+}
+
+// PublicKey implements Signer
+func (s *KeystoreSigner) PublicKey() *bbn.BIP340PubKey {
+	return s.pk
+}
+
+// SignPoP implements Signer
+func (s *KeystoreSigner) SignPoP(ctx context.Context, addr sdk.AccAddress) (*bstypes.ProofOfPossessionBTC, error) {
+	local, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return local.SignPoP(ctx, addr)
+}
+
+// SignSchnorr implements Signer
+func (s *KeystoreSigner) SignSchnorr(ctx context.Context, hash []byte) (*schnorr.Signature, error) {
+	local, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return local.SignSchnorr(ctx, hash)
+}
+
+// PubRandList implements Signer
+func (s *KeystoreSigner) PubRandList(ctx context.Context, chainID []byte, startHeight, num uint64) ([]*btcec.FieldVal, error) {
+	local, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return local.PubRandList(ctx, chainID, startHeight, num)
+}
+
+// SignEOTS implements Signer
+func (s *KeystoreSigner) SignEOTS(ctx context.Context, chainID []byte, height uint64, msg []byte) (*btcec.ModNScalar, error) {
+	local, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return local.SignEOTS(ctx, chainID, height, msg)
+}
+
+// SignEOTSWithSecretRand implements SecretRandSigner
+func (s *KeystoreSigner) SignEOTSWithSecretRand(ctx context.Context, sr *btcec.ModNScalar, msg []byte) (*btcec.ModNScalar, error) {
+	local, err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+	return local.SignEOTSWithSecretRand(ctx, sr, msg)
+}
+
+// unlock decrypts the key on first use
+func (s *KeystoreSigner) unlock() (*LocalSigner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local != nil {
+		return s.local, nil
+	}
+	sk, err := s.keys.Get(s.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key: %w", err)
+	}
+	local := NewLocal(sk)
+	if !bytes.Equal(local.PublicKey().MustMarshal(), s.pk.MustMarshal()) {
+		return nil, fmt.Errorf("key %s does not match its public key %s", s.name, s.pk.MarshalHex())
+	}
+	s.local = local
+	return local, nil
+}
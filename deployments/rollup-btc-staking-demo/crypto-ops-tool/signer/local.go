@@ -0,0 +1,80 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	"github.com/babylonlabs-io/babylon/v4/testutil/datagen"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/randgen"
+)
+
+// LocalSigner signs with an FP key held in memory, deriving randomness
+// like the EOTS manager does
+type LocalSigner struct {
+	sk *btcec.PrivateKey
+	pk *bbn.BIP340PubKey
+}
+
+var _ SecretRandSigner = (*LocalSigner)(nil)
+
+// NewLocal returns a signer using sk
+func NewLocal(sk *btcec.PrivateKey) *LocalSigner {
+	return &LocalSigner{
+		sk: sk,
+		pk: bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()),
+	}
+}
+
+// PublicKey implements Signer
+func (s *LocalSigner) PublicKey() *bbn.BIP340PubKey {
+	return s.pk
+}
+
+// SignPoP implements Signer exactly like datagen.NewPoPBTC
+func (s *LocalSigner) SignPoP(_ context.Context, addr sdk.AccAddress) (*bstypes.ProofOfPossessionBTC, error) {
+	pop, err := datagen.NewPoPBTC(addr, s.sk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PoP: %w", err)
+	}
+	return pop, nil
+}
+
+// SignSchnorr implements Signer
+func (s *LocalSigner) SignSchnorr(_ context.Context, hash []byte) (*schnorr.Signature, error) {
+	sig, err := schnorr.Sign(s.sk, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return sig, nil
+}
+
+// PubRandList implements Signer
+func (s *LocalSigner) PubRandList(_ context.Context, chainID []byte, startHeight, num uint64) ([]*btcec.FieldVal, error) {
+	randList, err := randgen.GenerateRandList(s.sk.Serialize(), chainID, startHeight, num)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive randomness: %w", err)
+	}
+	return randList.PRList, nil
+}
+
+// SignEOTS implements Signer
+func (s *LocalSigner) SignEOTS(ctx context.Context, chainID []byte, height uint64, msg []byte) (*btcec.ModNScalar, error) {
+	sr, _ := randgen.GenerateRandomness(s.sk.Serialize(), chainID, height)
+	return s.SignEOTSWithSecretRand(ctx, sr, msg)
+}
+
+// SignEOTSWithSecretRand implements SecretRandSigner
+func (s *LocalSigner) SignEOTSWithSecretRand(_ context.Context, sr *btcec.ModNScalar, msg []byte) (*btcec.ModNScalar, error) {
+	sig, err := eots.Sign(s.sk, sr, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate EOTS signature: %w", err)
+	}
+	return sig, nil
+}
@@ -0,0 +1,83 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/eotsclient"
+)
+
+// RemoteSigner delegates signing to an EOTS manager holding the FP key, so
+// that the key never enters crypto-ops. The EOTS manager derives the
+// randomness itself, and refuses to sign two messages at the same height.
+type RemoteSigner struct {
+	client *eotsclient.Client
+	target string
+	pk     *bbn.BIP340PubKey
+}
+
+var _ Signer = (*RemoteSigner)(nil)
+
+// DialRemote connects to the EOTS manager at target (eotsd://host:port)
+// signing with the key fpPk. timeout bounds each call.
+func DialRemote(target string, fpPk *bbn.BIP340PubKey, timeout time.Duration) (*RemoteSigner, error) {
+	client, err := eotsclient.Dial(target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteSigner{client: client, target: target, pk: fpPk}, nil
+}
+
+// Target returns the address of the EOTS manager
+func (s *RemoteSigner) Target() string {
+	return s.target
+}
+
+// Close closes the connection to the EOTS manager
+func (s *RemoteSigner) Close() error {
+	return s.client.Close()
+}
+
+// PublicKey implements Signer
+func (s *RemoteSigner) PublicKey() *bbn.BIP340PubKey {
+	return s.pk
+}
+
+// SignPoP implements Signer. The EOTS manager has no PoP call, so the
+// address hash signed by datagen.NewPoPBTC is signed with SignSchnorrSig.
+func (s *RemoteSigner) SignPoP(ctx context.Context, addr sdk.AccAddress) (*bstypes.ProofOfPossessionBTC, error) {
+	sig, err := s.SignSchnorr(ctx, tmhash.Sum(addr.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PoP: %w", err)
+	}
+	return &bstypes.ProofOfPossessionBTC{
+		BtcSigType: bstypes.BTCSigType_BIP340,
+		BtcSig:     bbn.NewBIP340SignatureFromBTCSig(sig).MustMarshal(),
+	}, nil
+}
+
+// SignSchnorr implements Signer
+func (s *RemoteSigner) SignSchnorr(ctx context.Context, hash []byte) (*schnorr.Signature, error) {
+	return s.client.SignSchnorrSig(ctx, s.pk.MustMarshal(), hash)
+}
+
+// PubRandList implements Signer
+func (s *RemoteSigner) PubRandList(ctx context.Context, chainID []byte, startHeight, num uint64) ([]*btcec.FieldVal, error) {
+	if num == 0 || num > uint64(^uint32(0)) {
+		return nil, fmt.Errorf("num pub rand must be between 1 and %d", ^uint32(0))
+	}
+	return s.client.CreateRandomnessPairList(ctx, s.pk.MustMarshal(), chainID, startHeight, uint32(num))
+}
+
+// SignEOTS implements Signer
+func (s *RemoteSigner) SignEOTS(ctx context.Context, chainID []byte, height uint64, msg []byte) (*btcec.ModNScalar, error) {
+	return s.client.SignEOTS(ctx, s.pk.MustMarshal(), chainID, msg, height)
+}
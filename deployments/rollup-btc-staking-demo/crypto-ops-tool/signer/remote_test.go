@@ -0,0 +1,187 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/babylonlabs-io/babylon/v4/crypto/eots"
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"crypto-ops-tool/eotsclient"
+)
+
+// startFake serves a fake EOTS manager holding sk and returns a remote
+// signer of that key dialled to it
+func startFake(t *testing.T, sk *btcec.PrivateKey) *RemoteSigner {
+	t.Helper()
+	fake := eotsclient.NewFakeServer(sk)
+	target, err := fake.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Stop)
+
+	// Dialling pings the EOTS manager
+	s, err := DialRemote(target, bbn.NewBIP340PubKeyFromBTCPK(sk.PubKey()), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testKey() *btcec.PrivateKey {
+	sk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	return sk
+}
+
+func TestRemoteSignerPubRandList(t *testing.T) {
+	sk := testKey()
+	remote := startFake(t, sk)
+	local := NewLocal(sk)
+	ctx := context.Background()
+	chainID := []byte("consumer-id")
+
+	got, err := remote.PubRandList(ctx, chainID, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := local.PubRandList(ctx, chainID, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if !got[i].Equals(want[i]) {
+			t.Errorf("public randomness of height %d differs from the local signer", 100+i)
+		}
+	}
+
+	if _, err := remote.PubRandList(ctx, chainID, 100, 0); err == nil {
+		t.Error("empty randomness list accepted")
+	}
+}
+
+func TestRemoteSignerSignEOTS(t *testing.T) {
+	sk := testKey()
+	remote := startFake(t, sk)
+	ctx := context.Background()
+	chainID := []byte("consumer-id")
+	const height = 42
+
+	prList, err := remote.PubRandList(ctx, chainID, height, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := append(sdk.Uint64ToBigEndian(height), bytes.Repeat([]byte{0xab}, 32)...)
+	sig, err := remote.SignEOTS(ctx, chainID, height, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eots.Verify(sk.PubKey(), prList[0], msg, sig); err != nil {
+		t.Fatalf("EOTS signature does not verify under the committed randomness: %v", err)
+	}
+
+	localSig, err := NewLocal(sk).SignEOTS(ctx, chainID, height, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Equals(localSig) {
+		t.Error("EOTS signature differs from the local signer")
+	}
+
+	// Signing the same message again is fine, another one is refused
+	if _, err := remote.SignEOTS(ctx, chainID, height, msg); err != nil {
+		t.Fatalf("signing the same message again: %v", err)
+	}
+	fork := append(sdk.Uint64ToBigEndian(height), bytes.Repeat([]byte{0xcd}, 32)...)
+	if _, err := remote.SignEOTS(ctx, chainID, height, fork); err == nil {
+		t.Fatal("EOTS manager signed two messages at the same height")
+	}
+}
+
+func TestRemoteSignerSignSchnorr(t *testing.T) {
+	sk := testKey()
+	remote := startFake(t, sk)
+
+	hash := tmhash.Sum([]byte("commitment"))
+	sig, err := remote.SignSchnorr(context.Background(), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(hash, sk.PubKey()) {
+		t.Fatal("Schnorr signature does not verify")
+	}
+}
+
+func TestRemoteSignerSignPoP(t *testing.T) {
+	sk := testKey()
+	remote := startFake(t, sk)
+	addr := sdk.AccAddress(bytes.Repeat([]byte{0x02}, 20))
+
+	pop, err := remote.SignPoP(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pop.Verify(addr, remote.PublicKey(), &chaincfg.RegressionNetParams); err != nil {
+		t.Fatalf("PoP does not verify: %v", err)
+	}
+
+	// The PoP is a BIP340 signature over tmhash(addr), like datagen.NewPoPBTC
+	sig, err := bbn.NewBIP340Signature(pop.BtcSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schnorrSig, err := sig.ToBTCSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schnorrSig.Verify(tmhash.Sum(addr.Bytes()), sk.PubKey()) {
+		t.Fatal("PoP is not a signature over tmhash(addr)")
+	}
+
+	other := sdk.AccAddress(bytes.Repeat([]byte{0x03}, 20))
+	if err := pop.Verify(other, remote.PublicKey(), &chaincfg.RegressionNetParams); err == nil {
+		t.Fatal("PoP verifies for another address")
+	}
+}
+
+func TestRemoteSignerUnknownKey(t *testing.T) {
+	fake := eotsclient.NewFakeServer(testKey())
+	target, err := fake.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fake.Stop)
+
+	otherSk, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x04}, 32))
+	s, err := DialRemote(target, bbn.NewBIP340PubKeyFromBTCPK(otherSk.PubKey()), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.SignSchnorr(context.Background(), tmhash.Sum(nil)); err == nil {
+		t.Fatal("EOTS manager signed with a key it does not hold")
+	}
+}
+
+func TestDialRemoteUnreachable(t *testing.T) {
+	fake := eotsclient.NewFakeServer()
+	target, err := fake.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Stop()
+
+	if _, err := DialRemote(target, bbn.NewBIP340PubKeyFromBTCPK(testKey().PubKey()), time.Second); err == nil {
+		t.Fatal("dialled a stopped EOTS manager")
+	}
+}
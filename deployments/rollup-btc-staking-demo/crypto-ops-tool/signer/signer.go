@@ -0,0 +1,62 @@
+// Package signer abstracts every signature made with the finality provider
+// BTC key: the proof of possession registering the FP, the Schnorr signature
+// of randomness commitments, and the EOTS signatures of blocks together with
+// the randomness they are made with. Commands sign through a Signer, so the
+// key may be held in process, in the encrypted keystore or by a remote EOTS
+// manager without the commands knowing which.
+package signer
+
+import (
+	"context"
+	"errors"
+
+	bbn "github.com/babylonlabs-io/babylon/v4/types"
+	bstypes "github.com/babylonlabs-io/babylon/v4/x/btcstaking/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Signer signs with a single FP key
+type Signer interface {
+	// PublicKey returns the BIP340 public key of the FP
+	PublicKey() *bbn.BIP340PubKey
+	// SignPoP returns the proof of possession of the FP key for the
+	// Babylon address registering the FP
+	SignPoP(ctx context.Context, addr sdk.AccAddress) (*bstypes.ProofOfPossessionBTC, error)
+	// SignSchnorr returns the BIP340 signature of the 32-byte hash, e.g.
+	// the hash of a randomness commitment
+	SignSchnorr(ctx context.Context, hash []byte) (*schnorr.Signature, error)
+	// PubRandList returns the public randomness of num consecutive heights
+	// starting at startHeight, derived from the FP key and chainID
+	PubRandList(ctx context.Context, chainID []byte, startHeight, num uint64) ([]*btcec.FieldVal, error)
+	// SignEOTS returns the EOTS signature of msg at height, made with the
+	// randomness PubRandList derives for that height
+	SignEOTS(ctx context.Context, chainID []byte, height uint64, msg []byte) (*btcec.ModNScalar, error)
+}
+
+// SecretRandSigner is implemented by the signers holding the FP key in
+// process. They can also sign with secret randomness generated outside of
+// them, such as the math/rand lists piped between commands.
+type SecretRandSigner interface {
+	Signer
+	// SignEOTSWithSecretRand returns the EOTS signature of msg made with sr
+	SignEOTSWithSecretRand(ctx context.Context, sr *btcec.ModNScalar, msg []byte) (*btcec.ModNScalar, error)
+}
+
+// ErrSecretRandUnsupported is returned when a signer deriving its own
+// randomness is asked to sign with randomness generated outside of it
+var ErrSecretRandUnsupported = errors.New("the signer derives its own randomness and cannot sign with randomness generated outside of it")
+
+// SignEOTSWithRand signs msg at height with the secret randomness sr, or,
+// when sr is nil, with the randomness s derives for chainID
+func SignEOTSWithRand(ctx context.Context, s Signer, chainID []byte, height uint64, sr *btcec.ModNScalar, msg []byte) (*btcec.ModNScalar, error) {
+	if sr == nil {
+		return s.SignEOTS(ctx, chainID, height, msg)
+	}
+	rs, ok := s.(SecretRandSigner)
+	if !ok {
+		return nil, ErrSecretRandUnsupported
+	}
+	return rs.SignEOTSWithSecretRand(ctx, sr, msg)
+}
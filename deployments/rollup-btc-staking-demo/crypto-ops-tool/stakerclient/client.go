@@ -0,0 +1,99 @@
+// Package stakerclient is a minimal client of the JSON-RPC API of the BTC
+// staker daemon (stakerd), the API `stakercli dn` commands are built on. It
+// lets the demo delegate BTC without shelling into the btc-staker container.
+package stakerclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	jsonrpcclient "github.com/cometbft/cometbft/rpc/jsonrpc/client"
+)
+
+// Output is an unspent output of the staker's BTC wallet
+type Output struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+}
+
+// StakeRequest is a BTC delegation to one or more finality providers
+type StakeRequest struct {
+	// StakerAddress is the BTC address funding the staking transaction
+	StakerAddress string
+	// StakingAmount is the staked amount in satoshis
+	StakingAmount int64
+	// FpBtcPksHex are the BIP340 public keys of the finality providers
+	FpBtcPksHex []string
+	// StakingTimeBlocks is the staking time lock in BTC blocks
+	StakingTimeBlocks int64
+}
+
+// Client talks to stakerd over HTTP
+type Client struct {
+	rpc *jsonrpcclient.Client
+}
+
+// New returns a client of the stakerd JSON-RPC endpoint at addr, e.g.
+// http://localhost:15912; each request is bounded by timeout
+func New(addr string, timeout time.Duration) (*Client, error) {
+	rpc, err := jsonrpcclient.NewWithHTTPClient(addr, &http.Client{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("invalid staker address %q: %w", addr, err)
+	}
+	return &Client{rpc: rpc}, nil
+}
+
+// ListOutputs returns the unspent outputs of the staker's wallet
+func (c *Client) ListOutputs(ctx context.Context) ([]Output, error) {
+	var result struct {
+		Outputs []Output `json:"outputs"`
+	}
+	if _, err := c.rpc.Call(ctx, "list_outputs", map[string]interface{}{}, &result); err != nil {
+		return nil, fmt.Errorf("failed to list staker outputs: %w", err)
+	}
+	return result.Outputs, nil
+}
+
+// StakerAddresses returns the distinct addresses holding the staker's
+// unspent outputs, sorted
+func (c *Client) StakerAddresses(ctx context.Context) ([]string, error) {
+	outputs, err := c.ListOutputs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var addrs []string
+	for _, output := range outputs {
+		if !seen[output.Address] {
+			seen[output.Address] = true
+			addrs = append(addrs, output.Address)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// Stake creates, signs and sends the staking transaction of req and
+// registers the delegation on Babylon. It returns the hash of the BTC
+// staking transaction.
+func (c *Client) Stake(ctx context.Context, req StakeRequest) (string, error) {
+	params := map[string]interface{}{
+		"stakerAddress":     req.StakerAddress,
+		"stakingAmount":     req.StakingAmount,
+		"fpBtcPks":          req.FpBtcPksHex,
+		"stakingTimeBlocks": req.StakingTimeBlocks,
+	}
+	var result struct {
+		TxHash string `json:"tx_hash"`
+	}
+	if _, err := c.rpc.Call(ctx, "stake", params, &result); err != nil {
+		return "", fmt.Errorf("failed to stake: %w", err)
+	}
+	if result.TxHash == "" {
+		return "", fmt.Errorf("staker returned no staking tx hash")
+	}
+	return result.TxHash, nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// PubRandCommit is a public randomness commitment of a finality provider
+// together with the chain id the signer derives its randomness for
+type PubRandCommit struct {
+	// FpBtcPk is the BIP340 public key of the finality provider
+	FpBtcPk []byte `json:"fp_btc_pk"`
+	// StartHeight is the height of the first randomness in the commitment
+	StartHeight uint64 `json:"start_height"`
+	// NumPubRand is the number of randomness values in the commitment
+	NumPubRand uint64 `json:"num_pub_rand"`
+	// Commitment is the merkle root over the public randomness
+	Commitment []byte `json:"commitment"`
+	// ChainID is the chain id the signer derives the randomness for. The
+	// secret randomness is never stored, since together with a signature
+	// it reveals the FP secret key.
+	ChainID []byte `json:"chain_id"`
+	// CreatedAt is the time the commitment was stored
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EndHeight returns the last height covered by the commitment
+func (c *PubRandCommit) EndHeight() uint64 {
+	return c.StartHeight + c.NumPubRand - 1
+}
+
+// Validate checks that the commitment is consistent
+func (c *PubRandCommit) Validate() error {
+	if len(c.FpBtcPk) == 0 {
+		return fmt.Errorf("finality provider public key must be set")
+	}
+	if c.NumPubRand == 0 {
+		return fmt.Errorf("num pub rand must be positive")
+	}
+	if c.StartHeight+c.NumPubRand < c.StartHeight {
+		return fmt.Errorf("height range starting at %d with %d values overflows", c.StartHeight, c.NumPubRand)
+	}
+	if len(c.ChainID) == 0 {
+		return fmt.Errorf("chain id the randomness is derived for must be set")
+	}
+	if len(c.Commitment) == 0 {
+		return fmt.Errorf("commitment must be set")
+	}
+	return nil
+}
+
+// SavePubRandCommit stores a commitment. Saving the same commitment again is
+// a no-op; a commitment overlapping the height range of a different stored
+// one is rejected, since the randomness of a height must be unique.
+func (s *Store) SavePubRandCommit(c *PubRandCommit) error {
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid commitment: %w", err)
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, pubRandBucket, c.FpBtcPk, true)
+		if err != nil {
+			return err
+		}
+
+		// Check the commitments around the new range for overlaps
+		cur := b.Cursor()
+		k, v := cur.Seek(heightKey(c.EndHeight()))
+		if k == nil || binary.BigEndian.Uint64(k) > c.EndHeight() {
+			k, v = cur.Prev()
+		}
+		for ; k != nil; k, v = cur.Prev() {
+			var stored PubRandCommit
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return fmt.Errorf("failed to decode commitment at height %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if stored.EndHeight() < c.StartHeight {
+				break
+			}
+			if stored.StartHeight == c.StartHeight && stored.NumPubRand == c.NumPubRand &&
+				bytes.Equal(stored.Commitment, c.Commitment) {
+				return nil
+			}
+			return fmt.Errorf("commitment [%d, %d] overlaps stored commitment [%d, %d]",
+				c.StartHeight, c.EndHeight(), stored.StartHeight, stored.EndHeight())
+		}
+
+		data, err := json.Marshal(c)
+		if err != nil {
+			return fmt.Errorf("failed to encode commitment: %w", err)
+		}
+		return b.Put(heightKey(c.StartHeight), data)
+	})
+}
+
+// GetPubRandCommit returns the stored commitment of fpBtcPk covering height,
+// or ErrNotFound
+func (s *Store) GetPubRandCommit(fpBtcPk []byte, height uint64) (*PubRandCommit, error) {
+	var commit *PubRandCommit
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, pubRandBucket, fpBtcPk, false)
+		if err != nil || b == nil {
+			return err
+		}
+
+		// The covering commitment is the last one starting at or below height
+		cur := b.Cursor()
+		k, v := cur.Seek(heightKey(height))
+		if k == nil || binary.BigEndian.Uint64(k) > height {
+			k, v = cur.Prev()
+		}
+		if k == nil {
+			return nil
+		}
+
+		var stored PubRandCommit
+		if err := json.Unmarshal(v, &stored); err != nil {
+			return fmt.Errorf("failed to decode commitment at height %d: %w", binary.BigEndian.Uint64(k), err)
+		}
+		if stored.EndHeight() >= height {
+			commit = &stored
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if commit == nil {
+		return nil, fmt.Errorf("commitment covering height %d: %w", height, ErrNotFound)
+	}
+	return commit, nil
+}
+
+// ListPubRandCommits returns all stored commitments of fpBtcPk ordered by
+// start height
+func (s *Store) ListPubRandCommits(fpBtcPk []byte) ([]*PubRandCommit, error) {
+	var commits []*PubRandCommit
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, pubRandBucket, fpBtcPk, false)
+		if err != nil || b == nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var stored PubRandCommit
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return fmt.Errorf("failed to decode commitment at height %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			commits = append(commits, &stored)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
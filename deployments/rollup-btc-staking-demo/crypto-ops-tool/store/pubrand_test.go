@@ -0,0 +1,103 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func pubRandCommit(start, num uint64, commitment byte) *PubRandCommit {
+	return &PubRandCommit{
+		FpBtcPk:     fpPk,
+		StartHeight: start,
+		NumPubRand:  num,
+		Commitment:  hash(commitment),
+		ChainID:     []byte("consumer-id"),
+	}
+}
+
+func TestSavePubRandCommitRejectsOverlap(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.SavePubRandCommit(pubRandCommit(100, 100, 0x01)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving the same commitment again is a no-op
+	if err := s.SavePubRandCommit(pubRandCommit(100, 100, 0x01)); err != nil {
+		t.Fatalf("saving the same commitment again: %v", err)
+	}
+
+	for name, c := range map[string]*PubRandCommit{
+		"same range":     pubRandCommit(100, 100, 0x02),
+		"overlaps start": pubRandCommit(50, 51, 0x02),
+		"overlaps end":   pubRandCommit(199, 10, 0x02),
+		"inside":         pubRandCommit(150, 1, 0x02),
+		"around":         pubRandCommit(1, 1000, 0x02),
+	} {
+		if err := s.SavePubRandCommit(c); err == nil || !strings.Contains(err.Error(), "overlaps") {
+			t.Errorf("%s: err = %v, want an overlap", name, err)
+		}
+	}
+
+	// Adjacent ranges do not overlap
+	if err := s.SavePubRandCommit(pubRandCommit(50, 50, 0x03)); err != nil {
+		t.Fatalf("range ending before the stored one: %v", err)
+	}
+	if err := s.SavePubRandCommit(pubRandCommit(200, 50, 0x04)); err != nil {
+		t.Fatalf("range starting after the stored one: %v", err)
+	}
+
+	commits, err := s.ListPubRandCommits(fpPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 3 || commits[0].StartHeight != 50 || commits[1].StartHeight != 100 || commits[2].StartHeight != 200 {
+		t.Fatalf("stored commitments = %+v", commits)
+	}
+}
+
+func TestGetPubRandCommitBoundaries(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.SavePubRandCommit(pubRandCommit(100, 100, 0x01)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SavePubRandCommit(pubRandCommit(300, 10, 0x02)); err != nil {
+		t.Fatal(err)
+	}
+
+	for height, wantStart := range map[uint64]uint64{100: 100, 150: 100, 199: 100, 300: 300, 309: 300} {
+		c, err := s.GetPubRandCommit(fpPk, height)
+		if err != nil {
+			t.Errorf("height %d: %v", height, err)
+			continue
+		}
+		if c.StartHeight != wantStart {
+			t.Errorf("height %d: commitment starting at %d, want %d", height, c.StartHeight, wantStart)
+		}
+	}
+	for _, height := range []uint64{0, 99, 200, 299, 310} {
+		if _, err := s.GetPubRandCommit(fpPk, height); !errors.Is(err, ErrNotFound) {
+			t.Errorf("height %d: err = %v, want ErrNotFound", height, err)
+		}
+	}
+	if _, err := s.GetPubRandCommit(hash(0x0b), 150); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other FP: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPubRandCommitValidate(t *testing.T) {
+	s := openTestStore(t)
+	for name, edit := range map[string]func(c *PubRandCommit){
+		"no FP":         func(c *PubRandCommit) { c.FpBtcPk = nil },
+		"no values":     func(c *PubRandCommit) { c.NumPubRand = 0 },
+		"overflow":      func(c *PubRandCommit) { c.StartHeight = ^uint64(0) },
+		"no chain id":   func(c *PubRandCommit) { c.ChainID = nil },
+		"no commitment": func(c *PubRandCommit) { c.Commitment = nil },
+	} {
+		c := pubRandCommit(100, 100, 0x01)
+		edit(c)
+		if err := s.SavePubRandCommit(c); err == nil {
+			t.Errorf("%s: invalid commitment saved", name)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SignRecord records a finality signature produced for a block
+type SignRecord struct {
+	// FpBtcPk is the BIP340 public key of the finality provider
+	FpBtcPk []byte `json:"fp_btc_pk"`
+	// Height is the height of the signed block
+	Height uint64 `json:"height"`
+	// BlockHash is the hash of the signed block
+	BlockHash []byte `json:"block_hash"`
+	// Signature is the EOTS signature over height || block hash
+	Signature []byte `json:"signature"`
+	// SignedAt is the time the signature was produced
+	SignedAt time.Time `json:"signed_at"`
+}
+
+// SaveSignRecord records a finality signature. A signature for a height
+// already signed over a different block is an equivocation that leaks the
+// FP's secret key, so it is refused with ErrDoubleSign unless
+// allowEquivocation is set, in which case the first record is kept.
+func (s *Store) SaveSignRecord(r *SignRecord, allowEquivocation bool) error {
+	if r.SignedAt.IsZero() {
+		r.SignedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode sign record: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, signedBucket, r.FpBtcPk, true)
+		if err != nil {
+			return err
+		}
+		if v := b.Get(heightKey(r.Height)); v != nil {
+			var stored SignRecord
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return fmt.Errorf("failed to decode sign record at height %d: %w", r.Height, err)
+			}
+			if err := checkDoubleSign(&stored, r.BlockHash); err != nil && !allowEquivocation {
+				return err
+			}
+			return nil
+		}
+		return b.Put(heightKey(r.Height), data)
+	})
+}
+
+// CheckDoubleSign returns ErrDoubleSign if fpBtcPk already signed height
+// over a block other than blockHash
+func (s *Store) CheckDoubleSign(fpBtcPk []byte, height uint64, blockHash []byte) error {
+	stored, err := s.GetSignRecord(fpBtcPk, height)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkDoubleSign(stored, blockHash)
+}
+
+func checkDoubleSign(stored *SignRecord, blockHash []byte) error {
+	if bytes.Equal(stored.BlockHash, blockHash) {
+		return nil
+	}
+	return fmt.Errorf("%w: height %d was already signed for block %x, refusing to sign block %x",
+		ErrDoubleSign, stored.Height, stored.BlockHash, blockHash)
+}
+
+// GetSignRecord returns the signature fpBtcPk produced at height, or
+// ErrNotFound
+func (s *Store) GetSignRecord(fpBtcPk []byte, height uint64) (*SignRecord, error) {
+	var record *SignRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, signedBucket, fpBtcPk, false)
+		if err != nil || b == nil {
+			return err
+		}
+		v := b.Get(heightKey(height))
+		if v == nil {
+			return nil
+		}
+		record = &SignRecord{}
+		if err := json.Unmarshal(v, record); err != nil {
+			return fmt.Errorf("failed to decode sign record at height %d: %w", height, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("sign record at height %d: %w", height, ErrNotFound)
+	}
+	return record, nil
+}
+
+// LastSignedHeight returns the highest height fpBtcPk signed, or ErrNotFound
+func (s *Store) LastSignedHeight(fpBtcPk []byte) (uint64, error) {
+	var (
+		height uint64
+		found  bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := fpBucket(tx, signedBucket, fpBtcPk, false)
+		if err != nil || b == nil {
+			return err
+		}
+		k, _ := b.Cursor().Last()
+		if k != nil {
+			height, found = binary.BigEndian.Uint64(k), true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("signed height: %w", ErrNotFound)
+	}
+	return height, nil
+}
+
+// ListSignRecords returns the signature records of fpBtcPk ordered by
+// height, or of all finality providers when fpBtcPk is empty
+func (s *Store) ListSignRecords(fpBtcPk []byte) ([]*SignRecord, error) {
+	var records []*SignRecord
+	collect := func(b *bolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			var record SignRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("failed to decode sign record at height %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			records = append(records, &record)
+			return nil
+		})
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if len(fpBtcPk) > 0 {
+			b, err := fpBucket(tx, signedBucket, fpBtcPk, false)
+			if err != nil || b == nil {
+				return err
+			}
+			return collect(b)
+		}
+		return tx.Bucket(signedBucket).ForEach(func(fpPk, _ []byte) error {
+			return collect(tx.Bucket(signedBucket).Bucket(fpPk))
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ImportSignRecords merges signature records exported from another store,
+// typically when an FP migrates between machines. Records already present
+// are skipped; the import is aborted without changes if any record
+// conflicts with the local history. It returns the number of new records.
+func (s *Store) ImportSignRecords(records []*SignRecord) (int, error) {
+	imported := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, r := range records {
+			if len(r.BlockHash) == 0 {
+				return fmt.Errorf("sign record at height %d has no block hash", r.Height)
+			}
+			b, err := fpBucket(tx, signedBucket, r.FpBtcPk, true)
+			if err != nil {
+				return err
+			}
+			if v := b.Get(heightKey(r.Height)); v != nil {
+				var stored SignRecord
+				if err := json.Unmarshal(v, &stored); err != nil {
+					return fmt.Errorf("failed to decode sign record at height %d: %w", r.Height, err)
+				}
+				if err := checkDoubleSign(&stored, r.BlockHash); err != nil {
+					return fmt.Errorf("FP %x: %w", r.FpBtcPk, err)
+				}
+				continue
+			}
+
+			data, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to encode sign record: %w", err)
+			}
+			if err := b.Put(heightKey(r.Height), data); err != nil {
+				return err
+			}
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func signRecord(height uint64, blockHash []byte) *SignRecord {
+	return &SignRecord{FpBtcPk: fpPk, Height: height, BlockHash: blockHash, Signature: hash(0xee)}
+}
+
+func TestSaveSignRecordRefusesDoubleSign(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.SaveSignRecord(signRecord(42, hash(0x01)), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveSignRecord(signRecord(42, hash(0x02)), false); !errors.Is(err, ErrDoubleSign) {
+		t.Fatalf("err = %v, want ErrDoubleSign", err)
+	}
+	if err := s.CheckDoubleSign(fpPk, 42, hash(0x02)); !errors.Is(err, ErrDoubleSign) {
+		t.Fatalf("CheckDoubleSign = %v, want ErrDoubleSign", err)
+	}
+
+	// Signing the same block again is no equivocation
+	if err := s.CheckDoubleSign(fpPk, 42, hash(0x01)); err != nil {
+		t.Fatalf("CheckDoubleSign of the signed block: %v", err)
+	}
+	if err := s.SaveSignRecord(signRecord(42, hash(0x01)), false); err != nil {
+		t.Fatalf("re-signing the same block: %v", err)
+	}
+
+	// Other heights and FPs are independent
+	if err := s.CheckDoubleSign(fpPk, 43, hash(0x02)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckDoubleSign(hash(0x0b), 42, hash(0x02)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveSignRecordAllowEquivocationKeepsFirst(t *testing.T) {
+	s := openTestStore(t)
+
+	if err := s.SaveSignRecord(signRecord(42, hash(0x01)), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveSignRecord(signRecord(42, hash(0x02)), true); err != nil {
+		t.Fatalf("equivocation not allowed: %v", err)
+	}
+
+	record, err := s.GetSignRecord(fpPk, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record.BlockHash, hash(0x01)) {
+		t.Fatalf("stored block %x, want the first one", record.BlockHash)
+	}
+}
+
+func TestImportSignRecordsConflictWritesNothing(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.SaveSignRecord(signRecord(42, hash(0x01)), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The conflicting record comes after a new one, which must not be kept
+	_, err := s.ImportSignRecords([]*SignRecord{
+		signRecord(41, hash(0x03)),
+		signRecord(42, hash(0x02)),
+	})
+	if !errors.Is(err, ErrDoubleSign) {
+		t.Fatalf("err = %v, want ErrDoubleSign", err)
+	}
+	if _, err := s.GetSignRecord(fpPk, 41); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("record of an aborted import was written: %v", err)
+	}
+}
+
+func TestImportSignRecordsRoundTrip(t *testing.T) {
+	src := openTestStore(t)
+	for h := uint64(1); h <= 3; h++ {
+		if err := src.SaveSignRecord(signRecord(h, hash(byte(h))), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exported, err := src.ListSignRecords(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := openTestStore(t)
+	if err := dst.SaveSignRecord(signRecord(2, hash(0x02)), false); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := dst.ImportSignRecords(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 {
+		t.Errorf("imported %d records, want the 2 missing ones", imported)
+	}
+
+	got, err := dst.ListSignRecords(fpPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("%d records after import, want 3", len(got))
+	}
+	for i, r := range got {
+		if r.Height != exported[i].Height || !bytes.Equal(r.BlockHash, exported[i].BlockHash) {
+			t.Errorf("record %d = height %d block %x", i, r.Height, r.BlockHash)
+		}
+	}
+	last, err := dst.LastSignedHeight(fpPk)
+	if err != nil || last != 3 {
+		t.Errorf("last signed height = %d, %v", last, err)
+	}
+
+	// Importing the same history again adds nothing
+	if imported, err := dst.ImportSignRecords(exported); err != nil || imported != 0 {
+		t.Errorf("re-import = %d, %v", imported, err)
+	}
+}
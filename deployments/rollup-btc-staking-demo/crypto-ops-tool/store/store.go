@@ -0,0 +1,115 @@
+// Package store persists the public randomness committed by finality
+// providers and the blocks they signed in an embedded bbolt database, the
+// same storage the finality provider daemon uses, so that randomness
+// survives restarts of crypto-ops.
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DBFileName is the name of the database file inside the data directory
+	DBFileName = "crypto-ops.db"
+	// dataDirName is the directory under the home directory holding the database
+	dataDirName = "data"
+)
+
+var (
+	// ErrNotFound is returned when no record exists for the requested key
+	ErrNotFound = errors.New("not found")
+	// ErrDoubleSign is returned when a height would be signed over a second,
+	// different block
+	ErrDoubleSign = errors.New("double sign")
+
+	// pubRandBucket holds one sub-bucket per FP public key, mapping the
+	// start height of each commitment to the commitment
+	pubRandBucket = []byte("pub_rand_commits")
+	// signedBucket holds one sub-bucket per FP public key, mapping each
+	// signed height to the signature record
+	signedBucket = []byte("signed_blocks")
+)
+
+// Store is the on-disk randomness and signing store of crypto-ops
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store under homeDir, creating the directory and the
+// database if they do not exist
+func Open(homeDir string) (*Store, error) {
+	if homeDir == "" {
+		return nil, fmt.Errorf("home directory must be set")
+	}
+
+	dataDir := filepath.Join(homeDir, dataDirName)
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory %s: %w", dataDir, err)
+	}
+
+	dbPath := filepath.Join(dataDir, DBFileName)
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("database %s is locked by another process using the same home directory", dbPath)
+		}
+		return nil, fmt.Errorf("failed to open database %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{pubRandBucket, signedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Path returns the path of the database file
+func (s *Store) Path() string {
+	return s.db.Path()
+}
+
+// fpBucket returns the sub-bucket of fpBtcPk inside the given top-level
+// bucket, creating it when create is set. It returns nil when the bucket
+// does not exist and create is not set.
+func fpBucket(tx *bolt.Tx, name []byte, fpBtcPk []byte, create bool) (*bolt.Bucket, error) {
+	if len(fpBtcPk) == 0 {
+		return nil, fmt.Errorf("finality provider public key must be set")
+	}
+	parent := tx.Bucket(name)
+	if parent == nil {
+		return nil, fmt.Errorf("bucket %s does not exist", name)
+	}
+	if !create {
+		return parent.Bucket(fpBtcPk), nil
+	}
+	return parent.CreateBucketIfNotExists(fpBtcPk)
+}
+
+// heightKey encodes a height as a big endian key so that bbolt cursors
+// iterate in height order
+func heightKey(height uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, height)
+	return key
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+// fpPk is the public key of the finality provider of the tests
+var fpPk = bytes.Repeat([]byte{0x0a}, 32)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func hash(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
@@ -0,0 +1,45 @@
+#!/bin/bash
+
+set -e  # Exit on any error
+
+# Regression test of the slashing path against a deployment on which
+# rollup-btc-staking-demo.sh has run: the consumer FP votes for a second,
+# conflicting block at a height it already signed, and the contract must
+# get it slashed on Babylon.
+#
+# Usage: ./equivocation-scenario.sh [block_height]
+
+CRYPTO_OPS_HOME=".testnets/crypto-ops"
+BLOCK_HEIGHT=${1:-5}
+
+if [ ! -f "$CRYPTO_OPS_HOME/demo.env" ]; then
+    echo "❌ $CRYPTO_OPS_HOME/demo.env not found, run the demo first (make run-demo-only)"
+    exit 1
+fi
+source "$CRYPTO_OPS_HOME/demo.env"
+
+echo "⚔️  Running equivocation scenario at height $BLOCK_HEIGHT"
+echo "=================================================="
+
+echo "🔧 Building crypto operations tool..."
+cd crypto-ops-tool
+go build -o ../crypto-ops ./cmd/crypto-ops
+cd ../
+
+echo "  → Consumer FP: $consumer_btc_pk"
+echo "  → Finality contract: $finalityContractAddr"
+
+# The FP's randomness and its vote at BLOCK_HEIGHT come from the demo's store
+if ! report=$(./crypto-ops equivocation-scenario --home $CRYPTO_OPS_HOME \
+    --passphrase-file $CRYPTO_OPS_PASSPHRASE_FILE --key $CONSUMER_FP_KEY --chain-id $BBN_CHAIN_ID \
+    --slashing-timeout 2m $finalityContractAddr $BLOCK_HEIGHT); then
+    echo "  → Report: $report"
+    echo "❌ Equivocation scenario failed: the FP was not slashed"
+    exit 1
+fi
+
+echo "  → Report: $report"
+echo ""
+echo "✅ Slashing event emitted by the contract: $(echo "$report" | jq -r '.slashing_event')"
+echo "✅ FP slashed at Babylon height: $(echo "$report" | jq -r '.babylon.slashed_babylon_height')"
+echo "✅ FP jailed: $(echo "$report" | jq -r '.jailed')"
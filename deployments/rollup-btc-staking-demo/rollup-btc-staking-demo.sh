@@ -6,6 +6,15 @@ sleep 10 # wait for containers to be ready (cov emulator takes a while to start)
 
 BBN_CHAIN_ID="chain-test"
 CONSUMER_ID="consumer-id"
+# Randomness and signatures of the consumer FP are kept here by crypto-ops
+CRYPTO_OPS_HOME=".testnets/crypto-ops"
+# The FP BTC keys live encrypted in the crypto-ops keystore under
+# $CRYPTO_OPS_HOME/keys and are referenced by name, never passed on argv.
+# The demo encrypts them with a random passphrase kept next to the testnet.
+CRYPTO_OPS_PASSPHRASE_FILE=".testnets/crypto-ops-passphrase"
+CRYPTO_OPS_KEY_FLAGS="--home $CRYPTO_OPS_HOME --passphrase-file $CRYPTO_OPS_PASSPHRASE_FILE"
+BBN_FP_KEY="bbn-fp"
+CONSUMER_FP_KEY="consumer-fp"
 
 echo "🚀 Starting Enhanced BTC Staking Integration Demo"
 echo "=================================================="
@@ -17,12 +26,22 @@ go build -o ../crypto-ops ./cmd/crypto-ops
 cd ../
 echo "  ✅ Crypto operations tool built successfully"
 
+# Wait for the tx of a `babylond --output json` broadcast to be included
+# instead of sleeping for a fixed time. Fails if the tx does not make it
+# into a block or is rejected by DeliverTx.
+wait_for_tx() {
+    local tx_hash
+    tx_hash=$(echo "$1" | jq -r '.txhash')
+    echo "  → Waiting for tx $tx_hash to be included..."
+    local tx_result
+    tx_result=$(./crypto-ops wait-tx --wait-timeout 2m "$tx_hash")
+    echo "  → Included: $tx_result"
+}
+
 # Get admin address for contract instantiation
 admin=$(docker exec babylondnode0 /bin/sh -c "/bin/babylond --home /babylondhome keys show test-spending-key --keyring-backend test --output json | jq -r '.address'")
 echo "Using admin address: $admin"
 
-sleep 5
-
 ###############################
 # Step 1: Deploy Finality     #
 # Contract                    #
@@ -36,8 +55,7 @@ STORE_CMD="/bin/babylond --home /babylondhome tx wasm store /contracts/op_finali
 echo "  → Command: $STORE_CMD"
 STORE_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$STORE_CMD")
 echo "  → Output: $STORE_OUTPUT"
-
-sleep 10
+wait_for_tx "$STORE_OUTPUT"
 
 echo "  → Instantiating contract..."
 INSTANTIATE_MSG_JSON="{\"admin\":\"$admin\",\"consumer_id\":\"$CONSUMER_ID\",\"is_enabled\":true}"
@@ -45,8 +63,7 @@ INSTANTIATE_CMD="/bin/babylond --home /babylondhome tx wasm instantiate 1 '$INST
 echo "  → Command: $INSTANTIATE_CMD"
 INSTANTIATE_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$INSTANTIATE_CMD")
 echo "  → Output: $INSTANTIATE_OUTPUT"
-
-sleep 10
+wait_for_tx "$INSTANTIATE_OUTPUT"
 
 # Extract contract address
 finalityContractAddr=$(docker exec babylondnode0 /bin/sh -c "/bin/babylond --home /babylondhome q wasm list-contracts-by-code 1 --output json | jq -r '.contracts[0]'")
@@ -63,8 +80,7 @@ REGISTER_CMD="/bin/babylond --home /babylondhome tx btcstkconsumer register-cons
 echo "  → Command: $REGISTER_CMD"
 REGISTER_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$REGISTER_CMD")
 echo "  → Output: $REGISTER_OUTPUT"
-
-sleep 10
+wait_for_tx "$REGISTER_OUTPUT"
 echo "  ✅ Consumer '$CONSUMER_ID' registered successfully"
 
 ###############################
@@ -76,19 +92,31 @@ echo "🔐 Step 3: Generating cryptographic keys..."
 
 echo "  → Generating BTC key pairs for finality providers..."
 
-# Generate key pairs using the Go tool and parse JSON output
-bbn_fp_json=$(./crypto-ops generate-keypair)
+mkdir -p $CRYPTO_OPS_HOME
+if [ ! -f $CRYPTO_OPS_PASSPHRASE_FILE ]; then
+    (umask 077 && head -c 32 /dev/urandom | base64 > $CRYPTO_OPS_PASSPHRASE_FILE)
+fi
+# Every run registers new finality providers
+rm -rf $CRYPTO_OPS_HOME/keys
+
+# Generate key pairs into the keystore; only the public keys are printed.
+# With FP_MNEMONIC_FILE, the keys are derived from that BIP39 mnemonic so
+# that every run uses the same FP identities.
+bbn_key_source=""
+consumer_key_source=""
+if [ -n "$FP_MNEMONIC_FILE" ]; then
+    echo "  → Deriving FP keys from the mnemonic in $FP_MNEMONIC_FILE"
+    bbn_key_source="--mnemonic-file $FP_MNEMONIC_FILE --index 0"
+    consumer_key_source="--mnemonic-file $FP_MNEMONIC_FILE --index 1"
+fi
+bbn_fp_json=$(./crypto-ops generate-keypair $CRYPTO_OPS_KEY_FLAGS --key $BBN_FP_KEY $bbn_key_source)
 bbn_btc_pk=$(echo "$bbn_fp_json" | jq -r '.public_key')
-bbn_btc_sk=$(echo "$bbn_fp_json" | jq -r '.private_key')
 
-consumer_fp_json=$(./crypto-ops generate-keypair)
+consumer_fp_json=$(./crypto-ops generate-keypair $CRYPTO_OPS_KEY_FLAGS --key $CONSUMER_FP_KEY $consumer_key_source)
 consumer_btc_pk=$(echo "$consumer_fp_json" | jq -r '.public_key')
-consumer_btc_sk=$(echo "$consumer_fp_json" | jq -r '.private_key')
 
-echo "  ✅ Babylon FP BTC PK: $bbn_btc_pk"
-echo "  ✅ Babylon FP BTC SK: $bbn_btc_sk"
-echo "  ✅ Consumer FP BTC PK: $consumer_btc_pk"
-echo "  ✅ Consumer FP BTC SK: $consumer_btc_sk"
+echo "  ✅ Babylon FP BTC PK: $bbn_btc_pk (key $BBN_FP_KEY)"
+echo "  ✅ Consumer FP BTC PK: $consumer_btc_pk (key $CONSUMER_FP_KEY)"
 
 ###############################
 # Step 4: Create Finality     #
@@ -105,25 +133,22 @@ echo "  → Using admin address for PoP: $admin"
 echo "  → Creating Babylon Finality Provider..."
 
 # Generate PoP for Babylon FP using crypto-ops
-bbn_pop_json=$(./crypto-ops generate-pop $bbn_btc_sk $admin)
+bbn_pop_json=$(./crypto-ops generate-pop $CRYPTO_OPS_KEY_FLAGS --key $BBN_FP_KEY $admin)
 bbn_pop_hex=$(echo "$bbn_pop_json" | jq -r '.pop_hex')
 
-sleep 15
-
 # Create Babylon FP on-chain
 BBN_FP_CMD="/bin/babylond --home /babylondhome tx btcstaking create-finality-provider $bbn_btc_pk $bbn_pop_hex --from test-spending-key --moniker 'Babylon FP' --commission-rate 0.05 --commission-max-rate 0.10 --commission-max-change-rate 0.01 --chain-id $BBN_CHAIN_ID --keyring-backend test --gas-prices=1ubbn --output json -y"
 echo "  → Command: $BBN_FP_CMD"
 BBN_FP_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$BBN_FP_CMD")
 echo "  → Output: $BBN_FP_OUTPUT"
-
-sleep 15
+wait_for_tx "$BBN_FP_OUTPUT"
 
 echo "  ✅ Babylon FP created successfully"
 
 echo "  → Creating Consumer Finality Provider..."
 
 # Generate PoP for Consumer FP using crypto-ops
-consumer_pop_json=$(./crypto-ops generate-pop $consumer_btc_sk $admin)
+consumer_pop_json=$(./crypto-ops generate-pop $CRYPTO_OPS_KEY_FLAGS --key $CONSUMER_FP_KEY $admin)
 consumer_pop_hex=$(echo "$consumer_pop_json" | jq -r '.pop_hex')
 
 # Create Consumer FP on-chain (note the --consumer-id flag)
@@ -131,8 +156,7 @@ CONSUMER_FP_CMD="/bin/babylond --home /babylondhome tx btcstaking create-finalit
 echo "  → Command: $CONSUMER_FP_CMD"
 CONSUMER_FP_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$CONSUMER_FP_CMD")
 echo "  → Output: $CONSUMER_FP_OUTPUT"
-
-sleep 15
+wait_for_tx "$CONSUMER_FP_OUTPUT"
 
 echo "  ✅ Consumer FP created successfully"
 
@@ -210,7 +234,7 @@ echo "    Start height: $start_height, Number of commitments: $num_pub_rand"
 
 # Step 7a: Generate public randomness commitment data using crypto-only command
 echo "  → Generating public randomness commitment data for blocks $start_height to $((start_height + num_pub_rand - 1))..."
-pub_rand_data=$(./crypto-ops generate-pub-rand-commitment $consumer_btc_sk $start_height $num_pub_rand)
+pub_rand_data=$(./crypto-ops generate-pub-rand-commitment $CRYPTO_OPS_KEY_FLAGS --key $CONSUMER_FP_KEY --consumer-id $CONSUMER_ID $start_height $num_pub_rand)
 
 if [ $? -ne 0 ]; then
     echo "  ❌ Failed to generate public randomness commitment data"
@@ -219,41 +243,24 @@ fi
 
 echo "  ✅ Public randomness commitment data generated successfully!"
 
-# Extract data from JSON response  
-rand_list_info_json=$(echo "$pub_rand_data" | jq -r '.rand_list_info')
+# Extract data from JSON response (the randomness itself stays in $CRYPTO_OPS_HOME)
 fp_pubkey_hex=$(echo "$pub_rand_data" | jq -r '.fp_pubkey_hex')
-commitment=$(echo "$pub_rand_data" | jq -c '.commitment')  # Keep as JSON array
-signature=$(echo "$pub_rand_data" | jq -c '.signature')    # Keep as JSON array
+commitment=$(echo "$pub_rand_data" | jq -c '.commitment')
 
 echo "  → Submitting commitment to finality contract..."
 echo "    Contract: $finalityContractAddr"
 echo "    FP PubKey: $fp_pubkey_hex"
 echo "    Commitment: $commitment"
 
-# Create the commit message for the finality contract  
-commit_msg=$(jq -n \
-  --arg fp_pubkey_hex "$fp_pubkey_hex" \
-  --argjson start_height "$start_height" \
-  --argjson num_pub_rand "$num_pub_rand" \
-  --argjson commitment "$commitment" \
-  --argjson signature "$signature" \
-  '{
-    commit_public_randomness: {
-      fp_pubkey_hex: $fp_pubkey_hex,
-      start_height: $start_height,
-      num_pub_rand: $num_pub_rand,
-      commitment: $commitment,
-      signature: $signature
-    }
-  }')
+# The commit message for the finality contract is built by crypto-ops
+commit_msg=$(echo "$pub_rand_data" | jq -c '.contract_message')
 
 # Submit to finality contract using wasm execute
 COMMIT_CMD="/bin/babylond --home /babylondhome tx wasm execute $finalityContractAddr '$commit_msg' --from test-spending-key --chain-id $BBN_CHAIN_ID --keyring-backend test --gas 500000 --fees 100000ubbn -y --output json"
 echo "  → Command: $COMMIT_CMD"
 COMMIT_OUTPUT=$(docker exec babylondnode0 /bin/sh -c "$COMMIT_CMD")
 echo "  → Output: $COMMIT_OUTPUT"
-
-sleep 8
+wait_for_tx "$COMMIT_OUTPUT"
 
 # Verify the commitment was stored
 echo "  → Verifying commitment was stored..."
@@ -280,7 +287,7 @@ for ((block_height=start_height; block_height<start_height+num_finality_sigs; bl
     
     # Generate finality signature using crypto-only command (block hash generated internally)
     echo "    → Generating finality signature (crypto-only)..."
-    finality_sig_data=$(echo "$rand_list_info_json" | ./crypto-ops generate-finality-sig $consumer_btc_sk $block_height)
+    finality_sig_data=$(./crypto-ops generate-finality-sig $CRYPTO_OPS_KEY_FLAGS --key $CONSUMER_FP_KEY $block_height)
     
     if [ $? -ne 0 ]; then
         echo "    ❌ Block $block_height: Failed to generate finality signature"
@@ -292,31 +299,11 @@ for ((block_height=start_height; block_height<start_height+num_finality_sigs; bl
     # Extract signature data from JSON response (using proper JSON handling)
     sig_fp_pubkey_hex=$(echo "$finality_sig_data" | jq -r '.fp_pubkey_hex')
     sig_height=$(echo "$finality_sig_data" | jq -r '.height')
-    sig_pub_rand=$(echo "$finality_sig_data" | jq -c '.pub_rand')          # Keep as JSON array
-    sig_proof=$(echo "$finality_sig_data" | jq -c '.proof')                # Keep as JSON object
-    sig_block_hash=$(echo "$finality_sig_data" | jq -c '.block_hash')      # Keep as JSON array
-    sig_signature=$(echo "$finality_sig_data" | jq -c '.signature')        # Keep as JSON array
     
     echo "    → Submitting finality signature to contract..."
     
-    # Create finality signature message for the contract
-    finality_msg=$(jq -n \
-      --arg fp_pubkey_hex "$sig_fp_pubkey_hex" \
-      --argjson height "$sig_height" \
-      --argjson pub_rand "$sig_pub_rand" \
-      --argjson proof "$sig_proof" \
-      --argjson block_hash "$sig_block_hash" \
-      --argjson signature "$sig_signature" \
-      '{
-        submit_finality_signature: {
-          fp_pubkey_hex: $fp_pubkey_hex,
-          height: $height,
-          pub_rand: $pub_rand,
-          proof: $proof,
-          block_hash: $block_hash,
-          signature: $signature
-        }
-      }')
+    # The finality signature message for the contract is built by crypto-ops
+    finality_msg=$(echo "$finality_sig_data" | jq -c '.contract_message')
     
     # Submit to finality contract using wasm execute
     FINALITY_CMD="/bin/babylond --home /babylondhome tx wasm execute $finalityContractAddr '$finality_msg' --from test-spending-key --chain-id $BBN_CHAIN_ID --keyring-backend test --gas 500000 --fees 100000ubbn -y --output json"
@@ -324,7 +311,7 @@ for ((block_height=start_height; block_height<start_height+num_finality_sigs; bl
     echo "    → Submission result: $FINALITY_OUTPUT"
     
     # Verify the signature was recorded
-    sleep 8  # Increased delay for transaction processing
+    wait_for_tx "$FINALITY_OUTPUT"
     echo "    → Verifying finality signature was recorded..."
     
     # Use the hex string directly from Go output (much simpler!)
@@ -362,15 +349,22 @@ for ((block_height=start_height; block_height<start_height+num_finality_sigs; bl
         ((successful_sigs++))
         echo "    ✅ Block $block_height: Finality signature submitted and verified successfully"
     fi
-    
-    # Add small delay to avoid overwhelming the system
-    sleep 2  # Reduced since we have longer delays in verification
 done
 
 echo ""
 echo "🎉 All $num_finality_sigs finality signatures processed successfully!"
 echo "  📊 Successfully processed blocks $start_height to $((start_height + num_finality_sigs - 1))"
 
+# Keep what follow-up scenarios such as equivocation-scenario.sh need
+cat > $CRYPTO_OPS_HOME/demo.env <<EOF
+BBN_CHAIN_ID=$BBN_CHAIN_ID
+CONSUMER_ID=$CONSUMER_ID
+finalityContractAddr=$finalityContractAddr
+CRYPTO_OPS_PASSPHRASE_FILE=$CRYPTO_OPS_PASSPHRASE_FILE
+CONSUMER_FP_KEY=$CONSUMER_FP_KEY
+consumer_btc_pk=$consumer_btc_pk
+EOF
+
 ###############################
 # Demo Summary                #
 ###############################